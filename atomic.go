@@ -0,0 +1,173 @@
+package xsync
+
+import (
+	"encoding/json"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// A Float64 is an atomic float64. The zero value is zero.
+type Float64 struct {
+	v atomic.Uint64
+}
+
+func (f *Float64) Load() float64 {
+	return math.Float64frombits(f.v.Load())
+}
+
+func (f *Float64) Store(val float64) {
+	f.v.Store(math.Float64bits(val))
+}
+
+// Swap stores val and returns the previous value.
+func (f *Float64) Swap(val float64) float64 {
+	return math.Float64frombits(f.v.Swap(math.Float64bits(val)))
+}
+
+// CompareAndSwap stores val if the current value is old. Values are compared bitwise, so NaN equals NaN.
+func (f *Float64) CompareAndSwap(old, val float64) bool {
+	return f.v.CompareAndSwap(math.Float64bits(old), math.Float64bits(val))
+}
+
+// Add adds delta and returns the new value.
+func (f *Float64) Add(delta float64) float64 {
+	for {
+		old := f.v.Load()
+		val := math.Float64frombits(old) + delta
+		if f.v.CompareAndSwap(old, math.Float64bits(val)) {
+			return val
+		}
+	}
+}
+
+func (f *Float64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Load())
+}
+
+func (f *Float64) UnmarshalJSON(data []byte) error {
+	var val float64
+	if err := json.Unmarshal(data, &val); err != nil {
+		return err
+	}
+	f.Store(val)
+	return nil
+}
+
+// A Duration is an atomic time.Duration. The zero value is zero.
+// It is marshaled to JSON as a duration string, like "1.5s".
+type Duration struct {
+	v atomic.Int64
+}
+
+func (d *Duration) Load() time.Duration {
+	return time.Duration(d.v.Load())
+}
+
+func (d *Duration) Store(val time.Duration) {
+	d.v.Store(int64(val))
+}
+
+// Swap stores val and returns the previous value.
+func (d *Duration) Swap(val time.Duration) time.Duration {
+	return time.Duration(d.v.Swap(int64(val)))
+}
+
+// CompareAndSwap stores val if the current value is old.
+func (d *Duration) CompareAndSwap(old, val time.Duration) bool {
+	return d.v.CompareAndSwap(int64(old), int64(val))
+}
+
+// Add adds delta and returns the new value.
+func (d *Duration) Add(delta time.Duration) time.Duration {
+	return time.Duration(d.v.Add(int64(delta)))
+}
+
+func (d *Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Load().String())
+}
+
+// UnmarshalJSON accepts a duration string or a number of nanoseconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if json.Unmarshal(data, &n) != nil {
+			return err
+		}
+		d.Store(time.Duration(n))
+		return nil
+	}
+	val, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Store(val)
+	return nil
+}
+
+// A Time is an atomic time.Time. The zero value is the zero time.
+// Times are stored with nanosecond precision as Unix time, so the location and
+// the monotonic clock reading are not preserved; Load returns local time.
+type Time struct {
+	v atomic.Int64
+}
+
+func timeToBits(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() ^ math.MinInt64 // so that the Unix epoch is not confused with the zero time
+}
+
+func timeFromBits(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n^math.MinInt64)
+}
+
+func (t *Time) Load() time.Time {
+	return timeFromBits(t.v.Load())
+}
+
+func (t *Time) Store(val time.Time) {
+	t.v.Store(timeToBits(val))
+}
+
+// Swap stores val and returns the previous value.
+func (t *Time) Swap(val time.Time) time.Time {
+	return timeFromBits(t.v.Swap(timeToBits(val)))
+}
+
+// CompareAndSwap stores val if the current value is the same instant as old.
+func (t *Time) CompareAndSwap(old, val time.Time) bool {
+	return t.v.CompareAndSwap(timeToBits(old), timeToBits(val))
+}
+
+// Add adds d to the time and returns the new value. The zero time stays zero.
+func (t *Time) Add(d time.Duration) time.Time {
+	for {
+		old := t.v.Load()
+		if old == 0 {
+			return time.Time{}
+		}
+		val := timeFromBits(old).Add(d)
+		if t.v.CompareAndSwap(old, timeToBits(val)) {
+			return val
+		}
+	}
+}
+
+func (t *Time) MarshalJSON() ([]byte, error) {
+	return t.Load().MarshalJSON()
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var val time.Time
+	if err := val.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	t.Store(val)
+	return nil
+}
@@ -0,0 +1,48 @@
+package xsync
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFloat64(t *testing.T) {
+	var f Float64
+	require(t, 1.5 == f.Add(1.5))
+	require(t, 1.5 == f.Swap(2))
+	require(t, !f.CompareAndSwap(1, 3) && f.CompareAndSwap(2, 3))
+
+	b, _ := json.Marshal(&f)
+	require(t, "3" == string(b))
+	require(t, nil == json.Unmarshal([]byte("4.25"), &f) && 4.25 == f.Load())
+}
+
+func TestDuration(t *testing.T) {
+	var d Duration
+	require(t, time.Second == d.Add(time.Second))
+	require(t, d.CompareAndSwap(time.Second, 1500*time.Millisecond))
+
+	b, _ := json.Marshal(&d)
+	require(t, `"1.5s"` == string(b))
+	require(t, nil == json.Unmarshal([]byte(`"2m"`), &d) && 2*time.Minute == d.Load())
+	require(t, nil == json.Unmarshal([]byte(`1000`), &d) && time.Microsecond == d.Load())
+	require(t, nil != json.Unmarshal([]byte(`"x"`), &d))
+}
+
+func TestTime(t *testing.T) {
+	var tm Time
+	require(t, tm.Load().IsZero() && tm.Add(time.Hour).IsZero())
+
+	now := time.Now()
+	tm.Store(now)
+	require(t, now.Equal(tm.Load()))
+	require(t, now.Add(time.Hour).Equal(tm.Add(time.Hour)))
+
+	epoch := time.Unix(0, 0)
+	require(t, tm.CompareAndSwap(now.Add(time.Hour), epoch))
+	require(t, epoch.Equal(tm.Load()) && !tm.Load().IsZero())
+
+	b, _ := json.Marshal(&tm)
+	var tm2 Time
+	require(t, nil == json.Unmarshal(b, &tm2) && epoch.Equal(tm2.Load()))
+}
@@ -0,0 +1,62 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// A Barrier blocks goroutines calling Wait until n of them have arrived, then releases them all
+// and starts a new generation, so it can be reused for successive phases.
+type Barrier struct {
+	n     int
+	mx    sync.Mutex
+	count int
+	gen   uint64
+	ch    chan struct{} // closed when the current generation trips
+}
+
+// NewBarrier returns a Barrier for n parties.
+func NewBarrier(n int) *Barrier {
+	return &Barrier{n: max(n, 1), ch: make(chan struct{})}
+}
+
+// Wait blocks until n goroutines have called Wait in the current generation and returns the generation.
+// If ctx is done first, the caller withdraws from the generation and Wait returns ctx.Err().
+func (b *Barrier) Wait(ctx context.Context) (gen uint64, err error) {
+	b.mx.Lock()
+	gen, ch := b.gen, b.ch
+	if b.count++; b.count == b.n {
+		b.count, b.gen, b.ch = 0, b.gen+1, make(chan struct{})
+		close(ch)
+		b.mx.Unlock()
+		return gen, nil
+	}
+	b.mx.Unlock()
+
+	select {
+	case <-ch:
+		return gen, nil
+	case <-ctx.Done():
+		b.mx.Lock()
+		defer b.mx.Unlock()
+		if b.gen != gen { // tripped concurrently
+			return gen, nil
+		}
+		b.count--
+		return gen, ctx.Err()
+	}
+}
+
+// Generation returns the number of times the barrier has tripped.
+func (b *Barrier) Generation() uint64 {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.gen
+}
+
+// Waiting returns the number of goroutines waiting in the current generation.
+func (b *Barrier) Waiting() int {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.count
+}
@@ -0,0 +1,39 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBarrier(t *testing.T) {
+	b := NewBarrier(3)
+	var wg sync.WaitGroup
+	gens := make(chan uint64, 6)
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gen, err := b.Wait(context.Background())
+			if err == nil {
+				gens <- gen
+			}
+		}()
+	}
+	wg.Wait()
+	close(gens)
+
+	cnt := map[uint64]int{}
+	for g := range gens {
+		cnt[g]++
+	}
+	require(t, 3 == cnt[0] && 3 == cnt[1])
+	require(t, 2 == b.Generation())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := b.Wait(ctx)
+	require(t, context.DeadlineExceeded == err)
+	require(t, 0 == b.Waiting())
+}
@@ -0,0 +1,95 @@
+package xsync
+
+import (
+	"sync"
+	"time"
+)
+
+// A Batcher accumulates items and passes them to the flush function in batches,
+// either when the batch reaches maxSize items or when maxDelay has passed since the first item of the batch.
+//
+// Flush calls are serialized. A Batcher is safe for use by multiple goroutines simultaneously.
+type Batcher[T any] struct {
+	maxSize  int
+	maxDelay time.Duration
+	flush    func([]T)
+	clock    Clock
+
+	mx     sync.Mutex
+	items  []T
+	timer  Timer
+	closed bool
+
+	flushMx sync.Mutex
+}
+
+// NewBatcher returns a Batcher. A maxSize <= 0 means no size limit; a maxDelay <= 0 means no time limit.
+func NewBatcher[T any](maxSize int, maxDelay time.Duration, flush func([]T), opts ...ClockOption) *Batcher[T] {
+	return &Batcher[T]{
+		maxSize:  maxSize,
+		maxDelay: maxDelay,
+		flush:    flush,
+		clock:    newClock(opts),
+	}
+}
+
+// Add adds the item to the current batch. Returns ErrStopped if the batcher is closed.
+func (b *Batcher[T]) Add(item T) error {
+	b.mx.Lock()
+	if b.closed {
+		b.mx.Unlock()
+		return ErrStopped
+	}
+	b.items = append(b.items, item)
+	if b.maxSize > 0 && len(b.items) >= b.maxSize {
+		items := b.take()
+		b.mx.Unlock()
+		b.deliver(items)
+		return nil
+	}
+	if len(b.items) == 1 && b.maxDelay > 0 {
+		b.timer = b.clock.AfterFunc(b.maxDelay, b.Flush)
+	}
+	b.mx.Unlock()
+	return nil
+}
+
+// Flush passes the current batch (if not empty) to the flush function.
+func (b *Batcher[T]) Flush() {
+	b.flushMx.Lock()
+	defer b.flushMx.Unlock()
+
+	b.mx.Lock()
+	items := b.take()
+	b.mx.Unlock()
+
+	if len(items) > 0 {
+		b.flush(items)
+	}
+}
+
+// take removes and returns the current batch. b.mx must be held.
+func (b *Batcher[T]) take() []T {
+	items := b.items
+	b.items = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return items
+}
+
+// deliver passes a batch taken from the batcher to the flush function.
+func (b *Batcher[T]) deliver(items []T) {
+	b.flushMx.Lock()
+	defer b.flushMx.Unlock()
+	b.flush(items)
+}
+
+// Close flushes the remaining items and stops accepting new ones.
+func (b *Batcher[T]) Close() {
+	b.mx.Lock()
+	b.closed = true
+	b.mx.Unlock()
+	b.Flush()
+}
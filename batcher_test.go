@@ -0,0 +1,56 @@
+package xsync
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcher_maxSize(t *testing.T) {
+	var batches [][]int
+	b := NewBatcher(3, 0, func(items []int) { batches = append(batches, items) })
+
+	for i := 0; i < 7; i++ {
+		require(t, nil == b.Add(i))
+	}
+	b.Close()
+
+	require(t, 3 == len(batches))
+	require(t, 1 == len(batches[2]))
+	require(t, ErrStopped == b.Add(0))
+}
+
+func TestBatcher_maxDelay(t *testing.T) {
+	ch := make(chan []string, 1)
+	b := NewBatcher(100, 10*time.Millisecond, func(items []string) { ch <- items })
+	defer b.Close()
+
+	b.Add("a")
+	b.Add("b")
+
+	require(t, 2 == len(<-ch))
+}
+
+func TestBatcher_concurrentAdd(t *testing.T) {
+	var maxLen, total int
+	b := NewBatcher(3, 0, func(items []int) {
+		maxLen, total = max(maxLen, len(items)), total+len(items)
+		time.Sleep(10 * time.Microsecond) // let other goroutines add meanwhile
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 300; j++ {
+				b.Add(j)
+			}
+		}()
+	}
+	wg.Wait()
+	b.Close()
+
+	require(t, 3 == maxLen)
+	require(t, 2400 == total)
+}
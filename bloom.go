@@ -0,0 +1,110 @@
+package xsync
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// bloomSeed is shared by all Bloom filters, so that filters with the same parameters can be merged.
+var bloomSeed = maphash.MakeSeed()
+
+// A BloomSet is an approximate set of keys: MayContain never returns false for an added key,
+// but may return true for a key that was never added.
+// It uses a fixed amount of memory regardless of the number of keys. Bits are set atomically,
+// so Add and MayContain never block.
+//
+// Hashes are seeded per process, so a BloomSet cannot be persisted or merged across processes.
+type BloomSet[K comparable] struct {
+	words []atomic.Uint64
+	m     uint64 // number of bits
+	k     int    // number of hash functions
+}
+
+// NewBloomSet returns a BloomSet sized for n keys with false-positive rate p.
+func NewBloomSet[K comparable](n int, p float64) *BloomSet[K] {
+	n = max(n, 1)
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = (max(m, 64) + 63) &^ 63
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	return &BloomSet[K]{
+		words: make([]atomic.Uint64, m/64),
+		m:     m,
+		k:     max(k, 1),
+	}
+}
+
+// positions calls fn for each bit position of the key using double hashing.
+func (b *BloomSet[K]) positions(key K, fn func(word int, mask uint64) bool) {
+	h1 := hashKey(bloomSeed, key)
+	h2 := bits.RotateLeft64(h1, 32)*0x9e3779b97f4a7c15 | 1
+	for i := 0; i < b.k; i++ {
+		pos := (h1 + uint64(i)*h2) % b.m
+		if !fn(int(pos/64), 1<<(pos%64)) {
+			return
+		}
+	}
+}
+
+// Add adds the key to the set.
+func (b *BloomSet[K]) Add(key K) {
+	b.positions(key, func(w int, mask uint64) bool {
+		orWord(&b.words[w], mask)
+		return true
+	})
+}
+
+// MayContain reports whether the key may have been added.
+func (b *BloomSet[K]) MayContain(key K) bool {
+	ok := true
+	b.positions(key, func(w int, mask uint64) bool {
+		ok = b.words[w].Load()&mask != 0
+		return ok
+	})
+	return ok
+}
+
+// EstimatedCount estimates the number of distinct keys added from the number of set bits.
+func (b *BloomSet[K]) EstimatedCount() int {
+	var x uint64
+	for i := range b.words {
+		x += uint64(bits.OnesCount64(b.words[i].Load()))
+	}
+	if x >= b.m {
+		return int(float64(b.m) / float64(b.k) * math.Log(float64(b.m))) // saturated
+	}
+	return int(math.Round(-float64(b.m) / float64(b.k) * math.Log(1-float64(x)/float64(b.m))))
+}
+
+// Merge adds all keys of other to the set. Both sets must have been created with the same parameters.
+func (b *BloomSet[K]) Merge(other *BloomSet[K]) error {
+	if b.m != other.m || b.k != other.k {
+		return fmt.Errorf("%w: bloom sets have different parameters", ErrTypeMismatch)
+	}
+	for i := range other.words {
+		orWord(&b.words[i], other.words[i].Load())
+	}
+	return nil
+}
+
+// Clear removes all keys.
+func (b *BloomSet[K]) Clear() {
+	for i := range b.words {
+		b.words[i].Store(0)
+	}
+}
+
+// orWord atomically sets the mask bits of w.
+func orWord(w *atomic.Uint64, mask uint64) {
+	for {
+		old := w.Load()
+		if old&mask == mask || w.CompareAndSwap(old, old|mask) {
+			return
+		}
+	}
+}
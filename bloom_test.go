@@ -0,0 +1,46 @@
+package xsync
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBloomSet(t *testing.T) {
+	b := NewBloomSet[int](1000, 0.01)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < 1000; i += 4 {
+				b.Add(i)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for i := 0; i < 1000; i++ {
+		require(t, b.MayContain(i))
+	}
+	fp := 0
+	for i := 1000; i < 11000; i++ {
+		if b.MayContain(i) {
+			fp++
+		}
+	}
+	require(t, fp < 300) // ~1% expected
+	n := b.EstimatedCount()
+	require(t, n > 900 && n < 1100)
+
+	b.Clear()
+	require(t, !b.MayContain(1) && 0 == b.EstimatedCount())
+}
+
+func TestBloomSet_Merge(t *testing.T) {
+	a, b := NewBloomSet[string](100, 0.01), NewBloomSet[string](100, 0.01)
+	a.Add("a")
+	b.Add("b")
+	require(t, nil == a.Merge(b))
+	require(t, a.MayContain("a") && a.MayContain("b"))
+	require(t, nil != a.Merge(NewBloomSet[string](1000, 0.01)))
+}
@@ -0,0 +1,243 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned by Breaker.Do when the breaker rejects calls.
+var ErrBreakerOpen = errors.New("xsync: circuit breaker is open")
+
+// BreakerState is the state of a Breaker.
+type BreakerState uint8
+
+const (
+	BreakerClosed   BreakerState = iota // calls pass
+	BreakerOpen                         // calls are rejected until the cooldown ends
+	BreakerHalfOpen                     // a single probe call passes
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// A Breaker is a circuit breaker. After threshold consecutive failures it opens and rejects calls
+// with ErrBreakerOpen for the cooldown period; then it lets a single probe call through,
+// closing again if the probe succeeds and reopening if it fails.
+//
+// A Breaker is safe for use by multiple goroutines simultaneously.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	clock     Clock
+
+	mx       sync.Mutex
+	state    BreakerState
+	failures int       // consecutive failures in the closed state
+	opened   time.Time // time of the last opening
+	probing  bool      // a probe call is running in the half-open state
+	gen      uint64    // incremented on every state change, to ignore results of calls from a previous state
+	used     time.Time // time of the last call
+}
+
+// NewBreaker returns a closed Breaker opening after threshold consecutive failures for the cooldown period.
+func NewBreaker(threshold int, cooldown time.Duration, opts ...ClockOption) *Breaker {
+	return newBreaker(threshold, cooldown, newClock(opts))
+}
+
+func newBreaker(threshold int, cooldown time.Duration, clock Clock) *Breaker {
+	return &Breaker{threshold: max(threshold, 1), cooldown: cooldown, clock: clock, used: clock.Now()}
+}
+
+// Do calls fn unless the breaker is open, and records its result: a non-nil error or a panic is a failure.
+// It returns ErrBreakerOpen if the call is rejected, ctx.Err() if ctx is already done, or the error of fn.
+// A panic of fn is propagated.
+func (b *Breaker) Do(ctx context.Context, fn func(context.Context) error) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	gen, err := b.allow()
+	if err != nil {
+		return err
+	}
+	ok := false
+	defer func() { b.done(gen, ok) }()
+	err = fn(ctx)
+	ok = err == nil
+	return err
+}
+
+// advance moves an open breaker to half-open when its cooldown has ended. b.mx must be held.
+func (b *Breaker) advance(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.opened) >= b.cooldown {
+		b.setState(BreakerHalfOpen, now)
+	}
+}
+
+// setState changes the state. b.mx must be held.
+func (b *Breaker) setState(s BreakerState, now time.Time) {
+	b.state, b.failures, b.probing = s, 0, false
+	b.gen++
+	if s == BreakerOpen {
+		b.opened = now
+	}
+}
+
+func (b *Breaker) allow() (uint64, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.used = b.clock.Now()
+	b.advance(b.used)
+	switch b.state {
+	case BreakerOpen:
+		return 0, ErrBreakerOpen
+	case BreakerHalfOpen:
+		if b.probing {
+			return 0, ErrBreakerOpen
+		}
+		b.probing = true
+	}
+	return b.gen, nil
+}
+
+func (b *Breaker) done(gen uint64, ok bool) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if gen != b.gen {
+		return
+	}
+	now := b.clock.Now()
+	switch {
+	case b.state == BreakerHalfOpen && ok:
+		b.setState(BreakerClosed, now)
+	case b.state == BreakerHalfOpen:
+		b.setState(BreakerOpen, now)
+	case ok:
+		b.failures = 0
+	default:
+		if b.failures++; b.failures >= b.threshold {
+			b.setState(BreakerOpen, now)
+		}
+	}
+}
+
+// State returns the current state.
+func (b *Breaker) State() BreakerState {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.advance(b.clock.Now())
+	return b.state
+}
+
+// Reset closes the breaker and clears its failures.
+func (b *Breaker) Reset() {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.setState(BreakerClosed, b.clock.Now())
+}
+
+// idle reports whether the breaker is closed without failures and unused since before t.
+func (b *Breaker) idle(t time.Time) bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.used.Before(t) && b.state == BreakerClosed && b.failures == 0
+}
+
+const keyedBreakerShards = 16
+
+// A KeyedBreaker maintains a Breaker per key, e.g. per dependency or remote host.
+// Breakers idle for the idle duration are removed, so memory stays proportional to the number of active keys.
+//
+// A KeyedBreaker is safe for use by multiple goroutines simultaneously.
+type KeyedBreaker[K comparable] struct {
+	threshold int
+	cooldown  time.Duration
+	idle      time.Duration
+	seed      maphash.Seed
+	clock     Clock
+
+	shards [keyedBreakerShards]breakerShard[K]
+}
+
+type breakerShard[K comparable] struct {
+	mx       sync.Mutex
+	breakers map[K]*Breaker
+	cleaned  time.Time
+}
+
+// cleanup removes breakers idle since before t. s.mx must be held.
+func (s *breakerShard[K]) cleanup(now, t time.Time) {
+	s.cleaned = now
+	for k, b := range s.breakers {
+		if b.idle(t) {
+			delete(s.breakers, k)
+		}
+	}
+}
+
+// NewKeyedBreaker returns a KeyedBreaker with breakers of the given threshold and cooldown.
+// A breaker is removed when it is closed without failures and was not used for the idle duration.
+func NewKeyedBreaker[K comparable](threshold int, cooldown, idle time.Duration, opts ...ClockOption) *KeyedBreaker[K] {
+	kb := &KeyedBreaker[K]{threshold: threshold, cooldown: cooldown, idle: idle, seed: maphash.MakeSeed(), clock: newClock(opts)}
+	for i := range kb.shards {
+		kb.shards[i].breakers = map[K]*Breaker{}
+		kb.shards[i].cleaned = kb.clock.Now()
+	}
+	return kb
+}
+
+// Breaker returns the breaker of the key, creating it if necessary.
+func (kb *KeyedBreaker[K]) Breaker(key K) *Breaker {
+	s := &kb.shards[hashKey(kb.seed, key)%keyedBreakerShards]
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := kb.clock.Now()
+	if kb.idle > 0 && now.Sub(s.cleaned) >= kb.idle {
+		s.cleanup(now, now.Add(-kb.idle))
+	}
+	b, ok := s.breakers[key]
+	if !ok {
+		b = newBreaker(kb.threshold, kb.cooldown, kb.clock)
+		s.breakers[key] = b
+	}
+	return b
+}
+
+// Do calls fn through the breaker of the key.
+func (kb *KeyedBreaker[K]) Do(ctx context.Context, key K, fn func(context.Context) error) error {
+	return kb.Breaker(key).Do(ctx, fn)
+}
+
+// Cleanup removes idle breakers of all keys. It is also done gradually by Breaker calls.
+func (kb *KeyedBreaker[K]) Cleanup() {
+	now := kb.clock.Now()
+	for i := range kb.shards {
+		s := &kb.shards[i]
+		s.mx.Lock()
+		s.cleanup(now, now.Add(-kb.idle))
+		s.mx.Unlock()
+	}
+}
+
+// Len returns the number of tracked keys.
+func (kb *KeyedBreaker[K]) Len() (n int) {
+	for i := range kb.shards {
+		s := &kb.shards[i]
+		s.mx.Lock()
+		n += len(s.breakers)
+		s.mx.Unlock()
+	}
+	return
+}
@@ -0,0 +1,71 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	b := NewBreaker(3, time.Second, WithClock(clock))
+	ctx := context.Background()
+	fail := func(context.Context) error { return ErrCorrupt }
+	ok := func(context.Context) error { return nil }
+
+	require(t, b.Do(ctx, fail) == ErrCorrupt && b.Do(ctx, fail) == ErrCorrupt)
+	require(t, b.Do(ctx, ok) == nil) // resets consecutive failures
+	for i := 0; i < 3; i++ {
+		require(t, b.Do(ctx, fail) == ErrCorrupt)
+	}
+	require(t, b.State() == BreakerOpen && b.Do(ctx, ok) == ErrBreakerOpen)
+
+	clock.Advance(time.Second)
+	require(t, b.State() == BreakerHalfOpen)
+	err := b.Do(ctx, func(context.Context) error {
+		require(t, b.Do(ctx, ok) == ErrBreakerOpen) // only one probe at a time
+		return ErrCorrupt
+	})
+	require(t, err == ErrCorrupt && b.State() == BreakerOpen)
+
+	clock.Advance(time.Second)
+	require(t, b.Do(ctx, ok) == nil && b.State() == BreakerClosed)
+
+	b.Do(ctx, fail)
+	b.Do(ctx, fail)
+	b.Reset()
+	b.Do(ctx, fail)
+	require(t, b.State() == BreakerClosed && BreakerHalfOpen.String() == "half-open")
+}
+
+func TestKeyedBreaker(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	kb := NewKeyedBreaker[string](1, time.Second, time.Minute, WithClock(clock))
+	ctx := context.Background()
+
+	kb.Do(ctx, "a", func(context.Context) error { return ErrCorrupt })
+	kb.Do(ctx, "b", func(context.Context) error { return nil })
+	require(t, kb.Do(ctx, "a", func(context.Context) error { return nil }) == ErrBreakerOpen)
+	require(t, kb.Breaker("b").State() == BreakerClosed && kb.Len() == 2)
+
+	clock.Advance(2 * time.Minute)
+	kb.Cleanup()
+	require(t, kb.Len() == 1) // the open breaker of "a" is kept
+}
+
+func TestBreaker_panic(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	b := NewBreaker(1, time.Second, WithClock(clock))
+	ctx := context.Background()
+	doPanic := func() (r any) {
+		defer func() { r = recover() }()
+		b.Do(ctx, func(context.Context) error { panic("boom") })
+		return
+	}
+
+	require(t, doPanic() == "boom" && b.State() == BreakerOpen)
+	clock.Advance(time.Second)
+	require(t, doPanic() == "boom" && b.State() == BreakerOpen) // the failed probe reopens the breaker
+	clock.Advance(time.Second)
+	require(t, b.Do(ctx, func(context.Context) error { return nil }) == nil && b.State() == BreakerClosed)
+}
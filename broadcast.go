@@ -0,0 +1,149 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// A Broadcast delivers every published value to all current subscribers.
+//
+// A zero Broadcast is ready to use. It is safe for use by multiple goroutines simultaneously.
+type Broadcast[T any] struct {
+	mx     sync.Mutex
+	subs   map[*subscriber[T]]struct{}
+	closed bool
+}
+
+type subscriber[T any] struct {
+	mx     sync.Mutex // held while sending, so that ch is not closed during a send
+	ctx    context.Context
+	ch     chan T
+	drop   bool
+	closed bool
+
+	done     chan struct{} // closed when the subscription ends, to abort a blocked send
+	doneOnce sync.Once
+	stop     func() bool // stops the unsubscription on ctx done
+}
+
+// A SubscribeOption configures a subscription.
+type SubscribeOption func(*subscribeOptions)
+
+type subscribeOptions struct {
+	buffer int
+	drop   bool
+}
+
+// WithBuffer sets the channel buffer size of the subscription.
+func WithBuffer(n int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.buffer = n
+	}
+}
+
+// WithDropping makes Publish drop values for the subscriber when its buffer is full instead of waiting.
+func WithDropping() SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.drop = true
+	}
+}
+
+// Subscribe returns a channel receiving published values until ctx is done or the broadcast is closed;
+// the channel is closed then.
+// By default the channel is unbuffered and Publish waits until the subscriber receives a value.
+func (b *Broadcast[T]) Subscribe(ctx context.Context, opts ...SubscribeOption) <-chan T {
+	var o subscribeOptions
+	for _, fn := range opts {
+		fn(&o)
+	}
+	s := &subscriber[T]{ctx: ctx, ch: make(chan T, o.buffer), drop: o.drop, done: make(chan struct{})}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.closed {
+		close(s.ch)
+		return s.ch
+	}
+	if b.subs == nil {
+		b.subs = map[*subscriber[T]]struct{}{}
+	}
+	b.subs[s] = struct{}{}
+	s.stop = context.AfterFunc(ctx, func() { b.unsubscribe(s) })
+	return s.ch
+}
+
+func (b *Broadcast[T]) unsubscribe(s *subscriber[T]) {
+	b.mx.Lock()
+	delete(b.subs, s)
+	b.mx.Unlock()
+	s.close()
+}
+
+func (s *subscriber[T]) close() {
+	s.doneOnce.Do(func() { close(s.done) })
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// send delivers the value and reports whether it was delivered.
+func (s *subscriber[T]) send(v T) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.closed {
+		return false
+	}
+	if s.drop {
+		select {
+		case s.ch <- v:
+			return true
+		default:
+			return false
+		}
+	}
+	select {
+	case s.ch <- v:
+		return true
+	case <-s.ctx.Done():
+		return false
+	case <-s.done:
+		return false
+	}
+}
+
+// Publish sends the value to all subscribers and returns the number of subscribers it was delivered to.
+func (b *Broadcast[T]) Publish(v T) (n int) {
+	b.mx.Lock()
+	subs := mapKeys(b.subs)
+	b.mx.Unlock()
+
+	for _, s := range subs {
+		if s.send(v) {
+			n++
+		}
+	}
+	return
+}
+
+// Subscribers returns the number of current subscribers.
+func (b *Broadcast[T]) Subscribers() int {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return len(b.subs)
+}
+
+// Close closes channels of all subscribers, aborting pending deliveries. Subsequent subscriptions get closed channels.
+func (b *Broadcast[T]) Close() {
+	b.mx.Lock()
+	subs := mapKeys(b.subs)
+	b.subs, b.closed = nil, true
+	b.mx.Unlock()
+
+	for _, s := range subs {
+		s.stop()
+		s.close()
+	}
+}
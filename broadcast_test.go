@@ -0,0 +1,60 @@
+package xsync
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestBroadcast(t *testing.T) {
+	var b Broadcast[int]
+	ctx, cancel := context.WithCancel(context.Background())
+	ch1 := b.Subscribe(ctx)
+	ch2 := b.Subscribe(context.Background(), WithBuffer(1), WithDropping())
+	require(t, 2 == b.Subscribers())
+
+	got := make(chan int, 1)
+	go func() { got <- <-ch1 }()
+	require(t, 2 == b.Publish(1))
+	require(t, 1 == <-got && 1 == <-ch2)
+
+	cancel()
+	require(t, 1 == b.Publish(2)) // ch1 is done
+	_, ok := <-ch1
+	require(t, !ok)
+
+	require(t, 0 == b.Publish(3)) // ch2 buffer is full
+	require(t, 2 == <-ch2)
+
+	b.Close()
+	_, ok = <-ch2
+	require(t, !ok)
+	_, ok = <-b.Subscribe(context.Background())
+	require(t, !ok && 0 == b.Subscribers())
+}
+
+func TestBroadcast_blockingContextDone(t *testing.T) {
+	var b Broadcast[int]
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	b.Subscribe(ctx)
+
+	require(t, 0 == b.Publish(1)) // returns when the subscription is done
+}
+
+func TestBroadcast_Close(t *testing.T) {
+	var b Broadcast[int]
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		b.Subscribe(context.Background()) // never canceled and never read
+	}
+	published := make(chan int)
+	go func() { published <- b.Publish(1) }() // blocks on the first unbuffered subscriber
+	time.Sleep(5 * time.Millisecond)
+
+	b.Close() // must not wait for the blocked delivery
+	require(t, <-published == 0)
+	time.Sleep(5 * time.Millisecond)
+	require(t, runtime.NumGoroutine() <= before)
+}
@@ -0,0 +1,230 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Cache is a read-through cache: missing or expired values are loaded by the loader function.
+// Concurrent loads of the same key are deduplicated, and load errors are not cached.
+// Expired entries are removed by Cleanup, or automatically on writes when WithCleanupInterval is set.
+//
+// A Cache is safe for use by multiple goroutines simultaneously.
+type Cache[K comparable, T any] struct {
+	loader func(ctx context.Context, key K) (T, error)
+	opts   cacheOptions
+
+	mx      sync.RWMutex
+	entries map[K]cacheEntry[T]
+	calls   SingleFlight[K, T]
+	pending map[K]uint64 // keys being loaded, with the number of their load; removed by writes to invalidate loads
+	loadSeq uint64
+	swept   time.Time // time of the last automatic cleanup
+
+	hits, staleHits, misses, loads, loadErrors atomic.Uint64
+}
+
+type cacheEntry[T any] struct {
+	val     T
+	expires time.Time // zero if the entry never expires
+}
+
+// A CacheOption configures a Cache.
+type CacheOption func(*cacheOptions)
+
+type cacheOptions struct {
+	ttl, stale time.Duration
+	cleanup    time.Duration
+	clock      Clock
+}
+
+// WithTTL sets the time to live of cached values. By default values never expire.
+func WithTTL(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.ttl = d
+	}
+}
+
+// WithStaleWhileRevalidate lets Get return an expired value for up to d after its expiration,
+// while the value is reloaded in the background.
+func WithStaleWhileRevalidate(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.stale = d
+	}
+}
+
+// WithCleanupInterval removes expired entries automatically, at most once per d, when a value is stored
+// by a load or Set. By default expired entries are kept until Cleanup is called or the key is reloaded.
+func WithCleanupInterval(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.cleanup = d
+	}
+}
+
+// WithCacheClock sets the clock used for expiration instead of RealClock.
+func WithCacheClock(c Clock) CacheOption {
+	return func(o *cacheOptions) {
+		o.clock = c
+	}
+}
+
+// CacheStats are cache counters.
+type CacheStats struct {
+	Hits       uint64 // fresh values returned
+	StaleHits  uint64 // stale values returned while revalidating
+	Misses     uint64 // Get calls waiting for a load
+	Loads      uint64 // loader calls
+	LoadErrors uint64 // loader calls returning an error
+}
+
+// NewCache returns a Cache loading values with loader.
+func NewCache[K comparable, T any](loader func(ctx context.Context, key K) (T, error), opts ...CacheOption) *Cache[K, T] {
+	c := &Cache[K, T]{loader: loader, entries: map[K]cacheEntry[T]{}, pending: map[K]uint64{}}
+	for _, fn := range opts {
+		fn(&c.opts)
+	}
+	c.opts.clock = clockOrReal(c.opts.clock)
+	return c
+}
+
+// Get returns the cached value for the key, loading it if it is missing or expired.
+// The load is shared by concurrent callers and is not canceled with ctx; a caller whose ctx is done
+// stops waiting and gets ctx.Err().
+func (c *Cache[K, T]) Get(ctx context.Context, key K) (T, error) {
+	c.mx.RLock()
+	e, ok := c.entries[key]
+	c.mx.RUnlock()
+
+	if ok {
+		now := c.opts.clock.Now()
+		if e.expires.IsZero() || now.Before(e.expires) {
+			c.hits.Add(1)
+			return e.val, nil
+		}
+		if now.Before(e.expires.Add(c.opts.stale)) {
+			c.staleHits.Add(1)
+			c.calls.DoChan(key, func() (T, error) {
+				return c.load(context.WithoutCancel(ctx), key)
+			})
+			return e.val, nil
+		}
+	}
+	c.misses.Add(1)
+	ch := c.calls.DoChan(key, func() (T, error) {
+		return c.load(context.WithoutCancel(ctx), key)
+	})
+	select {
+	case r := <-ch:
+		return r.Val, r.Err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// load calls the loader and caches its result, unless the key was written, deleted or cleared meanwhile.
+func (c *Cache[K, T]) load(ctx context.Context, key K) (T, error) {
+	c.mx.Lock()
+	c.loadSeq++
+	seq := c.loadSeq
+	c.pending[key] = seq
+	c.mx.Unlock()
+
+	c.loads.Add(1)
+	v, err := c.loader(ctx, key)
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	valid := c.pending[key] == seq
+	if valid {
+		delete(c.pending, key)
+	}
+	if err != nil {
+		c.loadErrors.Add(1)
+		return v, err
+	}
+	if valid {
+		c.store(key, v, c.opts.ttl)
+	}
+	return v, nil
+}
+
+// store caches the value for ttl, or forever if ttl <= 0. c.mx must be held.
+func (c *Cache[K, T]) store(key K, v T, ttl time.Duration) {
+	now := c.opts.clock.Now()
+	e := cacheEntry[T]{val: v}
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+	c.entries[key] = e
+	if c.opts.cleanup > 0 && now.Sub(c.swept) >= c.opts.cleanup {
+		c.swept = now
+		c.removeExpired(now)
+	}
+}
+
+// Set stores the value with the configured TTL.
+func (c *Cache[K, T]) Set(key K, value T) {
+	c.SetWithTTL(key, value, c.opts.ttl)
+}
+
+// SetWithTTL stores the value with its own time to live instead of the configured one.
+// A non-positive ttl stores a value that never expires.
+func (c *Cache[K, T]) SetWithTTL(key K, value T, ttl time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.store(key, value, ttl)
+	delete(c.pending, key)
+}
+
+// Delete invalidates the cached value of the key.
+func (c *Cache[K, T]) Delete(key K) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	delete(c.entries, key)
+	delete(c.pending, key)
+}
+
+// Clear invalidates all cached values.
+func (c *Cache[K, T]) Clear() {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.entries = map[K]cacheEntry[T]{}
+	clear(c.pending)
+}
+
+// Cleanup removes entries that can no longer be served, even as stale values.
+func (c *Cache[K, T]) Cleanup() {
+	now := c.opts.clock.Now()
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.removeExpired(now)
+}
+
+// removeExpired removes entries that cannot be served at now. c.mx must be held.
+func (c *Cache[K, T]) removeExpired(now time.Time) {
+	for k, e := range c.entries {
+		if !e.expires.IsZero() && !now.Before(e.expires.Add(c.opts.stale)) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// Len returns the number of cached entries, including expired ones not removed yet.
+func (c *Cache[K, T]) Len() int {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return len(c.entries)
+}
+
+func (c *Cache[K, T]) Stats() CacheStats {
+	return CacheStats{
+		Hits:       c.hits.Load(),
+		StaleHits:  c.staleHits.Load(),
+		Misses:     c.misses.Load(),
+		Loads:      c.loads.Load(),
+		LoadErrors: c.loadErrors.Load(),
+	}
+}
@@ -0,0 +1,144 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	var loads atomic.Int32
+	c := NewCache(func(ctx context.Context, key string) (int, error) {
+		loads.Add(1)
+		time.Sleep(5 * time.Millisecond)
+		if key == "bad" {
+			return 0, errors.New("bad key")
+		}
+		return len(key), nil
+	}, WithTTL(20*time.Millisecond))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.Get(ctx, "abc")
+			if err != nil || v != 3 {
+				t.Error(v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	require(t, 1 == loads.Load())
+
+	v, err := c.Get(ctx, "abc")
+	require(t, err == nil && v == 3 && 1 == loads.Load())
+
+	_, err = c.Get(ctx, "bad")
+	require(t, err != nil)
+	_, err = c.Get(ctx, "bad")
+	require(t, err != nil && 3 == loads.Load()) // errors are not cached
+
+	time.Sleep(25 * time.Millisecond)
+	c.Get(ctx, "abc")
+	require(t, 4 == loads.Load())
+
+	st := c.Stats()
+	require(t, 14 == st.Hits+st.Misses && 2 == st.LoadErrors && 4 == st.Loads)
+}
+
+func TestCache_staleWhileRevalidate(t *testing.T) {
+	var n atomic.Int32
+	c := NewCache(func(ctx context.Context, key string) (int32, error) {
+		return n.Add(1), nil
+	}, WithTTL(time.Millisecond), WithStaleWhileRevalidate(time.Hour))
+	ctx := context.Background()
+
+	v, _ := c.Get(ctx, "a")
+	require(t, 1 == v)
+
+	time.Sleep(2 * time.Millisecond)
+	v, _ = c.Get(ctx, "a") // stale value, reloading in background
+	require(t, 1 == v && 1 == c.Stats().StaleHits)
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		c.mx.RLock()
+		e := c.entries["a"]
+		c.mx.RUnlock()
+		if e.val == 2 {
+			break
+		}
+	}
+	c.mx.RLock()
+	require(t, 2 == c.entries["a"].val)
+	c.mx.RUnlock()
+
+	c.Cleanup()
+	require(t, 1 == c.Len())
+}
+
+func TestCache_cancelAndInvalidate(t *testing.T) {
+	release := make(chan struct{})
+	c := NewCache(func(ctx context.Context, key string) (int, error) {
+		<-release
+		return len(key), ctx.Err()
+	})
+	waiters := func() int {
+		c.calls.mx.Lock()
+		defer c.calls.mx.Unlock()
+		if call := c.calls.calls["abc"]; call != nil {
+			return call.dups + 1
+		}
+		return 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, "abc")
+		first <- err
+	}()
+	second := make(chan int, 1)
+	go func() {
+		v, _ := c.Get(context.Background(), "abc")
+		second <- v
+	}()
+	for waiters() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	require(t, errors.Is(<-first, context.Canceled)) // the first caller stops waiting
+	c.Delete("abc")                                  // invalidates the pending load
+	close(release)
+	require(t, <-second == 3) // the load is not canceled with the first caller
+	require(t, c.Len() == 0)  // and its stale result is not cached
+}
+
+func TestCache_SetWithTTL(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	c := NewCache(func(ctx context.Context, key string) (int, error) {
+		return 0, nil
+	}, WithTTL(time.Hour), WithCleanupInterval(time.Minute), WithCacheClock(clock))
+	ctx := context.Background()
+
+	c.SetWithTTL("short", 1, time.Second)
+	c.SetWithTTL("forever", 2, 0)
+	c.Set("default", 3)
+	clock.Advance(2 * time.Second)
+
+	v, _ := c.Get(ctx, "short")
+	require(t, 0 == v) // expired and reloaded
+	c.SetWithTTL("short", 1, time.Second)
+	require(t, 3 == c.Len())
+
+	clock.Advance(2 * time.Hour)
+	c.Set("new", 4) // sweeps expired entries
+	require(t, 2 == c.Len())
+	v, _ = c.Get(ctx, "forever")
+	require(t, 2 == v)
+}
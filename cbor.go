@@ -0,0 +1,65 @@
+package xsync
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+var ErrNoCBOR = errors.New("xsync: cbor codec is not registered")
+
+type cborCodec struct {
+	marshal   func(any) ([]byte, error)
+	unmarshal func([]byte, any) error
+}
+
+var cborFuncs atomic.Pointer[cborCodec]
+
+// RegisterCBOR sets the functions used by MarshalCBOR and UnmarshalCBOR methods of containers.
+//
+// Importing the github.com/goldic/xsync/cbor package registers its built-in codec.
+// Any compatible library can be registered instead, e.g. RegisterCBOR(cbor.Marshal, cbor.Unmarshal).
+func RegisterCBOR(marshal func(any) ([]byte, error), unmarshal func([]byte, any) error) {
+	cborFuncs.Store(&cborCodec{marshal, unmarshal})
+}
+
+func marshalCBOR(v any) ([]byte, error) {
+	c := cborFuncs.Load()
+	if c == nil {
+		return nil, ErrNoCBOR
+	}
+	return c.marshal(v)
+}
+
+func unmarshalCBOR(data []byte, v any) error {
+	c := cborFuncs.Load()
+	if c == nil {
+		return ErrNoCBOR
+	}
+	return c.unmarshal(data, v)
+}
+
+func (m *Map[K, T]) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(m.KeyValues())
+}
+
+func (m *Map[K, T]) UnmarshalCBOR(data []byte) error {
+	var vv map[K]T
+	if err := unmarshalCBOR(data, &vv); err != nil {
+		return err
+	}
+	m.replace(vv)
+	return nil
+}
+
+func (m *Set[K]) MarshalCBOR() ([]byte, error) {
+	return marshalCBOR(m.Values())
+}
+
+func (m *Set[K]) UnmarshalCBOR(data []byte) (err error) {
+	var vv []K
+	if err = unmarshalCBOR(data, &vv); err != nil {
+		return
+	}
+	m.replace(sliceToMap(vv))
+	return
+}
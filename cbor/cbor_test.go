@@ -0,0 +1,114 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/goldic/xsync"
+)
+
+func TestMarshal(t *testing.T) {
+	for _, c := range []struct {
+		v   any
+		hex string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{1000, "1903e8"},
+		{-1, "20"},
+		{-1000, "3903e7"},
+		{uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{1.5, "fb3ff8000000000000"},
+		{true, "f5"},
+		{nil, "f6"},
+		{"IETF", "6449455446"},
+		{[]byte{1, 2}, "420102"},
+		{[]int{1, 2, 3}, "83010203"},
+		{map[string]int{"a": 1}, "a1616101"},
+	} {
+		data, err := Marshal(c.v)
+		require(t, err == nil)
+		if hex.EncodeToString(data) != c.hex {
+			t.Fatalf("Marshal(%v) = %x, want %s", c.v, data, c.hex)
+		}
+	}
+}
+
+func TestUnmarshal_any(t *testing.T) {
+	data, _ := hex.DecodeString("a26161016162820203")
+	var v any
+
+	err := Unmarshal(data, &v)
+
+	require(t, err == nil)
+	m := v.(map[any]any)
+	require(t, uint64(1) == m["a"])
+	require(t, 2 == len(m["b"].([]any)))
+}
+
+func TestUnmarshal_float16(t *testing.T) {
+	var f float64
+
+	require(t, nil == Unmarshal([]byte{0xf9, 0x3e, 0x00}, &f))
+	require(t, 1.5 == f)
+}
+
+func TestRoundTrip(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Tags  []string
+		Score float32
+		Next  *item
+	}
+	in := map[string]item{"x": {Name: "x", Tags: []string{"a"}, Score: -2.5, Next: &item{Name: "y"}}}
+	var out map[string]item
+
+	data, err := Marshal(in)
+	require(t, err == nil)
+	require(t, nil == Unmarshal(data, &out))
+
+	require(t, "x" == out["x"].Name)
+	require(t, -2.5 == out["x"].Score)
+	require(t, "y" == out["x"].Next.Name)
+	require(t, "a" == out["x"].Tags[0])
+}
+
+func TestUnmarshal_overflow(t *testing.T) {
+	var n int8
+
+	require(t, nil != Unmarshal([]byte{0x19, 0x03, 0xe8}, &n))
+	require(t, nil != Unmarshal([]byte{0x19, 0x03}, &n))
+}
+
+func TestMap_MarshalCBOR(t *testing.T) {
+	m := xsync.NewMapPtr(map[string]int{"a": 1, "b": 2})
+	var m2 xsync.Map[string, int]
+
+	data, err := m.MarshalCBOR()
+	require(t, err == nil)
+	require(t, nil == m2.UnmarshalCBOR(data))
+
+	require(t, m.Equal(&m2))
+}
+
+func TestSet_MarshalCBOR(t *testing.T) {
+	type doc struct {
+		IDs *xsync.Set[int]
+	}
+	in := doc{IDs: xsync.NewSetPtr([]int{1, 2, 3})}
+	var out doc
+
+	data, err := Marshal(in)
+	require(t, err == nil)
+	require(t, nil == Unmarshal(data, &out))
+
+	require(t, in.IDs.Equal(out.IDs))
+}
+
+func require(t *testing.T, ok bool) {
+	if !ok {
+		t.Fatal()
+	}
+}
@@ -0,0 +1,407 @@
+package cbor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+var (
+	errUnexpectedEnd = errors.New("cbor: unexpected end of data")
+	errIndefinite    = errors.New("cbor: indefinite-length items are not supported")
+)
+
+// Unmarshal parses the CBOR-encoded data and stores the result in the value pointed to by v.
+//
+// Decoding into an empty interface produces bool, uint64, int64, float64, string, []byte, []any, map[any]any or nil.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cbor: Unmarshal(non-pointer %T)", v)
+	}
+	d := decoder{data: data}
+	if err := d.decode(rv.Elem()); err != nil {
+		return err
+	}
+	if d.off != len(d.data) {
+		return errors.New("cbor: extra data after top-level value")
+	}
+	return nil
+}
+
+type decoder struct {
+	data []byte
+	off  int
+}
+
+func (d *decoder) head() (major, info byte, arg uint64, err error) {
+	if d.off >= len(d.data) {
+		return 0, 0, 0, errUnexpectedEnd
+	}
+	b := d.data[d.off]
+	d.off++
+	major, info = b>>5, b&0x1f
+	var n int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24:
+		n = 1
+	case info == 25:
+		n = 2
+	case info == 26:
+		n = 4
+	case info == 27:
+		n = 8
+	case info == 31:
+		return 0, 0, 0, errIndefinite
+	default:
+		return 0, 0, 0, fmt.Errorf("cbor: invalid additional information %d", info)
+	}
+	if len(d.data)-d.off < n {
+		return 0, 0, 0, errUnexpectedEnd
+	}
+	p := d.data[d.off : d.off+n]
+	d.off += n
+	switch n {
+	case 1:
+		arg = uint64(p[0])
+	case 2:
+		arg = uint64(binary.BigEndian.Uint16(p))
+	case 4:
+		arg = uint64(binary.BigEndian.Uint32(p))
+	default:
+		arg = binary.BigEndian.Uint64(p)
+	}
+	return
+}
+
+func (d *decoder) bytes(n uint64) ([]byte, error) {
+	if uint64(len(d.data)-d.off) < n {
+		return nil, errUnexpectedEnd
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// skip skips a single data item.
+func (d *decoder) skip() error {
+	major, _, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case majorBytes, majorText:
+		_, err = d.bytes(arg)
+	case majorArray:
+		for i := uint64(0); i < arg && err == nil; i++ {
+			err = d.skip()
+		}
+	case majorMap:
+		for i := uint64(0); i < 2*arg && err == nil; i++ {
+			err = d.skip()
+		}
+	case majorTag:
+		err = d.skip()
+	}
+	return err
+}
+
+func (d *decoder) raw() ([]byte, error) {
+	start := d.off
+	if err := d.skip(); err != nil {
+		return nil, err
+	}
+	return d.data[start:d.off], nil
+}
+
+func (d *decoder) isNull() bool {
+	return d.off < len(d.data) && (d.data[d.off] == 0xf6 || d.data[d.off] == 0xf7)
+}
+
+func (d *decoder) decode(v reflect.Value) error {
+	if d.isNull() {
+		d.off++
+		v.SetZero()
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if v.Type().Implements(unmarshalerType) {
+			return d.unmarshaler(v.Interface().(Unmarshaler))
+		}
+		return d.decode(v.Elem())
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+		return d.unmarshaler(v.Addr().Interface().(Unmarshaler))
+	}
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		val, err := d.decodeAny()
+		if err != nil {
+			return err
+		}
+		if val == nil {
+			v.SetZero()
+		} else {
+			v.Set(reflect.ValueOf(val))
+		}
+		return nil
+	}
+
+	start := d.off
+	major, info, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case majorUint:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if arg > math.MaxInt64 || v.OverflowInt(int64(arg)) {
+				return d.overflow(arg, v)
+			}
+			v.SetInt(int64(arg))
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if v.OverflowUint(arg) {
+				return d.overflow(arg, v)
+			}
+			v.SetUint(arg)
+			return nil
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(arg))
+			return nil
+		}
+	case majorNegInt:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if arg > math.MaxInt64 || v.OverflowInt(-1-int64(arg)) {
+				return d.overflow(arg, v)
+			}
+			v.SetInt(-1 - int64(arg))
+			return nil
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(-1 - float64(arg))
+			return nil
+		}
+	case majorBytes, majorText:
+		b, err := d.bytes(arg)
+		if err != nil {
+			return err
+		}
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(string(b))
+			return nil
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes(append([]byte{}, b...))
+			return nil
+		}
+	case majorArray:
+		switch v.Kind() {
+		case reflect.Slice:
+			if arg > uint64(len(d.data)) {
+				return errUnexpectedEnd
+			}
+			s := reflect.MakeSlice(v.Type(), int(arg), int(arg))
+			for i := 0; i < int(arg); i++ {
+				if err := d.decode(s.Index(i)); err != nil {
+					return err
+				}
+			}
+			v.Set(s)
+			return nil
+		case reflect.Array:
+			for i := 0; i < int(arg); i++ {
+				if i < v.Len() {
+					err = d.decode(v.Index(i))
+				} else {
+					err = d.skip()
+				}
+				if err != nil {
+					return err
+				}
+			}
+			for i := int(arg); i < v.Len(); i++ {
+				v.Index(i).SetZero()
+			}
+			return nil
+		}
+	case majorMap:
+		switch v.Kind() {
+		case reflect.Map:
+			t := v.Type()
+			if arg > uint64(len(d.data)) {
+				return errUnexpectedEnd
+			}
+			m := reflect.MakeMapWithSize(t, int(arg))
+			for i := 0; i < int(arg); i++ {
+				key, val := reflect.New(t.Key()).Elem(), reflect.New(t.Elem()).Elem()
+				if err := d.decode(key); err != nil {
+					return err
+				}
+				if err := d.decode(val); err != nil {
+					return err
+				}
+				m.SetMapIndex(key, val)
+			}
+			v.Set(m)
+			return nil
+		case reflect.Struct:
+			return d.decodeStruct(v, arg)
+		}
+	case majorTag:
+		return d.decode(v)
+	case majorSimple:
+		switch {
+		case (info == 20 || info == 21) && v.Kind() == reflect.Bool:
+			v.SetBool(info == 21)
+			return nil
+		case info >= 25 && info <= 27 && (v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64):
+			v.SetFloat(decodeFloat(info, arg))
+			return nil
+		}
+	}
+	return fmt.Errorf("cbor: cannot unmarshal item 0x%02x into %s", d.data[start], v.Type())
+}
+
+func (d *decoder) overflow(arg uint64, v reflect.Value) error {
+	return fmt.Errorf("cbor: value %d overflows %s", arg, v.Type())
+}
+
+func (d *decoder) decodeStruct(v reflect.Value, n uint64) error {
+	ff := structFields(v.Type())
+	for i := uint64(0); i < n; i++ {
+		var name string
+		if err := d.decode(reflect.ValueOf(&name).Elem()); err != nil {
+			return err
+		}
+		idx := -1
+		for _, f := range ff {
+			if f.name == name {
+				idx = f.index
+				break
+			}
+			if idx < 0 && strings.EqualFold(f.name, name) {
+				idx = f.index
+			}
+		}
+		var err error
+		if idx >= 0 {
+			err = d.decode(v.Field(idx))
+		} else {
+			err = d.skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) unmarshaler(u Unmarshaler) error {
+	b, err := d.raw()
+	if err != nil {
+		return err
+	}
+	return u.UnmarshalCBOR(b)
+}
+
+func (d *decoder) decodeAny() (any, error) {
+	major, info, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case majorUint:
+		return arg, nil
+	case majorNegInt:
+		if arg > math.MaxInt64 {
+			return -1 - float64(arg), nil
+		}
+		return -1 - int64(arg), nil
+	case majorBytes:
+		b, err := d.bytes(arg)
+		return append([]byte{}, b...), err
+	case majorText:
+		b, err := d.bytes(arg)
+		return string(b), err
+	case majorArray:
+		if arg > uint64(len(d.data)) {
+			return nil, errUnexpectedEnd
+		}
+		res := make([]any, arg)
+		for i := range res {
+			if res[i], err = d.decodeAny(); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case majorMap:
+		if arg > uint64(len(d.data)) {
+			return nil, errUnexpectedEnd
+		}
+		res := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			key, err := d.decodeAny()
+			if err != nil {
+				return nil, err
+			}
+			if key != nil && !reflect.TypeOf(key).Comparable() {
+				return nil, fmt.Errorf("cbor: unhashable map key of type %T", key)
+			}
+			if res[key], err = d.decodeAny(); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case majorTag:
+		return d.decodeAny()
+	}
+	switch info {
+	case 20, 21:
+		return info == 21, nil
+	case 22, 23:
+		return nil, nil
+	case 25, 26, 27:
+		return decodeFloat(info, arg), nil
+	}
+	return nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+}
+
+func decodeFloat(info byte, arg uint64) float64 {
+	switch info {
+	case 25:
+		return float16(uint16(arg))
+	case 26:
+		return float64(math.Float32frombits(uint32(arg)))
+	}
+	return math.Float64frombits(arg)
+}
+
+func float16(h uint16) float64 {
+	exp, mant := int(h>>10)&0x1f, float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
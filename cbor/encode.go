@@ -0,0 +1,209 @@
+// Package cbor implements a compact CBOR (RFC 8949) codec sufficient for xsync containers.
+//
+// Importing the package registers the codec for MarshalCBOR and UnmarshalCBOR methods of xsync containers:
+//
+//	import _ "github.com/goldic/xsync/cbor"
+package cbor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/goldic/xsync"
+)
+
+func init() {
+	xsync.RegisterCBOR(Marshal, Unmarshal)
+}
+
+// Marshaler is the interface implemented by types that can marshal themselves into valid CBOR.
+type Marshaler interface {
+	MarshalCBOR() ([]byte, error)
+}
+
+// Unmarshaler is the interface implemented by types that can unmarshal a CBOR description of themselves.
+type Unmarshaler interface {
+	UnmarshalCBOR([]byte) error
+}
+
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+var (
+	marshalerType   = reflect.TypeFor[Marshaler]()
+	unmarshalerType = reflect.TypeFor[Unmarshaler]()
+)
+
+// Marshal returns the CBOR encoding of v.
+//
+// Structs are encoded as maps keyed by field names, which can be overridden by `cbor` or `json` tags.
+func Marshal(v any) ([]byte, error) {
+	var e encoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) head(major byte, n uint64) {
+	switch {
+	case n < 24:
+		e.buf = append(e.buf, major<<5|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, major<<5|24, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, major<<5|25), uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, major<<5|26), uint32(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, major<<5|27), n)
+	}
+}
+
+func (e *encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, 0xf6) // null
+		return nil
+	}
+	if v.Type().Implements(marshalerType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			e.buf = append(e.buf, 0xf6)
+			return nil
+		}
+		return e.marshaler(v.Interface().(Marshaler))
+	}
+	if v.Kind() != reflect.Pointer && v.CanAddr() && v.Addr().Type().Implements(marshalerType) {
+		return e.marshaler(v.Addr().Interface().(Marshaler))
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 0xf5)
+		} else {
+			e.buf = append(e.buf, 0xf4)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.Int(); n >= 0 {
+			e.head(majorUint, uint64(n))
+		} else {
+			e.head(majorNegInt, uint64(-1-n))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.head(majorUint, v.Uint())
+	case reflect.Float32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xfa), math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xfb), math.Float64bits(v.Float()))
+	case reflect.String:
+		e.head(majorText, uint64(v.Len()))
+		e.buf = append(e.buf, v.String()...)
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xf6)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.head(majorBytes, uint64(v.Len()))
+			e.buf = append(e.buf, v.Bytes()...)
+			return nil
+		}
+		return e.array(v)
+	case reflect.Array:
+		return e.array(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xf6)
+			return nil
+		}
+		e.head(majorMap, uint64(v.Len()))
+		for it := v.MapRange(); it.Next(); {
+			if err := e.encode(it.Key()); err != nil {
+				return err
+			}
+			if err := e.encode(it.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		ff := structFields(v.Type())
+		e.head(majorMap, uint64(len(ff)))
+		for _, f := range ff {
+			e.head(majorText, uint64(len(f.name)))
+			e.buf = append(e.buf, f.name...)
+			if err := e.encode(v.Field(f.index)); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xf6)
+			return nil
+		}
+		return e.encode(v.Elem())
+	default:
+		return fmt.Errorf("cbor: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (e *encoder) array(v reflect.Value) error {
+	e.head(majorArray, uint64(v.Len()))
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) marshaler(m Marshaler) error {
+	b, err := m.MarshalCBOR()
+	if err != nil {
+		return err
+	}
+	e.buf = append(e.buf, b...)
+	return nil
+}
+
+type field struct {
+	name  string
+	index int
+}
+
+func structFields(t reflect.Type) (ff []field) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		tag, ok := f.Tag.Lookup("cbor")
+		if !ok {
+			tag = f.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		if s, _, _ := strings.Cut(tag, ","); s != "" {
+			name = s
+		}
+		ff = append(ff, field{name, i})
+	}
+	return
+}
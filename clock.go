@@ -0,0 +1,208 @@
+package xsync
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// A Clock tells the time and creates timers. Time-based types (Cache, Debouncer, Throttler, Batcher,
+// Scheduler, RateLimiter, SlidingWindowLimiter, KeyedLimiter, TopK) use RealClock unless configured
+// with another one, such as a FakeClock in tests.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f after d. The returned timer has a nil channel.
+	AfterFunc(d time.Duration, f func()) Timer
+	// NewTimer returns a timer sending the time on its channel after d.
+	NewTimer(d time.Duration) Timer
+}
+
+// A Timer is a timer created by a Clock. Its methods behave like those of time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// A ClockOption configures the clock of a time-based type.
+type ClockOption func(*clockOptions)
+
+type clockOptions struct {
+	clock Clock
+}
+
+// WithClock sets the clock used instead of RealClock.
+func WithClock(c Clock) ClockOption {
+	return func(o *clockOptions) {
+		o.clock = c
+	}
+}
+
+// newClock returns the clock configured by opts.
+func newClock(opts []ClockOption) Clock {
+	var o clockOptions
+	for _, fn := range opts {
+		fn(&o)
+	}
+	return clockOrReal(o.clock)
+}
+
+func clockOrReal(c Clock) Clock {
+	if c == nil {
+		return RealClock{}
+	}
+	return c
+}
+
+// RealClock is the Clock of the time package.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time { return time.Now() }
+
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// A FakeClock is a Clock for tests: its time only moves when Advance or Set is called.
+// Functions of timers created by AfterFunc run synchronously in the goroutine moving the clock,
+// in order of their deadlines. Timers only expire when the clock is moved, even those created
+// with a non-positive duration.
+//
+// A FakeClock is safe for use by multiple goroutines simultaneously.
+type FakeClock struct {
+	mx     sync.Mutex
+	cond   sync.Cond
+	now    time.Time
+	timers []*fakeTimer // active timers
+}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond.L = &c.mx
+	return c
+}
+
+type fakeTimer struct {
+	c  *FakeClock
+	at time.Time
+	fn func()
+	ch chan time.Time
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{c: c, fn: f}
+	t.Reset(d)
+	return t
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{c: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers that expire on the way.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to t, firing the timers that expire on the way. The clock never moves backwards.
+func (c *FakeClock) Set(t time.Time) {
+	for {
+		c.mx.Lock()
+		if len(c.timers) == 0 || c.timers[0].at.After(t) {
+			if t.After(c.now) {
+				c.now = t
+			}
+			c.mx.Unlock()
+			return
+		}
+		tm := c.timers[0]
+		c.timers = c.timers[1:]
+		if tm.at.After(c.now) {
+			c.now = tm.at
+		}
+		now := c.now
+		c.cond.Broadcast()
+		c.mx.Unlock()
+
+		if tm.fn != nil {
+			tm.fn()
+		} else {
+			select {
+			case tm.ch <- now:
+			default:
+			}
+		}
+	}
+}
+
+// Timers returns the number of active timers.
+func (c *FakeClock) Timers() int {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return len(c.timers)
+}
+
+// WaitTimers blocks until there are at least n active timers,
+// e.g. until a goroutine under test has started waiting on the clock.
+func (c *FakeClock) WaitTimers(n int) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// remove deactivates t and reports whether it was active. c.mx must be held.
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, x := range c.timers {
+		if x == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	c := t.c
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	active := c.remove(t)
+	c.cond.Broadcast()
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	c := t.c
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	active := c.remove(t)
+	t.at = c.now.Add(d)
+	i := sort.Search(len(c.timers), func(i int) bool { return c.timers[i].at.After(t.at) })
+	c.timers = append(c.timers, nil)
+	copy(c.timers[i+1:], c.timers[i:])
+	c.timers[i] = t
+	c.cond.Broadcast()
+	return active
+}
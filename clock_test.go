@@ -0,0 +1,87 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	var fired []time.Duration
+	c.AfterFunc(2*time.Second, func() { fired = append(fired, c.Now().Sub(start)) })
+	c.AfterFunc(time.Second, func() { fired = append(fired, c.Now().Sub(start)) })
+	stopped := c.AfterFunc(time.Second, func() { t.Error("stopped timer fired") })
+	require(t, stopped.Stop() && !stopped.Stop() && c.Timers() == 2)
+
+	tm := c.NewTimer(3 * time.Second)
+	c.Advance(2500 * time.Millisecond)
+	require(t, len(fired) == 2 && fired[0] == time.Second && fired[1] == 2*time.Second)
+	require(t, c.Now().Sub(start) == 2500*time.Millisecond)
+	select {
+	case <-tm.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	require(t, (<-tm.C()).Sub(start) == 3*time.Second && c.Timers() == 0)
+	require(t, !tm.Reset(time.Second) && c.Timers() == 1)
+
+	c.Set(start) // never moves backwards
+	require(t, c.Now().Sub(start) == 3500*time.Millisecond)
+}
+
+func TestFakeClock_debouncer(t *testing.T) {
+	c := NewFakeClock(time.Now())
+	n := 0
+	d := NewDebouncer(time.Second, func() { n++ }, WithClock(c))
+	d.Trigger()
+	c.Advance(900 * time.Millisecond)
+	d.Trigger()
+	c.Advance(900 * time.Millisecond)
+	require(t, n == 0)
+	c.Advance(100 * time.Millisecond)
+	require(t, n == 1)
+}
+
+func TestFakeClock_scheduler(t *testing.T) {
+	c := NewFakeClock(time.Now())
+	s := NewScheduler(WithClock(c))
+	defer s.Stop()
+	done := make(chan struct{})
+	c.WaitTimers(1) // the loop is waiting
+	s.After(time.Minute, func() { close(done) })
+	c.Advance(time.Minute - 1)
+	select {
+	case <-done:
+		t.Fatal("task ran early")
+	default:
+	}
+	for { // the loop may not have rescheduled its timer yet
+		c.Advance(time.Millisecond)
+		select {
+		case <-done:
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestFakeClock_cacheAndLimiter(t *testing.T) {
+	c := NewFakeClock(time.Now())
+	loads := 0
+	cache := NewCache(func(context.Context, string) (int, error) { loads++; return loads, nil }, WithTTL(time.Minute), WithCacheClock(c))
+	v, _ := cache.Get(context.Background(), "a")
+	c.Advance(59 * time.Second)
+	v2, _ := cache.Get(context.Background(), "a")
+	c.Advance(time.Second)
+	v3, _ := cache.Get(context.Background(), "a")
+	require(t, v == 1 && v2 == 1 && v3 == 2)
+
+	l := NewRateLimiter(1, 1, WithClock(c))
+	require(t, l.Allow() && !l.Allow())
+	c.Advance(time.Second)
+	require(t, l.Allow())
+}
@@ -0,0 +1,20 @@
+package xsync
+
+// WithCloner sets a function copying values of a Map, e.g. slices.Clone or maps.Clone.
+// Every method of the map, or of a FrozenMap made by Freeze, returning stored values (Get, Lookup, Values,
+// KeyValues, Random, Pop, ComputeIfAbsent, ...) then returns copies, so callers cannot mutate values shared
+// with the map and other goroutines.
+// The function type must match the map value type, otherwise the map constructor panics.
+func WithCloner[T any](fn func(value T) T) Option {
+	return func(o *options) {
+		o.cloner = fn
+	}
+}
+
+// cloneVal returns a copy of the value made by the WithCloner function, or the value itself.
+func (m *Map[K, T]) cloneVal(v T) T {
+	if m.clone != nil {
+		return m.clone(v)
+	}
+	return v
+}
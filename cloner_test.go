@@ -0,0 +1,49 @@
+package xsync
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWithCloner(t *testing.T) {
+	m := NewMapPtr(map[string][]int{"a": {1, 2}}, WithCloner(slices.Clone[[]int]))
+	m.Get("a")[0] = 100
+	m.Values()[0][0] = 100
+	m.KeyValues()["a"][0] = 100
+	m.AppendValues(nil)[0][0] = 100
+	v, _ := m.Lookup("a")
+	v[0] = 100
+	m.GetOrSet("a", nil)[0] = 100
+	_, v = m.Random()
+	v[0] = 100
+	m.RandomN(1)["a"][0] = 100
+	_, v = m.RandomWeighted(func(string, []int) float64 { return 1 })
+	v[0] = 100
+	_, v, _ = m.Min(func(a, b []int) bool { return false })
+	v[0] = 100
+	m.ComputeIfAbsent("a", nil)[0] = 100
+	require(t, slices.Equal([]int{1, 2}, m.Get("a")))
+
+	m.ComputeIfAbsent("b", func(string) []int { return []int{3} })[0] = 100
+	require(t, slices.Equal([]int{3}, m.Get("b")))
+
+	plain := NewMapPtr(map[string][]int{"a": {1, 2}})
+	plain.Get("a")[0] = 100
+	require(t, 100 == plain.Get("a")[0])
+}
+
+func TestWithCloner_freeze(t *testing.T) {
+	m := NewMapPtr(map[string][]int{"a": {1, 2}}, WithCloner(slices.Clone[[]int]))
+	f := m.Freeze()
+
+	f.Get("a")[0] = 100
+	v, _ := f.Lookup("a")
+	v[0] = 100
+	f.Values()[0][0] = 100
+	f.KeyValues()["a"][0] = 100
+	f.Range(func(_ string, v []int) bool { v[0] = 100; return true })
+	f.Thaw().ComputeIfPresent("a", func(_ string, v []int) ([]int, bool) { v[0] = 100; return v, true })
+
+	require(t, slices.Equal([]int{1, 2}, m.Get("a")))
+	require(t, slices.Equal([]int{1, 2}, f.Get("a")))
+}
@@ -0,0 +1,41 @@
+package xsync
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+)
+
+// A Codec encodes and decodes container contents for BinaryEncodeWith and BinaryDecodeWith.
+type Codec interface {
+	Encode(w io.Writer, v any) error
+	Decode(r io.Reader, v any) error
+}
+
+var (
+	// GobCodec encodes values with encoding/gob. It is used by BinaryEncode and BinaryDecode.
+	GobCodec Codec = gobCodec{}
+
+	// JSONCodec encodes values with encoding/json.
+	JSONCodec Codec = jsonCodec{}
+)
+
+type gobCodec struct{}
+
+func (gobCodec) Encode(w io.Writer, v any) error {
+	return gob.NewEncoder(w).Encode(v)
+}
+
+func (gobCodec) Decode(r io.Reader, v any) error {
+	return gob.NewDecoder(r).Decode(v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (jsonCodec) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
@@ -0,0 +1,29 @@
+package xsync
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMap_BinaryEncodeWith(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1, "b": 2})
+	var m2 Map[string, int]
+	var buf bytes.Buffer
+
+	require(t, nil == m.BinaryEncodeWith(&buf, JSONCodec))
+	require(t, `{"a":1,"b":2}`+"\n" == buf.String())
+	require(t, nil == m2.BinaryDecodeWith(&buf, JSONCodec))
+
+	require(t, m.Equal(&m2))
+}
+
+func TestSet_BinaryEncode(t *testing.T) {
+	s := NewSet([]string{"a", "b"})
+	var s2 Set[string]
+	var buf bytes.Buffer
+
+	require(t, nil == s.BinaryEncode(&buf))
+	require(t, nil == s2.BinaryDecode(&buf))
+
+	require(t, s.Equal(&s2))
+}
@@ -0,0 +1,48 @@
+package xsync
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// BinaryEncodeCompressed writes a gzip-compressed BinaryEncode snapshot of the map to w.
+// level is a compress/gzip level, e.g. gzip.DefaultCompression or gzip.BestSpeed.
+func (m *Map[K, T]) BinaryEncodeCompressed(w io.Writer, level int) error {
+	return encodeCompressed(w, level, m.BinaryEncode)
+}
+
+// BinaryDecodeCompressed reads a snapshot written by BinaryEncodeCompressed.
+func (m *Map[K, T]) BinaryDecodeCompressed(r io.Reader) error {
+	return decodeCompressed(r, m.BinaryDecode)
+}
+
+// BinaryEncodeCompressed writes a gzip-compressed BinaryEncode snapshot of the set to w.
+func (m *Set[K]) BinaryEncodeCompressed(w io.Writer, level int) error {
+	return encodeCompressed(w, level, m.BinaryEncode)
+}
+
+// BinaryDecodeCompressed reads a snapshot written by BinaryEncodeCompressed.
+func (m *Set[K]) BinaryDecodeCompressed(r io.Reader) error {
+	return decodeCompressed(r, m.BinaryDecode)
+}
+
+func encodeCompressed(w io.Writer, level int, encode func(io.Writer) error) error {
+	zw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return err
+	}
+	if err = encode(zw); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+func decodeCompressed(r io.Reader, decode func(io.Reader) error) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	return decode(zr)
+}
@@ -0,0 +1,33 @@
+package xsync
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+func TestMap_BinaryEncodeCompressed(t *testing.T) {
+	m := NewMapPtr(map[int]string{})
+	for i := 0; i < 100; i++ {
+		m.Set(i, strings.Repeat("value", 20))
+	}
+	var plain, compressed bytes.Buffer
+	require(t, nil == m.BinaryEncode(&plain))
+	require(t, nil == m.BinaryEncodeCompressed(&compressed, gzip.BestCompression))
+	require(t, compressed.Len()*4 < plain.Len())
+
+	var m2 Map[int, string]
+	require(t, nil == m2.BinaryDecodeCompressed(&compressed))
+	require(t, m.Equal(&m2))
+	require(t, nil != m2.BinaryDecodeCompressed(&plain))
+	require(t, nil != m.BinaryEncodeCompressed(&compressed, 42))
+}
+
+func TestSet_BinaryEncodeCompressed(t *testing.T) {
+	s := NewSetPtr([]string{"a", "b"})
+	var buf bytes.Buffer
+	require(t, nil == s.BinaryEncodeCompressed(&buf, gzip.BestSpeed))
+	var s2 Set[string]
+	require(t, nil == s2.BinaryDecodeCompressed(&buf) && s.Equal(&s2))
+}
@@ -0,0 +1,362 @@
+package xsync
+
+import (
+	"bytes"
+	"io"
+	"maps"
+	"sync"
+)
+
+// CRDTs (conflict-free replicated data types) are replicated between nodes by exchanging snapshots:
+// each node applies local updates under its own node id and merges the snapshots of other nodes.
+// Merge is commutative, associative and idempotent, so replicas converge regardless of the order
+// or repetition of merges. Snapshots use the same versioned gob envelope as Map.BinaryEncode.
+
+// A GCounter is a grow-only counter CRDT.
+//
+// A GCounter is safe for use by multiple goroutines simultaneously.
+type GCounter struct {
+	mx     sync.RWMutex
+	node   string
+	counts map[string]uint64
+}
+
+// NewGCounter returns a GCounter updated locally by the given node.
+func NewGCounter(node string) *GCounter {
+	return &GCounter{node: node, counts: map[string]uint64{}}
+}
+
+// Inc increments the counter by 1.
+func (c *GCounter) Inc() {
+	c.Add(1)
+}
+
+// Add increments the counter by n.
+func (c *GCounter) Add(n uint64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.counts[c.node] += n
+}
+
+// Value returns the sum of the counts of all nodes.
+func (c *GCounter) Value() (n uint64) {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	for _, v := range c.counts {
+		n += v
+	}
+	return
+}
+
+// Merge merges the state of other into the counter.
+func (c *GCounter) Merge(other *GCounter) {
+	counts := other.snapshot()
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	mergeCounts(c.counts, counts)
+}
+
+func (c *GCounter) snapshot() map[string]uint64 {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return maps.Clone(c.counts)
+}
+
+func mergeCounts(dst, src map[string]uint64) {
+	for node, n := range src {
+		dst[node] = max(dst[node], n)
+	}
+}
+
+func (c *GCounter) MarshalBinary() ([]byte, error) {
+	return marshalCRDT(c.BinaryEncode)
+}
+
+func (c *GCounter) UnmarshalBinary(data []byte) error {
+	return c.BinaryDecode(bytes.NewReader(data))
+}
+
+// BinaryEncode writes a snapshot of the counter to w.
+func (c *GCounter) BinaryEncode(w io.Writer) error {
+	return encodeCRDT(w, c.snapshot())
+}
+
+// BinaryDecode replaces the counter state with a snapshot read from r. The local node id is kept.
+func (c *GCounter) BinaryDecode(r io.Reader) error {
+	var counts map[string]uint64
+	if err := decodeCRDT(r, &counts); err != nil {
+		return err
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.counts = nonNilMap(counts)
+	return nil
+}
+
+// A PNCounter is a counter CRDT supporting increments and decrements.
+//
+// A PNCounter is safe for use by multiple goroutines simultaneously.
+type PNCounter struct {
+	mx   sync.RWMutex
+	node string
+	p, n map[string]uint64
+}
+
+type pnCounterState struct {
+	P, N map[string]uint64
+}
+
+// NewPNCounter returns a PNCounter updated locally by the given node.
+func NewPNCounter(node string) *PNCounter {
+	return &PNCounter{node: node, p: map[string]uint64{}, n: map[string]uint64{}}
+}
+
+// Add adds delta, which may be negative, to the counter.
+func (c *PNCounter) Add(delta int64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if delta >= 0 {
+		c.p[c.node] += uint64(delta)
+	} else {
+		c.n[c.node] += uint64(-delta)
+	}
+}
+
+func (c *PNCounter) Inc() {
+	c.Add(1)
+}
+
+func (c *PNCounter) Dec() {
+	c.Add(-1)
+}
+
+// Value returns the sum of the increments minus the sum of the decrements of all nodes.
+func (c *PNCounter) Value() int64 {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	var v uint64
+	for _, n := range c.p {
+		v += n
+	}
+	for _, n := range c.n {
+		v -= n
+	}
+	return int64(v)
+}
+
+// Merge merges the state of other into the counter.
+func (c *PNCounter) Merge(other *PNCounter) {
+	s := other.snapshot()
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	mergeCounts(c.p, s.P)
+	mergeCounts(c.n, s.N)
+}
+
+func (c *PNCounter) snapshot() pnCounterState {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return pnCounterState{maps.Clone(c.p), maps.Clone(c.n)}
+}
+
+func (c *PNCounter) MarshalBinary() ([]byte, error) {
+	return marshalCRDT(c.BinaryEncode)
+}
+
+func (c *PNCounter) UnmarshalBinary(data []byte) error {
+	return c.BinaryDecode(bytes.NewReader(data))
+}
+
+// BinaryEncode writes a snapshot of the counter to w.
+func (c *PNCounter) BinaryEncode(w io.Writer) error {
+	return encodeCRDT(w, c.snapshot())
+}
+
+// BinaryDecode replaces the counter state with a snapshot read from r. The local node id is kept.
+func (c *PNCounter) BinaryDecode(r io.Reader) error {
+	var s pnCounterState
+	if err := decodeCRDT(r, &s); err != nil {
+		return err
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.p, c.n = nonNilMap(s.P), nonNilMap(s.N)
+	return nil
+}
+
+// An ORSet is an observed-remove set CRDT with add-wins semantics: a key removed on one node
+// and concurrently added on another stays in the merged set.
+// Every add is tagged with a dot (node, sequence number); a remove drops the dots observed locally,
+// and the causal context (the highest sequence number seen per node) tells merges which missing dots
+// were removed rather than not yet seen. Removed keys leave no tombstones.
+//
+// An ORSet is safe for use by multiple goroutines simultaneously.
+type ORSet[K comparable] struct {
+	mx      sync.RWMutex
+	ver     uint64
+	node    string
+	ctx     map[string]uint64
+	entries map[K][]crdtDot
+}
+
+type crdtDot struct {
+	Node string
+	Seq  uint64
+}
+
+type orSetState[K comparable] struct {
+	Context map[string]uint64
+	Entries map[K][]crdtDot
+}
+
+// NewORSet returns an ORSet updated locally by the given node.
+func NewORSet[K comparable](node string) *ORSet[K] {
+	return &ORSet[K]{node: node, ctx: map[string]uint64{}, entries: map[K][]crdtDot{}}
+}
+
+// Add adds the key to the set.
+func (s *ORSet[K]) Add(key K) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.ctx[s.node]++
+	s.entries[key] = []crdtDot{{s.node, s.ctx[s.node]}}
+	s.ver++
+}
+
+// Remove removes the key from the set and reports whether it was present.
+func (s *ORSet[K]) Remove(key K) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	_, ok := s.entries[key]
+	if ok {
+		delete(s.entries, key)
+		s.ver++
+	}
+	return ok
+}
+
+func (s *ORSet[K]) Contains(key K) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	_, ok := s.entries[key]
+	return ok
+}
+
+func (s *ORSet[K]) Len() int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return len(s.entries)
+}
+
+func (s *ORSet[K]) Version() uint64 {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.ver
+}
+
+func (s *ORSet[K]) Values() []K {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return mapKeys(s.entries)
+}
+
+// Merge merges the state of other into the set.
+func (s *ORSet[K]) Merge(other *ORSet[K]) {
+	o := other.snapshot()
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	// a dot survives if both sides have it, or if the side missing it has not seen it yet
+	keep := func(dots, others []crdtDot, ctx map[string]uint64, res []crdtDot) []crdtDot {
+		for _, d := range dots {
+			if (d.Seq > ctx[d.Node] || containsDot(others, d)) && !containsDot(res, d) {
+				res = append(res, d)
+			}
+		}
+		return res
+	}
+	entries := make(map[K][]crdtDot, len(s.entries))
+	merge := func(key K) {
+		a, b := s.entries[key], o.Entries[key]
+		if res := keep(b, a, s.ctx, keep(a, b, o.Context, nil)); len(res) > 0 {
+			entries[key] = res
+		}
+	}
+	for key := range s.entries {
+		merge(key)
+	}
+	for key := range o.Entries {
+		merge(key)
+	}
+	s.entries = entries
+	mergeCounts(s.ctx, o.Context)
+	s.ver++
+}
+
+func containsDot(dots []crdtDot, d crdtDot) bool {
+	for _, x := range dots {
+		if x == d {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ORSet[K]) snapshot() orSetState[K] {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return orSetState[K]{maps.Clone(s.ctx), maps.Clone(s.entries)}
+}
+
+func (s *ORSet[K]) MarshalBinary() ([]byte, error) {
+	return marshalCRDT(s.BinaryEncode)
+}
+
+func (s *ORSet[K]) UnmarshalBinary(data []byte) error {
+	return s.BinaryDecode(bytes.NewReader(data))
+}
+
+// BinaryEncode writes a snapshot of the set to w.
+func (s *ORSet[K]) BinaryEncode(w io.Writer) error {
+	return encodeCRDT(w, s.snapshot())
+}
+
+// BinaryDecode replaces the set state with a snapshot read from r. The local node id is kept.
+func (s *ORSet[K]) BinaryDecode(r io.Reader) error {
+	var st orSetState[K]
+	if err := decodeCRDT(r, &st); err != nil {
+		return err
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.ctx, s.entries = nonNilMap(st.Context), nonNilMap(st.Entries)
+	s.ver++
+	return nil
+}
+
+func marshalCRDT(encode func(io.Writer) error) ([]byte, error) {
+	w := bytes.NewBuffer(nil)
+	err := encode(w)
+	return w.Bytes(), err
+}
+
+func encodeCRDT(w io.Writer, state any) error {
+	return writeEnvelope(w, func(w io.Writer) error {
+		return GobCodec.Encode(w, state)
+	})
+}
+
+func decodeCRDT(r io.Reader, state any) error {
+	r, err := readEnvelope(r)
+	if err != nil {
+		return err
+	}
+	return GobCodec.Decode(r, state)
+}
+
+func nonNilMap[K comparable, T any](m map[K]T) map[K]T {
+	if m == nil {
+		return map[K]T{}
+	}
+	return m
+}
@@ -0,0 +1,74 @@
+package xsync
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGCounter(t *testing.T) {
+	a, b := NewGCounter("a"), NewGCounter("b")
+	a.Add(3)
+	b.Inc()
+	b.Inc()
+	a.Merge(b)
+	a.Merge(b) // idempotent
+	b.Merge(a)
+	require(t, 5 == a.Value() && 5 == b.Value())
+
+	data, err := a.MarshalBinary()
+	require(t, err == nil)
+	c := NewGCounter("c")
+	require(t, nil == c.UnmarshalBinary(data))
+	c.Inc()
+	require(t, 6 == c.Value())
+	a.Merge(c)
+	require(t, 6 == a.Value())
+}
+
+func TestPNCounter(t *testing.T) {
+	a, b := NewPNCounter("a"), NewPNCounter("b")
+	a.Add(10)
+	b.Add(-3)
+	b.Dec()
+	a.Inc()
+	a.Merge(b)
+	b.Merge(a)
+	require(t, 7 == a.Value() && 7 == b.Value())
+
+	data, err := b.MarshalBinary()
+	require(t, err == nil)
+	c := NewPNCounter("c")
+	require(t, nil == c.UnmarshalBinary(data) && 7 == c.Value())
+}
+
+func TestORSet(t *testing.T) {
+	a, b := NewORSet[string]("a"), NewORSet[string]("b")
+	a.Add("x")
+	a.Add("y")
+	b.Merge(a)
+	require(t, b.Contains("x") && b.Contains("y"))
+
+	// concurrent remove on a and re-add on b: add wins
+	require(t, a.Remove("x") && !a.Remove("x"))
+	b.Add("x")
+	// remove observed by both sides stays removed
+	b.Remove("y")
+
+	a.Merge(b)
+	b.Merge(a)
+	for _, s := range []*ORSet[string]{a, b} {
+		vals := s.Values()
+		slices.Sort(vals)
+		require(t, slices.Equal([]string{"x"}, vals))
+	}
+
+	// merging a stale snapshot does not resurrect removed keys
+	stale := NewORSet[string]("a")
+	data, err := a.MarshalBinary()
+	require(t, err == nil && nil == stale.UnmarshalBinary(data))
+	a.Remove("x")
+	a.Merge(stale)
+	require(t, 0 == a.Len())
+	stale.Merge(a)
+	require(t, 0 == stale.Len())
+}
@@ -0,0 +1,174 @@
+package xsync
+
+import (
+	"sync"
+	"time"
+)
+
+// A Debouncer coalesces bursts of calls: the function runs once the calls have stopped for the delay.
+//
+// Function runs are serialized. A Debouncer is safe for use by multiple goroutines simultaneously.
+type Debouncer struct {
+	delay time.Duration
+	clock Clock
+
+	mx      sync.Mutex
+	fn      func()
+	pending bool
+	stopped bool
+	timer   Timer
+
+	runMx sync.Mutex
+}
+
+// NewDebouncer returns a Debouncer running fn after delay of quiet. fn may be nil if Call is used.
+func NewDebouncer(delay time.Duration, fn func(), opts ...ClockOption) *Debouncer {
+	return &Debouncer{delay: delay, clock: newClock(opts), fn: fn}
+}
+
+// Trigger schedules the function to run after the delay, postponing a pending run.
+func (b *Debouncer) Trigger() {
+	b.Call(nil)
+}
+
+// Call is like Trigger, but replaces the function to run with fn if it is not nil.
+func (b *Debouncer) Call(fn func()) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.stopped {
+		return
+	}
+	if fn != nil {
+		b.fn = fn
+	}
+	b.pending = true
+	if b.timer == nil {
+		b.timer = b.clock.AfterFunc(b.delay, b.Flush)
+	} else {
+		b.timer.Reset(b.delay)
+	}
+}
+
+// Flush runs a pending function immediately.
+func (b *Debouncer) Flush() {
+	b.runMx.Lock()
+	defer b.runMx.Unlock()
+
+	b.mx.Lock()
+	fn := b.fn
+	pending := b.pending && fn != nil
+	b.pending = false
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.mx.Unlock()
+
+	if pending {
+		fn()
+	}
+}
+
+// Stop cancels a pending run; subsequent calls are ignored. Call Flush before Stop to run it instead.
+func (b *Debouncer) Stop() {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.stopped, b.pending = true, false
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+}
+
+// A Throttler runs the function at most once per interval: the first call of a burst runs immediately,
+// and further calls within the interval are coalesced into a single run at the end of the interval.
+//
+// Function runs are serialized. A Throttler is safe for use by multiple goroutines simultaneously.
+type Throttler struct {
+	interval time.Duration
+	clock    Clock
+
+	mx      sync.Mutex
+	fn      func()
+	pending bool
+	stopped bool
+	timer   Timer // not nil within an interval
+
+	runMx sync.Mutex
+}
+
+// NewThrottler returns a Throttler running fn at most once per interval. fn may be nil if Call is used.
+func NewThrottler(interval time.Duration, fn func(), opts ...ClockOption) *Throttler {
+	return &Throttler{interval: interval, clock: newClock(opts), fn: fn}
+}
+
+// Trigger runs the function now, or at the end of the current interval.
+func (t *Throttler) Trigger() {
+	t.Call(nil)
+}
+
+// Call is like Trigger, but replaces the function to run with fn if it is not nil.
+func (t *Throttler) Call(fn func()) {
+	t.mx.Lock()
+	if t.stopped {
+		t.mx.Unlock()
+		return
+	}
+	if fn != nil {
+		t.fn = fn
+	}
+	if t.timer != nil {
+		t.pending = true
+		t.mx.Unlock()
+		return
+	}
+	t.timer = t.clock.AfterFunc(t.interval, t.tick)
+	fn = t.fn
+	t.mx.Unlock()
+	t.run(fn)
+}
+
+// tick ends the interval, running a pending function and starting a new interval if needed.
+func (t *Throttler) tick() {
+	t.mx.Lock()
+	if !t.pending || t.stopped {
+		t.timer = nil
+		t.mx.Unlock()
+		return
+	}
+	t.pending = false
+	t.timer = t.clock.AfterFunc(t.interval, t.tick)
+	fn := t.fn
+	t.mx.Unlock()
+	t.run(fn)
+}
+
+// Flush runs a pending function immediately.
+func (t *Throttler) Flush() {
+	t.mx.Lock()
+	pending := t.pending
+	t.pending = false
+	fn := t.fn
+	t.mx.Unlock()
+	if pending {
+		t.run(fn)
+	}
+}
+
+// Stop cancels a pending run; subsequent calls are ignored.
+func (t *Throttler) Stop() {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.stopped, t.pending = true, false
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+func (t *Throttler) run(fn func()) {
+	if fn == nil {
+		return
+	}
+	t.runMx.Lock()
+	defer t.runMx.Unlock()
+	fn()
+}
@@ -0,0 +1,53 @@
+package xsync
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebouncer(t *testing.T) {
+	var n atomic.Int32
+	b := NewDebouncer(20*time.Millisecond, func() { n.Add(1) })
+	for i := 0; i < 5; i++ {
+		b.Trigger()
+	}
+	require(t, 0 == n.Load())
+	time.Sleep(50 * time.Millisecond)
+	require(t, 1 == n.Load())
+
+	b.Call(func() { n.Add(10) })
+	b.Flush()
+	require(t, 11 == n.Load())
+	b.Flush()
+	require(t, 11 == n.Load())
+
+	b.Trigger()
+	b.Stop()
+	b.Trigger()
+	time.Sleep(30 * time.Millisecond)
+	require(t, 11 == n.Load())
+}
+
+func TestThrottler(t *testing.T) {
+	var n atomic.Int32
+	th := NewThrottler(20*time.Millisecond, func() { n.Add(1) })
+	for i := 0; i < 5; i++ {
+		th.Trigger()
+	}
+	require(t, 1 == n.Load()) // leading run
+	time.Sleep(30 * time.Millisecond)
+	require(t, 2 == n.Load()) // trailing run
+
+	time.Sleep(30 * time.Millisecond)
+	th.Trigger()
+	th.Trigger()
+	require(t, 3 == n.Load())
+	th.Flush()
+	require(t, 4 == n.Load())
+
+	th.Trigger()
+	th.Stop()
+	time.Sleep(30 * time.Millisecond)
+	require(t, 4 == n.Load())
+}
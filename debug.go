@@ -0,0 +1,120 @@
+package xsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"strconv"
+)
+
+// sample returns up to n entries of the map (not a random sample). m.mx must be held.
+func (m *Map[K, T]) sample(n int) map[K]T {
+	res := make(map[K]T, min(n, len(m.vals)))
+	for k, v := range m.vals {
+		if len(res) >= n {
+			break
+		}
+		res[k] = v
+	}
+	return res
+}
+
+// DebugString returns the map as JSON truncated to at most limit entries,
+// followed by the number of omitted entries.
+func (m *Map[K, T]) DebugString(limit int) string {
+	m.mx.RLock()
+	vals, total := m.sample(limit), len(m.vals)
+	m.mx.RUnlock()
+
+	keys := mapKeys(vals)
+	sortKeys(keys)
+	buf := bytes.NewBufferString("{")
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := marshalJSONKey(k)
+		val, _ := json.Marshal(vals[k])
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	if n := total - len(keys); n > 0 {
+		if len(keys) > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("...(" + strconv.Itoa(n) + " more)")
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// Expvar publishes the map as an expvar variable with the given name, reporting its len, version
+// and, if sampleSize > 0, up to sampleSize entries. Like expvar.Publish, it panics if the name is already registered.
+func (m *Map[K, T]) Expvar(name string, sampleSize int) expvar.Var {
+	v := expvar.Func(func() any {
+		m.mx.RLock()
+		defer m.mx.RUnlock()
+		res := map[string]any{"len": len(m.vals), "version": m.ver}
+		if sampleSize > 0 {
+			if b, err := json.Marshal(m.sample(sampleSize)); err != nil {
+				res["sample"] = err.Error()
+			} else {
+				res["sample"] = json.RawMessage(b)
+			}
+		}
+		return res
+	})
+	expvar.Publish(name, v)
+	return v
+}
+
+// DebugString returns the set as JSON truncated to at most limit keys,
+// followed by the number of omitted keys.
+func (m *Set[K]) DebugString(limit int) string {
+	keys, total := m.sample(limit)
+
+	sortKeys(keys)
+	buf := bytes.NewBufferString("[")
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, _ := json.Marshal(k)
+		buf.Write(b)
+	}
+	if n := total - len(keys); n > 0 {
+		if len(keys) > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("...(" + strconv.Itoa(n) + " more)")
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
+
+// Expvar publishes the set as an expvar variable with the given name, reporting its size and version.
+// Like expvar.Publish, it panics if the name is already registered.
+func (m *Set[K]) Expvar(name string) expvar.Var {
+	v := expvar.Func(func() any {
+		return map[string]any{"len": m.Size(), "version": m.Version()}
+	})
+	expvar.Publish(name, v)
+	return v
+}
+
+// sample returns up to n arbitrary keys and the set size.
+func (m *Set[K]) sample(n int) (keys []K, total int) {
+	parts := m.rlock()
+	defer runlockParts(parts)
+	keys, total = make([]K, 0, min(max(n, 0), m.Size())), m.Size()
+	for _, s := range parts {
+		for k := range s.vals {
+			if len(keys) >= n {
+				return
+			}
+			keys = append(keys, k)
+		}
+	}
+	return
+}
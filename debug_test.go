@@ -0,0 +1,51 @@
+package xsync
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMap_DebugString(t *testing.T) {
+	m := NewMap(map[int]int{1: 1, 2: 2, 3: 3})
+
+	require(t, `{"1":1,"2":2,"3":3}` == m.DebugString(10))
+	require(t, 2 == len(m.sample(2)))
+	require(t, `{...(3 more)}` == m.DebugString(0))
+}
+
+func TestSet_DebugString(t *testing.T) {
+	s := NewSet([]string{"a", "b"})
+
+	require(t, `["a","b"]` == s.DebugString(2))
+	require(t, `[...(2 more)]` == s.DebugString(0))
+	require(t, `[...(2 more)]` == s.DebugString(-1))
+}
+
+func TestMap_Expvar(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1})
+
+	v := m.Expvar("test_map", 10)
+	var res struct {
+		Len     int
+		Version uint64
+		Sample  map[string]int
+	}
+
+	require(t, nil == json.Unmarshal([]byte(v.String()), &res))
+	require(t, 1 == res.Len)
+	require(t, 1 == res.Sample["a"])
+}
+
+func TestMap_Expvar_marshalError(t *testing.T) {
+	m := NewMap(map[string]chan int{"a": nil})
+
+	v := m.Expvar("test_map_chan", 10)
+	var res struct {
+		Len    int
+		Sample string
+	}
+
+	require(t, nil == json.Unmarshal([]byte(v.String()), &res))
+	require(t, 1 == res.Len)
+	require(t, "" != res.Sample)
+}
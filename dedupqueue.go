@@ -0,0 +1,143 @@
+package xsync
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// A DedupQueue is a FIFO queue of keyed items holding at most one pending item per key:
+// pushing an item with the key of a pending one replaces or merges it in place instead of
+// enqueueing a duplicate, so consumers see the latest value of every key once.
+//
+// A zero DedupQueue is ready to use. A DedupQueue is safe for use by multiple goroutines simultaneously.
+type DedupQueue[K comparable, T any] struct {
+	mx      sync.Mutex
+	order   list.List // of *dedupItem[K, T], oldest first
+	items   map[K]*list.Element
+	closed  bool
+	changed chan struct{} // closed and replaced on every push
+}
+
+type dedupItem[K comparable, T any] struct {
+	key K
+	val T
+}
+
+// notify wakes up waiting goroutines. q.mx must be held.
+func (q *DedupQueue[K, T]) notify() {
+	if q.changed != nil {
+		close(q.changed)
+		q.changed = nil
+	}
+}
+
+// Push enqueues v, or replaces the pending item of the key keeping its position.
+// It returns ErrStopped if the queue is closed.
+func (q *DedupQueue[K, T]) Push(key K, v T) error {
+	return q.PushFunc(key, func(T, bool) T { return v })
+}
+
+// PushFunc enqueues fn(zero, false), or replaces the pending item of the key with fn(pending, true)
+// keeping its position, e.g. to coalesce updates. fn is called while the queue is locked.
+// It returns ErrStopped if the queue is closed.
+func (q *DedupQueue[K, T]) PushFunc(key K, fn func(pending T, ok bool) T) error {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if q.closed {
+		return ErrStopped
+	}
+	if e := q.items[key]; e != nil {
+		it := e.Value.(*dedupItem[K, T])
+		it.val = fn(it.val, true)
+		return nil
+	}
+	var zero T
+	if q.items == nil {
+		q.items = map[K]*list.Element{}
+	}
+	q.items[key] = q.order.PushBack(&dedupItem[K, T]{key, fn(zero, false)})
+	q.notify()
+	return nil
+}
+
+// TryPop dequeues the oldest item without blocking and reports whether there was one.
+func (q *DedupQueue[K, T]) TryPop() (key K, v T, ok bool) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return q.pop()
+}
+
+// pop dequeues the oldest item. q.mx must be held.
+func (q *DedupQueue[K, T]) pop() (key K, v T, ok bool) {
+	e := q.order.Front()
+	if e == nil {
+		return
+	}
+	it := q.order.Remove(e).(*dedupItem[K, T])
+	delete(q.items, it.key)
+	return it.key, it.val, true
+}
+
+// Pop dequeues the oldest item, blocking until there is one.
+// It returns ErrStopped if the queue is closed and empty, or ctx.Err() if ctx is done first.
+func (q *DedupQueue[K, T]) Pop(ctx context.Context) (key K, v T, err error) {
+	q.mx.Lock()
+	for {
+		var ok bool
+		if key, v, ok = q.pop(); ok {
+			q.mx.Unlock()
+			return
+		}
+		if q.closed {
+			q.mx.Unlock()
+			return key, v, ErrStopped
+		}
+		if q.changed == nil {
+			q.changed = make(chan struct{})
+		}
+		ch := q.changed
+		q.mx.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return key, v, ctx.Err()
+		}
+		q.mx.Lock()
+	}
+}
+
+// Remove drops the pending item of the key and reports whether there was one.
+func (q *DedupQueue[K, T]) Remove(key K) bool {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	e := q.items[key]
+	if e == nil {
+		return false
+	}
+	q.order.Remove(e)
+	delete(q.items, key)
+	return true
+}
+
+// Pending reports whether an item of the key is pending.
+func (q *DedupQueue[K, T]) Pending(key K) bool {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return q.items[key] != nil
+}
+
+// Len returns the number of pending items.
+func (q *DedupQueue[K, T]) Len() int {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return q.order.Len()
+}
+
+// Close rejects further pushes. Pending items can still be popped; then Pop returns ErrStopped.
+func (q *DedupQueue[K, T]) Close() {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.closed = true
+	q.notify()
+}
@@ -0,0 +1,49 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDedupQueue(t *testing.T) {
+	var q DedupQueue[string, int]
+	q.Push("a", 1)
+	q.Push("b", 2)
+	q.Push("a", 3) // replaces in place
+	q.PushFunc("b", func(old int, ok bool) int { return old + 10 })
+	require(t, q.Len() == 2 && q.Pending("a"))
+
+	k, v, ok := q.TryPop()
+	require(t, ok && k == "a" && v == 3)
+	k, v, _ = q.TryPop()
+	require(t, k == "b" && v == 12)
+	_, _, ok = q.TryPop()
+	require(t, !ok)
+
+	q.Push("c", 1)
+	require(t, q.Remove("c") && !q.Remove("c") && q.Len() == 0)
+}
+
+func TestDedupQueue_Pop(t *testing.T) {
+	var q DedupQueue[string, int]
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Push("a", 1)
+	}()
+	k, v, err := q.Pop(context.Background())
+	require(t, err == nil && k == "a" && v == 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = q.Pop(ctx)
+	require(t, err == context.DeadlineExceeded)
+
+	q.Push("b", 2)
+	q.Close()
+	require(t, q.Push("c", 3) == ErrStopped)
+	_, v, err = q.Pop(context.Background())
+	require(t, err == nil && v == 2)
+	_, _, err = q.Pop(context.Background())
+	require(t, err == ErrStopped)
+}
@@ -0,0 +1,118 @@
+package xsync
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// WithEntryStats makes a Map track when each entry was created, updated and last read, and how many times
+// it was read, as reported by EntryInfo and ColdestKeys. Reads are Get, Lookup, Fetch and GetOrSet.
+// Time is measured by RealClock, unless another clock is set by opts.
+func WithEntryStats(opts ...ClockOption) Option {
+	return func(o *options) {
+		o.entryClock = newClock(opts)
+	}
+}
+
+// EntryInfo describes the history of a Map entry tracked by WithEntryStats.
+type EntryInfo struct {
+	Created  time.Time // when the key was added
+	Updated  time.Time // when the value was last set
+	Accessed time.Time // when the value was last read; zero if it was never read
+	Hits     uint64    // number of reads
+}
+
+// lastUse returns the time of the last read or write.
+func (e EntryInfo) lastUse() time.Time {
+	if e.Accessed.After(e.Updated) {
+		return e.Accessed
+	}
+	return e.Updated
+}
+
+// entryMeta holds UnixNano times. Reads update it atomically under the read lock.
+type entryMeta struct {
+	created, updated int64
+	accessed         atomic.Int64
+	hits             atomic.Uint64
+}
+
+func (e *entryMeta) info() EntryInfo {
+	info := EntryInfo{
+		Created: time.Unix(0, e.created),
+		Updated: time.Unix(0, e.updated),
+		Hits:    e.hits.Load(),
+	}
+	if t := e.accessed.Load(); t != 0 {
+		info.Accessed = time.Unix(0, t)
+	}
+	return info
+}
+
+// resetMeta starts tracking the current entries anew. m.mx must be held.
+func (m *Map[K, T]) resetMeta() {
+	if m.opts.entryClock == nil {
+		m.meta = nil
+		return
+	}
+	now := m.opts.entryClock.Now().UnixNano()
+	m.meta = make(map[K]*entryMeta, len(m.vals))
+	for k := range m.vals {
+		m.meta[k] = &entryMeta{created: now, updated: now}
+	}
+}
+
+// updated records a write of the key. m.mx must be held.
+func (m *Map[K, T]) updated(key K) {
+	now := m.opts.entryClock.Now().UnixNano()
+	if e, ok := m.meta[key]; ok {
+		e.updated = now
+	} else {
+		m.meta[key] = &entryMeta{created: now, updated: now}
+	}
+}
+
+// accessed records a read of the key. m.mx must be held for reading.
+func (m *Map[K, T]) accessed(key K) {
+	if e, ok := m.meta[key]; ok {
+		e.accessed.Store(m.opts.entryClock.Now().UnixNano())
+		e.hits.Add(1)
+	}
+}
+
+// EntryInfo returns the tracked history of the key; ok is false if the key is not present
+// or the map was not created with WithEntryStats. It does not count as a read.
+func (m *Map[K, T]) EntryInfo(key K) (info EntryInfo, ok bool) {
+	key = m.normKey(key)
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if e, ok := m.meta[key]; ok {
+		return e.info(), true
+	}
+	return
+}
+
+// ColdestKeys returns up to n keys that have gone the longest without being read or written,
+// coldest first. It returns nil if the map was not created with WithEntryStats.
+func (m *Map[K, T]) ColdestKeys(n int) []K {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if m.meta == nil || n <= 0 {
+		return nil
+	}
+	type used struct {
+		key K
+		at  time.Time
+	}
+	all := make([]used, 0, len(m.meta))
+	for k, e := range m.meta {
+		all = append(all, used{k, e.info().lastUse()})
+	}
+	slices.SortFunc(all, func(a, b used) int { return a.at.Compare(b.at) })
+	keys := make([]K, min(n, len(all)))
+	for i := range keys {
+		keys[i] = all[i].key
+	}
+	return keys
+}
@@ -0,0 +1,43 @@
+package xsync
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMap_WithEntryStats(t *testing.T) {
+	c := NewFakeClock(time.Unix(100, 0))
+	m := NewMapPtr(map[string]int{"a": 1}, WithEntryStats(WithClock(c)))
+
+	c.Advance(time.Second)
+	m.Set("b", 2)
+	c.Advance(time.Second)
+	m.Set("a", 10)
+	m.Get("b")
+	m.Get("b")
+	m.GetOrSet("b", func() int { return 0 })
+	_, ok := m.Lookup("missing")
+	require(t, !ok)
+
+	a, ok := m.EntryInfo("a")
+	require(t, ok && a.Created.Unix() == 100 && a.Updated.Unix() == 102 && a.Accessed.IsZero() && a.Hits == 0)
+	b, _ := m.EntryInfo("b")
+	require(t, b.Created.Unix() == 101 && b.Updated.Unix() == 101 && b.Accessed.Unix() == 102 && b.Hits == 3)
+
+	c.Advance(time.Second)
+	m.Set("c", 3)
+	c.Advance(time.Second)
+	m.Get("a")
+	require(t, slices.Equal(m.ColdestKeys(2), []string{"b", "c"}))
+	require(t, len(m.ColdestKeys(10)) == 3)
+
+	m.Delete("b")
+	_, ok = m.EntryInfo("b")
+	require(t, !ok)
+
+	var plain Map[string, int]
+	plain.Set("a", 1)
+	_, ok = plain.EntryInfo("a")
+	require(t, !ok && plain.ColdestKeys(1) == nil)
+}
@@ -0,0 +1,131 @@
+package xsync
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sync"
+)
+
+// Binary snapshots written by BinaryEncode are wrapped in an envelope:
+//
+//	magic "XSYN" | format version (1 byte) | payload length (8 bytes, big-endian) | payload | CRC-32C of payload (4 bytes, big-endian)
+//
+// Version 1 envelopes have no checksum trailer. Streams without the envelope (written by older versions)
+// are decoded as plain gob.
+const (
+	binaryMagic         = "XSYN"
+	binaryFormatVersion = 2
+	binaryHeaderSize    = len(binaryMagic) + 1 + 8
+	binaryTrailerSize   = 4
+)
+
+var ErrFormatVersion = errors.New("xsync: unsupported binary format version")
+
+// ErrCorruptSnapshot is returned when a binary snapshot is truncated or fails its checksum. It wraps ErrCorrupt.
+var ErrCorruptSnapshot = fmt.Errorf("%w snapshot", ErrCorrupt)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// bufPool holds buffers for encoding snapshots, so that frequent snapshotting does not allocate
+// a new buffer every time. Buffers grown larger than maxPooledBuffer are dropped.
+var bufPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+const maxPooledBuffer = 1 << 20
+
+func getBuffer() *bytes.Buffer {
+	return bufPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		buf.Reset()
+		bufPool.Put(buf)
+	}
+}
+
+func writeEnvelope(w io.Writer, encode func(io.Writer) error) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	buf.Write(make([]byte, binaryHeaderSize))
+	if err := encode(buf); err != nil {
+		return err
+	}
+	b := buf.Bytes()
+	copy(b, binaryMagic)
+	b[len(binaryMagic)] = binaryFormatVersion
+	binary.BigEndian.PutUint64(b[len(binaryMagic)+1:], uint64(len(b)-binaryHeaderSize))
+	b = binary.BigEndian.AppendUint32(b, crc32.Checksum(b[binaryHeaderSize:], crcTable))
+	_, err := w.Write(b)
+	return err
+}
+
+// readEnvelope returns a reader of the envelope payload.
+func readEnvelope(r io.Reader) (io.Reader, error) {
+	head := make([]byte, binaryHeaderSize)
+	n, err := io.ReadFull(r, head[:len(binaryMagic)])
+	if err != nil || string(head[:n]) != binaryMagic {
+		if n == 0 && err != nil {
+			return nil, err
+		}
+		return io.MultiReader(bytes.NewReader(head[:n]), r), nil // legacy stream
+	}
+	if _, err = io.ReadFull(r, head[len(binaryMagic):]); err != nil {
+		return nil, truncated(err)
+	}
+	v := head[len(binaryMagic)]
+	if v != 1 && v != binaryFormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrFormatVersion, v)
+	}
+	size := binary.BigEndian.Uint64(head[len(binaryMagic)+1:])
+	payload := bytes.NewBuffer(nil)
+	if _, err = io.CopyN(payload, r, int64(size)); err != nil {
+		return nil, truncated(err)
+	}
+	if v == 1 {
+		return payload, nil
+	}
+	var trailer [binaryTrailerSize]byte
+	if _, err = io.ReadFull(r, trailer[:]); err != nil {
+		return nil, truncated(err)
+	}
+	if binary.BigEndian.Uint32(trailer[:]) != crc32.Checksum(payload.Bytes(), crcTable) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptSnapshot)
+	}
+	return payload, nil
+}
+
+// truncated wraps a read error of an incomplete snapshot.
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: %w", ErrCorruptSnapshot, io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// countingWriter counts bytes written to w, for io.WriterTo implementations.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingReader counts bytes read from r, for io.ReaderFrom implementations.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
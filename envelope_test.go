@@ -0,0 +1,97 @@
+package xsync
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestMap_BinaryEncode(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1, "b": 2})
+	var m2 Map[string, int]
+	var buf bytes.Buffer
+
+	require(t, nil == m.BinaryEncode(&buf))
+	require(t, binaryMagic == buf.String()[:4])
+	require(t, nil == m2.BinaryDecode(&buf))
+
+	require(t, m.Equal(&m2))
+}
+
+func TestMap_BinaryDecode_legacy(t *testing.T) {
+	var m Map[string, int]
+	var buf bytes.Buffer
+	gob.NewEncoder(&buf).Encode(map[string]int{"a": 1})
+
+	require(t, nil == m.BinaryDecode(&buf))
+	require(t, 1 == m.Get("a"))
+}
+
+func TestMap_BinaryDecode_version(t *testing.T) {
+	var m Map[string, int]
+	data := []byte(binaryMagic + "\x09\x00\x00\x00\x00\x00\x00\x00\x00")
+
+	err := m.UnmarshalBinary(data)
+
+	require(t, errors.Is(err, ErrFormatVersion))
+}
+
+func TestGobEncode_nested(t *testing.T) {
+	type state struct {
+		Map *Map[string, int]
+		Set *Set[int]
+	}
+	in := state{NewMapPtr(map[string]int{"a": 1}), NewSetPtr([]int{1, 2})}
+	var buf bytes.Buffer
+	require(t, nil == gob.NewEncoder(&buf).Encode(in))
+
+	var out state
+	require(t, nil == gob.NewDecoder(&buf).Decode(&out))
+	require(t, in.Map.Equal(out.Map) && in.Set.Equal(out.Set))
+
+	data, err := in.Set.MarshalBinary()
+	require(t, err == nil && binaryMagic == string(data[:4]))
+	var s Set[int]
+	require(t, nil == s.UnmarshalBinary(data) && 2 == s.Size())
+}
+
+func TestWriteTo(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewMapPtr(map[string]int{"a": 1})
+	s := NewSetPtr([]int{1, 2})
+	n1, err := m.WriteTo(&buf)
+	require(t, err == nil && n1 == int64(buf.Len()))
+	n2, err := s.WriteTo(&buf)
+	require(t, err == nil && n1+n2 == int64(buf.Len()))
+	buf.WriteString("trailing")
+
+	var m2 Map[string, int]
+	var s2 Set[int]
+	n, err := m2.ReadFrom(&buf)
+	require(t, err == nil && n == n1 && m.Equal(&m2))
+	n, err = s2.ReadFrom(&buf)
+	require(t, err == nil && n == n2 && s.Equal(&s2))
+	require(t, "trailing" == buf.String())
+}
+
+func TestMap_BinaryDecode_corrupt(t *testing.T) {
+	m := NewMapPtr(map[string]int{"a": 1, "b": 2})
+	data, _ := m.MarshalBinary()
+
+	var m2 Map[string, int]
+	bad := bytes.Clone(data)
+	bad[binaryHeaderSize+2] ^= 1
+	require(t, errors.Is(m2.UnmarshalBinary(bad), ErrCorruptSnapshot))
+
+	err := m2.UnmarshalBinary(data[:len(data)-1])
+	require(t, errors.Is(err, ErrCorruptSnapshot) && errors.Is(err, io.ErrUnexpectedEOF))
+	require(t, errors.Is(m2.UnmarshalBinary(data[:7]), ErrCorruptSnapshot))
+	require(t, 0 == m2.Len())
+
+	// version 1 envelopes have no checksum
+	v1 := bytes.Clone(data[:len(data)-binaryTrailerSize])
+	v1[len(binaryMagic)] = 1
+	require(t, nil == m2.UnmarshalBinary(v1) && m.Equal(&m2))
+}
@@ -0,0 +1,58 @@
+package xsync
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by containers. Returned errors wrap them with details, so test them with errors.Is.
+var (
+	// ErrKeyNotFound is returned when a required key is missing.
+	ErrKeyNotFound = errors.New("xsync: key not found")
+
+	// ErrTypeMismatch is returned when data cannot be converted to the container key or value types.
+	ErrTypeMismatch = errors.New("xsync: type mismatch")
+
+	// ErrCorrupt is returned when decoded data is malformed or inconsistent.
+	ErrCorrupt = errors.New("xsync: corrupt data")
+
+	// ErrCapacityExceeded is returned when an operation would grow a bounded container past its capacity.
+	ErrCapacityExceeded = errors.New("xsync: capacity exceeded")
+
+	// ErrVersionMismatch is returned when a conditional update finds the container at another version.
+	ErrVersionMismatch = errors.New("xsync: version mismatch")
+)
+
+// A DecodeOption makes decoding stricter.
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	disallowUnknown, disallowDuplicates bool
+}
+
+func newDecodeOptions(opts []DecodeOption) (o decodeOptions) {
+	for _, fn := range opts {
+		fn(&o)
+	}
+	return
+}
+
+// DisallowUnknownFields makes decoding fail if a JSON object decoded into a struct value or key
+// has a field that the struct does not have.
+func DisallowUnknownFields() DecodeOption {
+	return func(o *decodeOptions) {
+		o.disallowUnknown = true
+	}
+}
+
+// DisallowDuplicateKeys makes decoding fail with ErrCorrupt if the input repeats a key.
+// By default the last occurrence wins.
+func DisallowDuplicateKeys() DecodeOption {
+	return func(o *decodeOptions) {
+		o.disallowDuplicates = true
+	}
+}
+
+func errDuplicateKey(key any) error {
+	return fmt.Errorf("%w: duplicate key %v", ErrCorrupt, key)
+}
@@ -0,0 +1,40 @@
+package xsync
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMap_Fetch(t *testing.T) {
+	m := NewMapPtr(map[string]int{"a": 1})
+	v, err := m.Fetch("a")
+	require(t, err == nil && 1 == v)
+	_, err = m.Fetch("b")
+	require(t, errors.Is(err, ErrKeyNotFound))
+}
+
+func TestMap_TrySet(t *testing.T) {
+	m := NewMapPtr(map[string]int{"a": 1}, WithMaxEntries(2, EvictLRU))
+	require(t, nil == m.TrySet("b", 2))
+	require(t, errors.Is(m.TrySet("c", 3), ErrCapacityExceeded))
+	require(t, nil == m.TrySet("a", 10) && 10 == m.Get("a") && 2 == m.Len())
+	require(t, nil == NewMapPtr[string, int](nil).TrySet("x", 1))
+}
+
+func TestJSONDecode_strict(t *testing.T) {
+	var m Map[string, int]
+	require(t, nil == m.JSONDecode(strings.NewReader(`{"a":1,"a":2}`)) && 2 == m.Get("a"))
+	err := m.JSONDecode(strings.NewReader(`{"b":1,"b":2}`), DisallowDuplicateKeys())
+	require(t, errors.Is(err, ErrCorrupt) && !m.Exists("b"))
+	require(t, errors.Is(m.JSONDecode(strings.NewReader(`[1]`)), ErrTypeMismatch))
+
+	type point struct{ X, Y int }
+	var pm Map[string, point]
+	require(t, nil == pm.JSONDecode(strings.NewReader(`{"p":{"X":1,"Z":3}}`)))
+	require(t, nil != pm.JSONDecode(strings.NewReader(`{"p":{"X":1,"Z":3}}`), DisallowUnknownFields()))
+
+	var s Set[int]
+	require(t, errors.Is(s.JSONDecode(strings.NewReader(`[1,1]`), DisallowDuplicateKeys()), ErrCorrupt))
+	require(t, nil == s.JSONDecode(strings.NewReader(`[1,1]`)) && 1 == s.Size())
+}
@@ -0,0 +1,69 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// An Event is a manual-reset signal. Set releases all current and future waiters until Reset is called.
+//
+// A zero Event is not set and is ready to use.
+type Event struct {
+	mx  sync.Mutex
+	set bool
+	ch  chan struct{} // closed when the event is set
+}
+
+// channel returns the channel of the current state. e.mx must be held.
+func (e *Event) channel() chan struct{} {
+	if e.ch == nil {
+		e.ch = make(chan struct{})
+	}
+	return e.ch
+}
+
+// Set sets the event, releasing all waiters.
+func (e *Event) Set() {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if !e.set {
+		e.set = true
+		close(e.channel())
+	}
+}
+
+// Reset clears the event, so that subsequent Wait calls block until the next Set.
+func (e *Event) Reset() {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if e.set {
+		e.set, e.ch = false, nil
+	}
+}
+
+// IsSet reports whether the event is set.
+func (e *Event) IsSet() bool {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	return e.set
+}
+
+// Done returns a channel that is closed when the event is set.
+func (e *Event) Done() <-chan struct{} {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	return e.channel()
+}
+
+// Wait blocks until the event is set or ctx is done, in which case it returns ctx.Err().
+func (e *Event) Wait(ctx context.Context) error {
+	if e.IsSet() {
+		return nil
+	}
+	select {
+	case <-e.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,32 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEvent(t *testing.T) {
+	var e Event
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require(t, !e.IsSet())
+	require(t, context.DeadlineExceeded == e.Wait(ctx))
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { done <- e.Wait(context.Background()) }()
+	}
+	e.Set()
+	e.Set()
+	require(t, nil == <-done && nil == <-done)
+	require(t, e.IsSet() && nil == e.Wait(ctx))
+
+	e.Reset()
+	require(t, !e.IsSet())
+	select {
+	case <-e.Done():
+		t.Fatal()
+	default:
+	}
+}
@@ -0,0 +1,306 @@
+package xsync
+
+import (
+	"container/heap"
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// EvictionPolicy defines which entry is evicted from a size-bounded Map.
+type EvictionPolicy uint8
+
+const (
+	EvictLRU    EvictionPolicy = iota // least recently used (set or get)
+	EvictLFU                          // least frequently used; ties are broken by recency
+	EvictFIFO                         // oldest inserted
+	EvictRandom                       // random entry
+)
+
+// WithMaxEntries bounds the number of map entries. When a new key is set into a full map,
+// an entry chosen by the policy is evicted first and passed to the WithOnEvict callback.
+func WithMaxEntries(n int, policy EvictionPolicy) Option {
+	return func(o *options) {
+		o.maxEntries, o.policy = n, policy
+	}
+}
+
+// WithOnEvict sets the callback called with entries evicted from a size-bounded map.
+// The callback is called while the map is locked, so it must not call methods of the map.
+// Its key and value types must match the map types, otherwise the map constructor panics.
+func WithOnEvict[K comparable, T any](fn func(key K, value T)) Option {
+	return func(o *options) {
+		o.onEvict = fn
+	}
+}
+
+// RemovalReason tells why an entry was removed from a Map.
+type RemovalReason uint8
+
+const (
+	RemovedByDelete   RemovalReason = iota // Delete, DeleteFunc
+	RemovedByClear                         // Clear
+	RemovedByPop                           // Pop, TryPop, PopN, PopAll
+	RemovedByEviction                      // eviction from a map bounded by WithMaxEntries
+	RemovedByExpiry                        // expiration of an entry
+)
+
+func (r RemovalReason) String() string {
+	switch r {
+	case RemovedByDelete:
+		return "delete"
+	case RemovedByClear:
+		return "clear"
+	case RemovedByPop:
+		return "pop"
+	case RemovedByEviction:
+		return "eviction"
+	case RemovedByExpiry:
+		return "expiry"
+	}
+	return "unknown"
+}
+
+// WithOnDelete sets the callback called with every entry removed from a map and the removal reason,
+// e.g. to release resources held by values. Values overwritten by Set are not reported.
+// The callback is called while the map is locked, so it must not call methods of the map.
+// Its key and value types must match the map types, otherwise the map constructor panics.
+func WithOnDelete[K comparable, T any](fn func(key K, value T, reason RemovalReason)) Option {
+	return func(o *options) {
+		o.onDelete = fn
+	}
+}
+
+// checkCallback panics if a callback set by the option does not have type F.
+func checkCallback[F any](option string, fn any) {
+	if _, ok := fn.(F); fn != nil && !ok {
+		panic(fmt.Sprintf("xsync: %s callback type %T does not match the map", option, fn))
+	}
+}
+
+// remove deletes the key and reports the removed entry to callbacks. m.mx must be held.
+func (m *Map[K, T]) remove(key K, reason RemovalReason) bool {
+	val, ok := m.vals[key]
+	if ok {
+		m.del(key)
+		m.removed(key, val, reason)
+	}
+	return ok
+}
+
+// removed reports a removed entry to callbacks. m.mx must be held.
+func (m *Map[K, T]) removed(key K, val T, reason RemovalReason) {
+	if reason == RemovedByEviction {
+		if fn, ok := m.opts.onEvict.(func(K, T)); ok {
+			fn(key, val)
+		}
+	}
+	if fn, ok := m.opts.onDelete.(func(K, T, RemovalReason)); ok {
+		fn(key, val, reason)
+	}
+}
+
+// An evictor tracks keys of a bounded map and chooses eviction victims.
+// touch may be called concurrently under the map read lock, so evictors have their own lock.
+type evictor[K comparable] interface {
+	add(key K)
+	touch(key K)
+	remove(key K)
+	victim() (K, bool)
+	reset()
+}
+
+func newEvictor[K comparable](policy EvictionPolicy, rnd *randSource) evictor[K] {
+	switch policy {
+	case EvictLFU:
+		return &lfuEvictor[K]{items: map[K]*lfuItem[K]{}}
+	case EvictFIFO:
+		return &listEvictor[K]{items: map[K]*list.Element{}}
+	case EvictRandom:
+		return &randomEvictor[K]{idx: newKeyIndex[K, struct{}](nil, rnd)}
+	}
+	return &listEvictor[K]{items: map[K]*list.Element{}, lru: true}
+}
+
+// evictOverflow evicts entries until there is room for n new keys. m.mx must be held.
+func (m *Map[K, T]) evictOverflow(n int) {
+	for len(m.vals)+n > m.opts.maxEntries {
+		key, ok := m.evict.victim()
+		if !ok {
+			return
+		}
+		m.remove(key, RemovedByEviction)
+	}
+}
+
+type listEvictor[K comparable] struct {
+	mx    sync.Mutex
+	lru   bool
+	order list.List
+	items map[K]*list.Element
+}
+
+func (e *listEvictor[K]) add(key K) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	e.items[key] = e.order.PushBack(key)
+}
+
+func (e *listEvictor[K]) touch(key K) {
+	if !e.lru {
+		return
+	}
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if el, ok := e.items[key]; ok {
+		e.order.MoveToBack(el)
+	}
+}
+
+func (e *listEvictor[K]) remove(key K) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if el, ok := e.items[key]; ok {
+		e.order.Remove(el)
+		delete(e.items, key)
+	}
+}
+
+func (e *listEvictor[K]) victim() (key K, ok bool) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if el := e.order.Front(); el != nil {
+		return el.Value.(K), true
+	}
+	return
+}
+
+func (e *listEvictor[K]) reset() {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	e.order.Init()
+	e.items = map[K]*list.Element{}
+}
+
+type lfuItem[K comparable] struct {
+	key   K
+	count uint64
+	seq   uint64
+	idx   int
+}
+
+type lfuHeap[K comparable] []*lfuItem[K]
+
+func (h lfuHeap[K]) Len() int { return len(h) }
+
+func (h lfuHeap[K]) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count < h[j].count
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h lfuHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].idx, h[j].idx = i, j
+}
+
+func (h *lfuHeap[K]) Push(x any) {
+	it := x.(*lfuItem[K])
+	it.idx = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *lfuHeap[K]) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return it
+}
+
+type lfuEvictor[K comparable] struct {
+	mx    sync.Mutex
+	seq   uint64
+	heap  lfuHeap[K]
+	items map[K]*lfuItem[K]
+}
+
+func (e *lfuEvictor[K]) add(key K) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	e.seq++
+	it := &lfuItem[K]{key: key, count: 1, seq: e.seq}
+	e.items[key] = it
+	heap.Push(&e.heap, it)
+}
+
+func (e *lfuEvictor[K]) touch(key K) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if it, ok := e.items[key]; ok {
+		e.seq++
+		it.count++
+		it.seq = e.seq
+		heap.Fix(&e.heap, it.idx)
+	}
+}
+
+func (e *lfuEvictor[K]) remove(key K) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if it, ok := e.items[key]; ok {
+		heap.Remove(&e.heap, it.idx)
+		delete(e.items, key)
+	}
+}
+
+func (e *lfuEvictor[K]) victim() (key K, ok bool) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if len(e.heap) > 0 {
+		return e.heap[0].key, true
+	}
+	return
+}
+
+func (e *lfuEvictor[K]) reset() {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	e.heap = nil
+	e.items = map[K]*lfuItem[K]{}
+}
+
+type randomEvictor[K comparable] struct {
+	mx  sync.Mutex
+	idx *keyIndex[K]
+}
+
+func (e *randomEvictor[K]) add(key K) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	e.idx.add(key)
+}
+
+func (e *randomEvictor[K]) touch(K) {}
+
+func (e *randomEvictor[K]) remove(key K) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	e.idx.remove(key)
+}
+
+func (e *randomEvictor[K]) victim() (key K, ok bool) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if len(e.idx.keys) > 0 {
+		return e.idx.random(), true
+	}
+	return
+}
+
+func (e *randomEvictor[K]) reset() {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	e.idx = newKeyIndex[K, struct{}](nil, e.idx.rnd)
+}
@@ -0,0 +1,80 @@
+package xsync
+
+import "testing"
+
+func TestMap_WithMaxEntries(t *testing.T) {
+	var evicted []string
+	onEvict := WithOnEvict(func(k string, v int) { evicted = append(evicted, k) })
+
+	m := NewMapPtr[string, int](nil, WithMaxEntries(2, EvictLRU), onEvict)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Get("a")
+	m.Set("c", 3)
+	require(t, m.Len() == 2)
+	require(t, !m.Exists("b"))
+	require(t, len(evicted) == 1 && evicted[0] == "b")
+
+	m.Set("a", 10) // update of an existing key does not evict
+	require(t, m.Len() == 2 && len(evicted) == 1)
+
+	evicted = nil
+	m = NewMapPtr[string, int](nil, WithMaxEntries(2, EvictFIFO), onEvict)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Get("a")
+	m.Set("c", 3)
+	require(t, !m.Exists("a") && m.Exists("b") && m.Exists("c"))
+	require(t, len(evicted) == 1 && evicted[0] == "a")
+
+	m = NewMapPtr[string, int](nil, WithMaxEntries(2, EvictLFU))
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Get("a")
+	m.Get("a")
+	m.Get("b")
+	m.Set("c", 3)
+	m.Set("d", 4) // c has the lowest frequency
+	require(t, m.Exists("a") && !m.Exists("b") && !m.Exists("c") && m.Exists("d"))
+
+	m = NewMapPtr(map[string]int{"a": 1, "b": 2, "c": 3}, WithMaxEntries(2, EvictRandom))
+	require(t, m.Len() == 2)
+	m.Set("d", 4)
+	require(t, m.Len() == 2 && m.Exists("d"))
+
+	m.replace(map[string]int{"x": 1, "y": 2, "z": 3})
+	require(t, m.Len() == 2)
+}
+
+func TestWithOnEvict_typeMismatch(t *testing.T) {
+	defer func() { require(t, recover() != nil) }()
+	NewMapPtr[string, int](nil, WithMaxEntries(1, EvictLRU), WithOnEvict(func(string, string) {}))
+}
+
+func TestMap_WithOnDelete(t *testing.T) {
+	removed := map[string]RemovalReason{}
+	onDelete := WithOnDelete(func(k string, v int, reason RemovalReason) { removed[k] = reason })
+
+	m := NewMapPtr(map[string]int{"a": 1, "b": 2, "c": 3, "d": 4}, WithMaxEntries(4, EvictFIFO), onDelete)
+	m.Delete("a")
+	m.Delete("x")
+	m.Pop()
+	m.Set("e", 5)
+	m.Set("f", 6)
+	require(t, len(removed) == 2)
+	m.Set("g", 7)
+	require(t, len(removed) == 3)
+	m.Clear()
+	require(t, m.Len() == 0 && len(removed) == 7)
+	require(t, removed["a"] == RemovedByDelete)
+	require(t, removed["e"] == RemovedByClear && removed["g"] == RemovedByClear)
+
+	var evicted int
+	for _, r := range removed {
+		if r == RemovedByEviction {
+			evicted++
+		}
+	}
+	require(t, evicted == 1)
+	require(t, RemovedByPop.String() == "pop")
+}
@@ -0,0 +1,134 @@
+package xsync
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SaveFile atomically writes a binary snapshot of the map to the file:
+// the snapshot is written to a temporary file which is then renamed to path.
+func (m *Map[K, T]) SaveFile(path string) error {
+	return saveFile(path, m.BinaryEncode)
+}
+
+// LoadFile reads a binary snapshot written by SaveFile.
+func (m *Map[K, T]) LoadFile(path string) error {
+	return loadFile(path, m.BinaryDecode)
+}
+
+// AutoSave saves the map to the file every interval if it was modified since the last save,
+// until ctx is done; then it saves pending modifications for the last time.
+// It is intended to run in its own goroutine and returns the error of the last save attempt.
+func (m *Map[K, T]) AutoSave(ctx context.Context, path string, interval time.Duration) error {
+	return autoSave(ctx, interval, m.Version, func() error { return m.SaveFile(path) })
+}
+
+// SaveFile atomically writes a binary snapshot of the set to the file.
+func (m *Set[K]) SaveFile(path string) error {
+	return saveFile(path, m.BinaryEncode)
+}
+
+// LoadFile reads a binary snapshot written by SaveFile.
+func (m *Set[K]) LoadFile(path string) error {
+	return loadFile(path, m.BinaryDecode)
+}
+
+// AutoSave saves the set to the file every interval if it was modified since the last save, until ctx is done.
+// See Map.AutoSave.
+func (m *Set[K]) AutoSave(ctx context.Context, path string, interval time.Duration) error {
+	return autoSave(ctx, interval, m.Version, func() error { return m.SaveFile(path) })
+}
+
+func saveFile(path string, encode func(io.Writer) error) (err error) {
+	f, err := createTemp(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	w := bufio.NewWriter(f)
+	if err = encode(w); err != nil {
+		return
+	}
+	if err = w.Flush(); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return renameDurable(f.Name(), path)
+}
+
+// createTemp creates a temporary file in the directory of path, with the permissions of path
+// or 0644 if it does not exist, to be renamed to path by renameDurable.
+func createTemp(path string) (*os.File, error) {
+	mode := os.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	if err = f.Chmod(mode); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+// renameDurable renames the synced file tmp to path and syncs the directory, so that the rename survives a crash.
+func renameDurable(tmp, path string) error {
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+func loadFile(path string, decode func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return decode(bufio.NewReader(f))
+}
+
+func autoSave(ctx context.Context, interval time.Duration, version func() uint64, save func() error) (err error) {
+	saved := version()
+	trySave := func() {
+		if ver := version(); ver != saved {
+			if err = save(); err == nil {
+				saved = ver
+			}
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			trySave()
+		case <-ctx.Done():
+			trySave()
+			return
+		}
+	}
+}
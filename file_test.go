@@ -0,0 +1,54 @@
+package xsync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMap_SaveFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.bin")
+	m := NewMap(map[string]int{"a": 1, "b": 2})
+	var m2 Map[string, int]
+
+	require(t, nil == m.SaveFile(path))
+	require(t, nil == m2.LoadFile(path))
+
+	require(t, m.Equal(&m2))
+}
+
+func TestMap_SaveFile_mode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.bin")
+	var m Map[string, int]
+	mode := func() os.FileMode {
+		fi, err := os.Stat(path)
+		require(t, err == nil)
+		return fi.Mode().Perm()
+	}
+
+	require(t, nil == m.SaveFile(path))
+	require(t, 0o644 == mode())
+	require(t, nil == os.Chmod(path, 0o640))
+	require(t, nil == m.SaveFile(path))
+	require(t, 0o640 == mode())
+}
+
+func TestMap_AutoSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.bin")
+	var m, m2 Map[string, int]
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.AutoSave(ctx, path, time.Millisecond) }()
+
+	time.Sleep(5 * time.Millisecond)
+	_, err := os.Stat(path)
+	require(t, os.IsNotExist(err))
+
+	m.Set("a", 1)
+	cancel()
+	require(t, nil == <-done)
+	require(t, nil == m2.LoadFile(path))
+	require(t, 1 == m2.Get("a"))
+}
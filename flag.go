@@ -0,0 +1,89 @@
+package xsync
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SetFlag returns a flag.Value (also compatible with pflag) that adds comma-separated values to s.
+// The flag can be repeated: -tag a,b -tag c.
+func SetFlag(s *Set[string]) *StringSetFlag {
+	return &StringSetFlag{s}
+}
+
+// MapFlag returns a flag.Value (also compatible with pflag) that sets comma-separated key=value pairs in m.
+// The flag can be repeated: -label a=1,b=2 -label c=3.
+func MapFlag(m *Map[string, string]) *StringMapFlag {
+	return &StringMapFlag{m}
+}
+
+// StringSetFlag is a flag.Value backed by a Set[string].
+type StringSetFlag struct {
+	s *Set[string]
+}
+
+func (f *StringSetFlag) String() string {
+	if f == nil || f.s == nil {
+		return ""
+	}
+	keys := f.s.Values()
+	slices.Sort(keys)
+	return strings.Join(keys, ",")
+}
+
+func (f *StringSetFlag) Set(value string) error {
+	var keys []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			keys = append(keys, v)
+		}
+	}
+	f.s.AddMany(keys...)
+	return nil
+}
+
+// Type implements pflag.Value.
+func (f *StringSetFlag) Type() string {
+	return "strings"
+}
+
+// StringMapFlag is a flag.Value backed by a Map[string, string].
+type StringMapFlag struct {
+	m *Map[string, string]
+}
+
+func (f *StringMapFlag) String() string {
+	if f == nil || f.m == nil {
+		return ""
+	}
+	vals := f.m.KeyValues()
+	keys := mapKeys(vals)
+	slices.Sort(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + vals[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f *StringMapFlag) Set(value string) error {
+	vals := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("xsync: invalid key=value pair %q", pair)
+		}
+		vals[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	f.m.MergeMap(vals, nil)
+	return nil
+}
+
+// Type implements pflag.Value.
+func (f *StringMapFlag) Type() string {
+	return "stringToString"
+}
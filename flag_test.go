@@ -0,0 +1,30 @@
+package xsync
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestSetFlag(t *testing.T) {
+	var tags Set[string]
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(SetFlag(&tags), "tag", "tags")
+
+	err := fs.Parse([]string{"-tag", "b,a", "-tag", "c"})
+
+	require(t, err == nil)
+	require(t, 3 == tags.Size())
+	require(t, "a,b,c" == fs.Lookup("tag").Value.String())
+}
+
+func TestMapFlag(t *testing.T) {
+	var labels Map[string, string]
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(MapFlag(&labels), "label", "labels")
+
+	require(t, nil == fs.Parse([]string{"-label", "a=1, b=2", "-label", "a=3"}))
+	require(t, "a=3,b=2" == fs.Lookup("label").Value.String())
+	require(t, nil != fs.Parse([]string{"-label", "x"}))
+}
@@ -0,0 +1,110 @@
+package xsync
+
+import (
+	"encoding/json"
+	"maps"
+)
+
+// A FrozenMap is an immutable snapshot of a Map, made by Map.Freeze.
+// It has no mutating methods, so reads never lock and it can be shared freely.
+type FrozenMap[K comparable, T any] struct {
+	ver   uint64
+	vals  map[K]T
+	opts  options // of the source map, for Thaw
+	keyFn func(K) K
+	clone func(T) T
+}
+
+// Freeze returns an immutable copy of the map.
+func (m *Map[K, T]) Freeze() *FrozenMap[K, T] {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return &FrozenMap[K, T]{ver: m.ver, vals: maps.Clone(m.vals), opts: m.opts, keyFn: m.keyFn, clone: m.clone}
+}
+
+// Thaw returns a new mutable Map with the contents of the frozen map and the options of its source map.
+func (m *FrozenMap[K, T]) Thaw() *Map[K, T] {
+	res := &Map[K, T]{}
+	res.initWith(m.KeyValues(), m.opts)
+	return res
+}
+
+func (m *FrozenMap[K, T]) Get(key K) T {
+	return m.cloneVal(m.vals[m.normKey(key)])
+}
+
+func (m *FrozenMap[K, T]) Lookup(key K) (v T, ok bool) {
+	v, ok = m.vals[m.normKey(key)]
+	return m.cloneVal(v), ok
+}
+
+func (m *FrozenMap[K, T]) Exists(key K) bool {
+	_, ok := m.vals[m.normKey(key)]
+	return ok
+}
+
+// normKey returns the key normalized by the WithKeyFunc function of the source map.
+func (m *FrozenMap[K, T]) normKey(key K) K {
+	if m.keyFn != nil {
+		return m.keyFn(key)
+	}
+	return key
+}
+
+// cloneVal returns a copy of the value made by the WithCloner function of the source map, or the value itself.
+// The frozen map shares values with its source map, so they are copied on the way out.
+func (m *FrozenMap[K, T]) cloneVal(v T) T {
+	if m.clone != nil {
+		return m.clone(v)
+	}
+	return v
+}
+
+func (m *FrozenMap[K, T]) Len() int {
+	return len(m.vals)
+}
+
+// Version returns the version of the map at the time it was frozen.
+func (m *FrozenMap[K, T]) Version() uint64 {
+	return m.ver
+}
+
+func (m *FrozenMap[K, T]) Keys() []K {
+	return mapKeys(m.vals)
+}
+
+func (m *FrozenMap[K, T]) Values() []T {
+	vv := make([]T, 0, len(m.vals))
+	for _, v := range m.vals {
+		vv = append(vv, m.cloneVal(v))
+	}
+	return vv
+}
+
+// KeyValues returns a copy of the frozen map contents.
+func (m *FrozenMap[K, T]) KeyValues() map[K]T {
+	res := make(map[K]T, len(m.vals))
+	for k, v := range m.vals {
+		res[k] = m.cloneVal(v)
+	}
+	return res
+}
+
+// Range calls fn for each entry until fn returns false.
+func (m *FrozenMap[K, T]) Range(fn func(key K, value T) bool) {
+	for k, v := range m.vals {
+		if !fn(k, m.cloneVal(v)) {
+			return
+		}
+	}
+}
+
+func (m *FrozenMap[K, T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.vals)
+}
+
+// String returns the map as JSON with sorted keys.
+func (m *FrozenMap[K, T]) String() string {
+	b, _ := marshalJSONSorted(m.vals)
+	return string(b)
+}
@@ -0,0 +1,27 @@
+package xsync
+
+import "testing"
+
+func TestMap_Freeze(t *testing.T) {
+	m := NewMapPtr(map[string]int{"a": 1, "b": 2})
+	m.Set("c", 3)
+
+	f := m.Freeze()
+	m.Set("a", 10)
+	m.Delete("b")
+	require(t, f.Len() == 3 && f.Get("a") == 1 && f.Exists("b"))
+	require(t, f.Version() == 1)
+	require(t, f.String() == `{"a":1,"b":2,"c":3}`)
+
+	kv := f.KeyValues()
+	kv["d"] = 4
+	require(t, !f.Exists("d"))
+
+	n := 0
+	f.Range(func(string, int) bool { n++; return n < 2 })
+	require(t, n == 2)
+
+	m2 := f.Thaw()
+	m2.Set("a", 100)
+	require(t, f.Get("a") == 1 && m2.Get("a") == 100 && m2.Len() == 3)
+}
@@ -0,0 +1,79 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// A Group is a collection of goroutines producing results of type T.
+// Results are returned by Wait in the order the goroutines were submitted.
+//
+// A zero Group is valid, has no limit on the number of active goroutines, and does not cancel on error.
+type Group[T any] struct {
+	mx     sync.Mutex
+	wg     sync.WaitGroup
+	sem    *Semaphore
+	cancel context.CancelCauseFunc
+	res    []T
+	err    error
+}
+
+// NewGroup returns a new Group and an associated Context derived from ctx.
+// The derived Context is canceled the first time a function passed to Go returns an error or Wait returns.
+func NewGroup[T any](ctx context.Context) (*Group[T], context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group[T]{cancel: cancel}, ctx
+}
+
+// SetLimit limits the number of active goroutines in the group to at most n.
+// A negative value indicates no limit.
+// SetLimit must not be called while goroutines of the group are active.
+func (g *Group[T]) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = NewSemaphore(int64(n))
+}
+
+// Go calls fn in a new goroutine, blocking until it can be started within the limit.
+func (g *Group[T]) Go(fn func() (T, error)) {
+	if g.sem != nil {
+		_ = g.sem.Acquire(context.Background(), 1)
+	}
+	g.mx.Lock()
+	i := len(g.res)
+	g.res = append(g.res, *new(T))
+	g.mx.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer g.sem.Release(1)
+		}
+		v, err := fn()
+
+		g.mx.Lock()
+		defer g.mx.Unlock()
+		g.res[i] = v
+		if err != nil && g.err == nil {
+			g.err = err
+			if g.cancel != nil {
+				g.cancel(err)
+			}
+		}
+	}()
+}
+
+// Wait blocks until all function calls from the Go method have returned,
+// then returns their results in submission order and the first non-nil error (if any).
+func (g *Group[T]) Wait() ([]T, error) {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	return g.res, g.err
+}
@@ -0,0 +1,42 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGroup_Wait(t *testing.T) {
+	var g Group[int]
+
+	for i := 0; i < 10; i++ {
+		g.Go(func() (int, error) {
+			time.Sleep(time.Duration(10-i) * time.Millisecond)
+			return i, nil
+		})
+	}
+	res, err := g.Wait()
+
+	require(t, err == nil)
+	require(t, 10 == len(res))
+	for i, v := range res {
+		require(t, i == v)
+	}
+}
+
+func TestGroup_error(t *testing.T) {
+	errTest := errors.New("test")
+	g, ctx := NewGroup[string](context.Background())
+	g.SetLimit(2)
+
+	g.Go(func() (string, error) { return "", errTest })
+	g.Go(func() (string, error) {
+		<-ctx.Done()
+		return "canceled", nil
+	})
+	res, err := g.Wait()
+
+	require(t, err == errTest)
+	require(t, res[1] == "canceled")
+}
@@ -0,0 +1,77 @@
+package xsync
+
+import "sync"
+
+// A Guard is a mutex-protected value container.
+// The value can only be accessed while holding the lock.
+//
+// A zero Guard holds the zero value of T and is ready to use.
+// A Guard must not be copied after first use.
+type Guard[T any] struct {
+	mx  sync.Mutex
+	val T
+}
+
+func NewGuard[T any](val T) *Guard[T] {
+	return &Guard[T]{val: val}
+}
+
+// With calls fn with a pointer to the value while holding the lock.
+// The pointer must not be retained after fn returns.
+func (g *Guard[T]) With(fn func(*T)) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	fn(&g.val)
+}
+
+func (g *Guard[T]) Load() T {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	return g.val
+}
+
+func (g *Guard[T]) Store(val T) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	g.val = val
+}
+
+// A RWGuard is a reader/writer mutex-protected value container.
+//
+// A zero RWGuard holds the zero value of T and is ready to use.
+// A RWGuard must not be copied after first use.
+type RWGuard[T any] struct {
+	mx  sync.RWMutex
+	val T
+}
+
+func NewRWGuard[T any](val T) *RWGuard[T] {
+	return &RWGuard[T]{val: val}
+}
+
+// With calls fn with a pointer to the value while holding the write lock.
+// The pointer must not be retained after fn returns.
+func (g *RWGuard[T]) With(fn func(*T)) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	fn(&g.val)
+}
+
+// RWith calls fn with the value while holding the read lock.
+func (g *RWGuard[T]) RWith(fn func(T)) {
+	g.mx.RLock()
+	defer g.mx.RUnlock()
+	fn(g.val)
+}
+
+func (g *RWGuard[T]) Load() T {
+	g.mx.RLock()
+	defer g.mx.RUnlock()
+	return g.val
+}
+
+func (g *RWGuard[T]) Store(val T) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	g.val = val
+}
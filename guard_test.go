@@ -0,0 +1,54 @@
+package xsync
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestGuard(t *testing.T) {
+	var g Guard[int]
+	require(t, 0 == g.Load())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				g.With(func(v *int) { *v++ })
+			}
+		}()
+	}
+	wg.Wait()
+	require(t, 1000 == g.Load())
+
+	g.Store(5)
+	require(t, 5 == g.Load())
+	require(t, "a" == NewGuard("a").Load())
+}
+
+func TestRWGuard(t *testing.T) {
+	g := NewRWGuard(map[string]int{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				g.With(func(m *map[string]int) { (*m)["a"]++ })
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				g.RWith(func(m map[string]int) { _ = m["a"] })
+			}
+		}()
+	}
+	wg.Wait()
+	require(t, 1000 == g.Load()["a"])
+
+	g.Store(nil)
+	require(t, nil == g.Load())
+}
@@ -0,0 +1,149 @@
+package xsync
+
+import (
+	"slices"
+	"sync"
+)
+
+// A HashMap is a map for keys that are not comparable with ==, e.g. slices or structs with slice fields.
+// Keys are hashed and compared by user-supplied functions; entries with the same hash share a bucket.
+//
+// A HashMap is safe for use by multiple goroutines simultaneously.
+type HashMap[K, T any] struct {
+	mx      sync.RWMutex
+	ver     uint64
+	size    int
+	hash    func(K) uint64
+	eq      func(a, b K) bool
+	buckets map[uint64][]hashEntry[K, T]
+}
+
+type hashEntry[K, T any] struct {
+	key K
+	val T
+}
+
+// NewMapWithHasher returns an empty HashMap. Keys equal by eq must have equal hashes.
+func NewMapWithHasher[K, T any](hash func(K) uint64, eq func(a, b K) bool) *HashMap[K, T] {
+	return &HashMap[K, T]{hash: hash, eq: eq, buckets: map[uint64][]hashEntry[K, T]{}}
+}
+
+// find returns the hash of the key and the index of its entry in the bucket, or -1.
+func (m *HashMap[K, T]) find(key K) (uint64, int) {
+	h := m.hash(key)
+	for i, e := range m.buckets[h] {
+		if m.eq(e.key, key) {
+			return h, i
+		}
+	}
+	return h, -1
+}
+
+func (m *HashMap[K, T]) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.buckets, m.size = map[uint64][]hashEntry[K, T]{}, 0
+	m.ver++
+}
+
+func (m *HashMap[K, T]) Set(key K, value T) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if h, i := m.find(key); i >= 0 {
+		m.buckets[h][i].val = value
+	} else {
+		m.buckets[h] = append(m.buckets[h], hashEntry[K, T]{key, value})
+		m.size++
+	}
+	m.ver++
+}
+
+func (m *HashMap[K, T]) Get(key K) T {
+	v, _ := m.Lookup(key)
+	return v
+}
+
+func (m *HashMap[K, T]) Lookup(key K) (value T, ok bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if h, i := m.find(key); i >= 0 {
+		return m.buckets[h][i].val, true
+	}
+	return
+}
+
+func (m *HashMap[K, T]) Exists(key K) bool {
+	_, ok := m.Lookup(key)
+	return ok
+}
+
+// Delete removes the key and reports whether it was present.
+func (m *HashMap[K, T]) Delete(key K) bool {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	h, i := m.find(key)
+	if i < 0 {
+		return false
+	}
+	if b := slices.Delete(m.buckets[h], i, i+1); len(b) > 0 {
+		m.buckets[h] = b
+	} else {
+		delete(m.buckets, h)
+	}
+	m.size--
+	m.ver++
+	return true
+}
+
+func (m *HashMap[K, T]) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.size
+}
+
+func (m *HashMap[K, T]) Version() uint64 {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.ver
+}
+
+func (m *HashMap[K, T]) Keys() []K {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	keys := make([]K, 0, m.size)
+	for _, b := range m.buckets {
+		for _, e := range b {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+func (m *HashMap[K, T]) Values() []T {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	vals := make([]T, 0, m.size)
+	for _, b := range m.buckets {
+		for _, e := range b {
+			vals = append(vals, e.val)
+		}
+	}
+	return vals
+}
+
+// Range calls fn for each entry until fn returns false.
+// Iteration is done over a snapshot, so fn may modify the map.
+func (m *HashMap[K, T]) Range(fn func(key K, value T) bool) {
+	m.mx.RLock()
+	entries := make([]hashEntry[K, T], 0, m.size)
+	for _, b := range m.buckets {
+		entries = append(entries, b...)
+	}
+	m.mx.RUnlock()
+
+	for _, e := range entries {
+		if !fn(e.key, e.val) {
+			return
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package xsync
+
+import (
+	"hash/fnv"
+	"slices"
+	"testing"
+)
+
+func TestHashMap(t *testing.T) {
+	hash := func(k []string) uint64 {
+		h := fnv.New64a()
+		for _, s := range k {
+			h.Write([]byte(s))
+			h.Write([]byte{0})
+		}
+		return h.Sum64() % 4 // force collisions
+	}
+	m := NewMapWithHasher[[]string, int](hash, slices.Equal[[]string])
+	for i, k := range [][]string{{"a"}, {"a", "b"}, {"b"}, {"c"}, {"d"}, {"e"}, {}} {
+		m.Set(k, i)
+	}
+	m.Set([]string{"a", "b"}, 10)
+	require(t, 7 == m.Len() && 8 == m.Version())
+	require(t, 10 == m.Get([]string{"a", "b"}) && 6 == m.Get(nil) && m.Exists([]string{"e"}))
+	require(t, !m.Exists([]string{"b", "a"}))
+
+	require(t, m.Delete([]string{"b"}) && !m.Delete([]string{"b"}))
+	require(t, 6 == m.Len() && 6 == len(m.Keys()) && 6 == len(m.Values()))
+
+	n := 0
+	m.Range(func(k []string, v int) bool {
+		m.Delete(k) // modification during Range is allowed
+		n++
+		return true
+	})
+	require(t, 6 == n && 0 == m.Len())
+	m.Set([]string{"x"}, 1)
+	m.Clear()
+	require(t, 0 == m.Len() && !m.Exists([]string{"x"}))
+}
@@ -0,0 +1,183 @@
+package xsync
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// An HLL is a HyperLogLog distinct counter: it estimates the number of distinct items added
+// with a standard error of 1.04/sqrt(2^precision) in 2^precision bytes, regardless of the count.
+// Registers are updated atomically, so Add never blocks.
+//
+// Items are hashed with a fixed function, so HLLs can be persisted and merged across processes.
+// Keys of other types can be added with AddHash using any well-distributed, stable hash.
+type HLL struct {
+	p    uint8
+	regs []atomic.Uint32 // four 8-bit registers per word
+}
+
+// NewHLL returns an empty HLL with 2^precision registers. precision is clamped to [4, 18].
+func NewHLL(precision int) *HLL {
+	p := uint8(min(max(precision, 4), 18))
+	return &HLL{p: p, regs: make([]atomic.Uint32, 1<<p/4)}
+}
+
+// Precision returns the precision of the HLL.
+func (h *HLL) Precision() int {
+	return int(h.p)
+}
+
+// Add adds an item.
+func (h *HLL) Add(data []byte) {
+	f := fnv.New64a()
+	f.Write(data)
+	h.addHash(f.Sum64())
+}
+
+// AddString adds an item.
+func (h *HLL) AddString(s string) {
+	f := fnv.New64a()
+	f.Write([]byte(s))
+	h.addHash(f.Sum64())
+}
+
+// AddHash adds an item by its 64-bit hash.
+func (h *HLL) AddHash(hash uint64) {
+	h.addHash(hash)
+}
+
+func (h *HLL) addHash(x uint64) {
+	// fmix64 of MurmurHash3 spreads the bits of weak hashes
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	idx := x >> (64 - h.p)
+	rank := uint32(bits.LeadingZeros64(x<<h.p|1<<(h.p-1)) + 1)
+	h.setMax(int(idx), rank)
+}
+
+// setMax raises register i to at least v.
+func (h *HLL) setMax(i int, v uint32) {
+	w, shift := &h.regs[i/4], uint(i%4)*8
+	for {
+		old := w.Load()
+		if (old>>shift)&0xff >= v {
+			return
+		}
+		if w.CompareAndSwap(old, old&^(0xff<<shift)|v<<shift) {
+			return
+		}
+	}
+}
+
+func (h *HLL) register(i int) uint32 {
+	return h.regs[i/4].Load() >> (uint(i%4) * 8) & 0xff
+}
+
+// Estimate returns the estimated number of distinct items added.
+func (h *HLL) Estimate() uint64 {
+	m := float64(int(1) << h.p)
+	var sum float64
+	zeros := 0
+	for i := 0; i < 1<<h.p; i++ {
+		r := h.register(i)
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	var alpha float64
+	switch h.p {
+	case 4:
+		alpha = 0.673
+	case 5:
+		alpha = 0.697
+	case 6:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 { // small range correction by linear counting
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(e + 0.5)
+}
+
+// Merge adds the items of other to h. It returns ErrTypeMismatch if the precisions differ.
+func (h *HLL) Merge(other *HLL) error {
+	if h.p != other.p {
+		return fmt.Errorf("%w: cannot merge HLLs of precision %d and %d", ErrTypeMismatch, h.p, other.p)
+	}
+	for i := 0; i < 1<<h.p; i++ {
+		if r := other.register(i); r > 0 {
+			h.setMax(i, r)
+		}
+	}
+	return nil
+}
+
+// Clear removes all items.
+func (h *HLL) Clear() {
+	for i := range h.regs {
+		h.regs[i].Store(0)
+	}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (h *HLL) MarshalBinary() ([]byte, error) {
+	w := bytes.NewBuffer(nil)
+	err := h.BinaryEncode(w)
+	return w.Bytes(), err
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (h *HLL) UnmarshalBinary(data []byte) error {
+	return h.BinaryDecode(bytes.NewReader(data))
+}
+
+// BinaryEncode writes the precision and one byte per register to w.
+func (h *HLL) BinaryEncode(w io.Writer) error {
+	return writeEnvelope(w, func(w io.Writer) error {
+		b := make([]byte, 1+1<<h.p)
+		b[0] = h.p
+		for i := 1; i < len(b); i++ {
+			b[i] = byte(h.register(i - 1))
+		}
+		_, err := w.Write(b)
+		return err
+	})
+}
+
+// BinaryDecode replaces h with an HLL read from r, which may have a different precision.
+// It must not be called concurrently with other methods.
+func (h *HLL) BinaryDecode(r io.Reader) error {
+	r, err := readEnvelope(r)
+	if err != nil {
+		return err
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(b) == 0 || b[0] < 4 || b[0] > 18 || len(b) != 1+1<<b[0] {
+		return fmt.Errorf("%w: invalid HLL", ErrCorruptSnapshot)
+	}
+	res := NewHLL(int(b[0]))
+	for i, r := range b[1:] {
+		if r > 64 {
+			return fmt.Errorf("%w: invalid HLL register", ErrCorruptSnapshot)
+		}
+		res.setMax(i, uint32(r))
+	}
+	h.p, h.regs = res.p, res.regs
+	return nil
+}
@@ -0,0 +1,65 @@
+package xsync
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestHLL(t *testing.T) {
+	h := NewHLL(14)
+	require(t, h.Estimate() == 0)
+	for i := 0; i < 100000; i++ {
+		h.AddString(strconv.Itoa(i))
+		h.Add([]byte(strconv.Itoa(i % 1000))) // duplicates
+	}
+	e := float64(h.Estimate())
+	require(t, math.Abs(e-100000)/100000 < 0.03)
+
+	small := NewHLL(10)
+	for i := uint64(0); i < 100; i++ {
+		small.AddHash(i)
+	}
+	require(t, small.Estimate() >= 97 && small.Estimate() <= 103)
+
+	h.Clear()
+	require(t, h.Estimate() == 0)
+}
+
+func TestHLL_Merge(t *testing.T) {
+	a, b := NewHLL(12), NewHLL(12)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5000; i++ {
+				a.AddString(strconv.Itoa(g*5000 + i))
+				b.AddString(strconv.Itoa(10000 + g*5000 + i))
+			}
+		}()
+	}
+	wg.Wait()
+	require(t, a.Merge(b) == nil)
+	e := float64(a.Estimate())
+	require(t, math.Abs(e-30000)/30000 < 0.05)
+	require(t, errors.Is(a.Merge(NewHLL(10)), ErrTypeMismatch))
+}
+
+func TestHLL_MarshalBinary(t *testing.T) {
+	h := NewHLL(8)
+	for i := 0; i < 1000; i++ {
+		h.AddString(strconv.Itoa(i))
+	}
+	data, err := h.MarshalBinary()
+	require(t, err == nil)
+
+	var h2 HLL
+	require(t, h2.UnmarshalBinary(data) == nil)
+	require(t, h2.Precision() == 8 && h2.Estimate() == h.Estimate())
+
+	data[len(data)-5] = 200
+	require(t, errors.Is(h2.UnmarshalBinary(data), ErrCorruptSnapshot))
+}
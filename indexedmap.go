@@ -0,0 +1,187 @@
+package xsync
+
+import (
+	"encoding/json"
+	"maps"
+	"sync"
+)
+
+// An IndexedMap is a map with named secondary indexes over its values.
+// Indexes are maintained atomically with Set and Delete.
+//
+// A zero IndexedMap is empty and ready to use. It is safe for use by multiple goroutines simultaneously.
+type IndexedMap[K comparable, T any] struct {
+	mx      sync.RWMutex
+	ver     uint64
+	vals    map[K]T
+	indexes map[string]*valueIndex[K, T]
+}
+
+// valueIndex maps index values to the keys of entries having them.
+type valueIndex[K comparable, T any] struct {
+	fn   func(T) string
+	keys map[string]map[K]struct{}
+}
+
+func (x *valueIndex[K, T]) add(key K, val T) {
+	iv := x.fn(val)
+	kk := x.keys[iv]
+	if kk == nil {
+		kk = map[K]struct{}{}
+		x.keys[iv] = kk
+	}
+	kk[key] = struct{}{}
+}
+
+func (x *valueIndex[K, T]) remove(key K, val T) {
+	iv := x.fn(val)
+	if kk := x.keys[iv]; kk != nil {
+		delete(kk, key)
+		if len(kk) == 0 {
+			delete(x.keys, iv)
+		}
+	}
+}
+
+// AddIndex registers the index name computed by fn over values and builds it for the present entries.
+// An index with the same name is replaced.
+func (m *IndexedMap[K, T]) AddIndex(name string, fn func(value T) string) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	x := &valueIndex[K, T]{fn: fn, keys: map[string]map[K]struct{}{}}
+	for k, v := range m.vals {
+		x.add(k, v)
+	}
+	if m.indexes == nil {
+		m.indexes = map[string]*valueIndex[K, T]{}
+	}
+	m.indexes[name] = x
+}
+
+// RemoveIndex unregisters the index name.
+func (m *IndexedMap[K, T]) RemoveIndex(name string) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	delete(m.indexes, name)
+}
+
+// GetByIndex returns the entries whose value is indexed by name as indexValue.
+// It returns nil if there is no such index.
+func (m *IndexedMap[K, T]) GetByIndex(name, indexValue string) map[K]T {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	x, ok := m.indexes[name]
+	if !ok {
+		return nil
+	}
+	kk := x.keys[indexValue]
+	res := make(map[K]T, len(kk))
+	for k := range kk {
+		res[k] = m.vals[k]
+	}
+	return res
+}
+
+// IndexValues returns the distinct values of the index name.
+func (m *IndexedMap[K, T]) IndexValues(name string) []string {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if x, ok := m.indexes[name]; ok {
+		return mapKeys(x.keys)
+	}
+	return nil
+}
+
+func (m *IndexedMap[K, T]) Set(key K, value T) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if m.vals == nil {
+		m.vals = map[K]T{}
+	}
+	m.del(key)
+	m.vals[key] = value
+	for _, x := range m.indexes {
+		x.add(key, value)
+	}
+	m.ver++
+}
+
+func (m *IndexedMap[K, T]) Delete(key K) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if m.del(key) {
+		m.ver++
+	}
+}
+
+// del deletes the key from the map and the indexes. m.mx must be held.
+func (m *IndexedMap[K, T]) del(key K) bool {
+	old, ok := m.vals[key]
+	if ok {
+		delete(m.vals, key)
+		for _, x := range m.indexes {
+			x.remove(key, old)
+		}
+	}
+	return ok
+}
+
+func (m *IndexedMap[K, T]) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.vals = nil
+	for _, x := range m.indexes {
+		x.keys = map[string]map[K]struct{}{}
+	}
+	m.ver++
+}
+
+func (m *IndexedMap[K, T]) Get(key K) T {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.vals[key]
+}
+
+func (m *IndexedMap[K, T]) Lookup(key K) (v T, ok bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	v, ok = m.vals[key]
+	return
+}
+
+func (m *IndexedMap[K, T]) Exists(key K) bool {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	_, ok := m.vals[key]
+	return ok
+}
+
+func (m *IndexedMap[K, T]) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.vals)
+}
+
+func (m *IndexedMap[K, T]) Version() uint64 {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.ver
+}
+
+func (m *IndexedMap[K, T]) Keys() []K {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return mapKeys(m.vals)
+}
+
+func (m *IndexedMap[K, T]) KeyValues() map[K]T {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	res := make(map[K]T, len(m.vals))
+	maps.Copy(res, m.vals)
+	return res
+}
+
+func (m *IndexedMap[K, T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.KeyValues())
+}
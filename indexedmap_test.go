@@ -0,0 +1,37 @@
+package xsync
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIndexedMap(t *testing.T) {
+	type doc struct{ Owner, Kind string }
+	var m IndexedMap[int, doc]
+	m.Set(1, doc{"ann", "a"})
+	m.Set(2, doc{"bob", "a"})
+
+	m.AddIndex("byOwner", func(d doc) string { return d.Owner })
+	m.AddIndex("byKind", func(d doc) string { return d.Kind })
+	m.Set(3, doc{"ann", "b"})
+
+	require(t, 2 == len(m.GetByIndex("byOwner", "ann")))
+	require(t, 2 == len(m.GetByIndex("byKind", "a")))
+	require(t, 0 == len(m.GetByIndex("byOwner", "eve")))
+	require(t, nil == m.GetByIndex("unknown", "ann"))
+
+	m.Set(1, doc{"bob", "b"})
+	res := m.GetByIndex("byOwner", "bob")
+	require(t, 2 == len(res) && res[1].Kind == "b")
+
+	m.Delete(2)
+	require(t, 1 == len(m.GetByIndex("byOwner", "bob")))
+	require(t, 0 == len(m.GetByIndex("byKind", "a")))
+
+	vals := m.IndexValues("byOwner")
+	slices.Sort(vals)
+	require(t, slices.Equal([]string{"ann", "bob"}, vals))
+
+	m.Clear()
+	require(t, 0 == m.Len() && 0 == len(m.IndexValues("byKind")))
+}
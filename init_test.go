@@ -0,0 +1,59 @@
+package xsync
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMap_zeroValue(t *testing.T) {
+	var m Map[string, int]
+	b, err := json.Marshal(&m)
+	require(t, err == nil && string(b) == `{}`)
+	require(t, m.Keys() != nil && m.Values() != nil)
+	require(t, m.vals == nil && m.Version() == 0) // nothing was allocated or mutated
+	require(t, m.PopAll() != nil)
+
+	m.Set("a", 1)
+	m.Clear()
+	require(t, m.vals == nil && m.Len() == 0)
+}
+
+func TestMap_Init(t *testing.T) {
+	var s struct{ m Map[string, int] }
+	s.m.Set("x", 1)
+	ver := s.m.Version()
+
+	m := s.m.Init(WithMaxEntries(2, EvictFIFO))
+	require(t, m == &s.m && m.Len() == 0 && m.Version() == ver+1)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+	require(t, m.Len() == 2)
+
+	var empty Map[string, int]
+	require(t, empty.Init().Version() == 0)
+}
+
+func TestSet_zeroValue(t *testing.T) {
+	var s Set[int]
+	b, err := json.Marshal(&s)
+	require(t, err == nil && string(b) == `[]`)
+	require(t, s.Values() != nil && s.PopAll() != nil)
+
+	s.Set(1)
+	s.Clear()
+	require(t, s.vals == nil && s.Size() == 0)
+}
+
+func TestSet_Init(t *testing.T) {
+	var s Set[int]
+	s.Set(1)
+	ver := s.Version()
+
+	s.Init(WithShards(4))
+	require(t, s.Size() == 0 && s.Version() == ver+1 && len(s.shards) == 4)
+	s.Set(1)
+	s.Set(2)
+	s.Set(3)
+	require(t, s.Size() == 3 && s.Version() > ver+1)
+}
@@ -0,0 +1,161 @@
+package xsync
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// An IntervalMap maps non-overlapping half-open ranges [Lo, Hi) of keys to values,
+// e.g. IP ranges or time windows. Setting a range overwrites the overlapped parts of existing ranges.
+// It is backed by a sorted slice: lookups are O(log n), updates are O(n).
+//
+// An IntervalMap is safe for use by multiple goroutines simultaneously.
+type IntervalMap[K cmp.Ordered, T any] struct {
+	mx  sync.RWMutex
+	ver uint64
+	ivs []Interval[K, T]
+}
+
+// An Interval is a half-open range [Lo, Hi) with a value.
+type Interval[K cmp.Ordered, T any] struct {
+	Lo, Hi K
+	Value  T
+}
+
+var errIntervalOverlap = fmt.Errorf("%w: intervals overlap", ErrCorrupt)
+
+// span returns the indexes [i, j) of the intervals overlapping [lo, hi).
+func (m *IntervalMap[K, T]) span(lo, hi K) (i, j int) {
+	i, _ = slices.BinarySearchFunc(m.ivs, lo, func(iv Interval[K, T], k K) int {
+		if iv.Hi <= k {
+			return -1
+		}
+		return 1
+	})
+	j, _ = slices.BinarySearchFunc(m.ivs, hi, func(iv Interval[K, T], k K) int {
+		if iv.Lo < k {
+			return -1
+		}
+		return 1
+	})
+	return i, max(i, j)
+}
+
+// cut removes [lo, hi) from the intervals, inserting ins in its place.
+func (m *IntervalMap[K, T]) cut(lo, hi K, ins ...Interval[K, T]) bool {
+	i, j := m.span(lo, hi)
+	if i == j && len(ins) == 0 {
+		return false
+	}
+	var parts []Interval[K, T]
+	if i < j && m.ivs[i].Lo < lo {
+		left := m.ivs[i]
+		left.Hi = lo
+		parts = append(parts, left)
+	}
+	parts = append(parts, ins...)
+	if i < j && m.ivs[j-1].Hi > hi {
+		right := m.ivs[j-1]
+		right.Lo = hi
+		parts = append(parts, right)
+	}
+	m.ivs = slices.Replace(m.ivs, i, j, parts...)
+	return true
+}
+
+// Set maps [lo, hi) to value. Empty ranges (lo >= hi) are ignored.
+func (m *IntervalMap[K, T]) Set(lo, hi K, value T) {
+	if lo >= hi {
+		return
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.cut(lo, hi, Interval[K, T]{lo, hi, value})
+	m.ver++
+}
+
+// Delete unmaps [lo, hi), trimming or splitting the overlapped ranges.
+func (m *IntervalMap[K, T]) Delete(lo, hi K) {
+	if lo >= hi {
+		return
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if m.cut(lo, hi) {
+		m.ver++
+	}
+}
+
+// Get returns the value of the range containing the point.
+func (m *IntervalMap[K, T]) Get(point K) T {
+	v, _ := m.Lookup(point)
+	return v
+}
+
+// Lookup returns the value of the range containing the point and whether there is one.
+func (m *IntervalMap[K, T]) Lookup(point K) (value T, ok bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	i, _ := m.span(point, point)
+	if i < len(m.ivs) && m.ivs[i].Lo <= point {
+		return m.ivs[i].Value, true
+	}
+	return
+}
+
+// Overlaps returns the ranges overlapping [lo, hi) in ascending order. The ranges are not trimmed.
+func (m *IntervalMap[K, T]) Overlaps(lo, hi K) []Interval[K, T] {
+	if lo >= hi {
+		return nil
+	}
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	i, j := m.span(lo, hi)
+	return slices.Clone(m.ivs[i:j])
+}
+
+// Replace atomically replaces the contents of the map with the given intervals.
+// It returns an error and leaves the map unchanged if the intervals overlap.
+func (m *IntervalMap[K, T]) Replace(intervals []Interval[K, T]) error {
+	ivs := slices.DeleteFunc(slices.Clone(intervals), func(iv Interval[K, T]) bool { return iv.Lo >= iv.Hi })
+	slices.SortFunc(ivs, func(a, b Interval[K, T]) int { return cmp.Compare(a.Lo, b.Lo) })
+	for i := 1; i < len(ivs); i++ {
+		if ivs[i].Lo < ivs[i-1].Hi {
+			return errIntervalOverlap
+		}
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.ivs = ivs
+	m.ver++
+	return nil
+}
+
+func (m *IntervalMap[K, T]) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.ivs = nil
+	m.ver++
+}
+
+// Intervals returns all ranges in ascending order.
+func (m *IntervalMap[K, T]) Intervals() []Interval[K, T] {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return slices.Clone(m.ivs)
+}
+
+// Len returns the number of ranges.
+func (m *IntervalMap[K, T]) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.ivs)
+}
+
+func (m *IntervalMap[K, T]) Version() uint64 {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.ver
+}
@@ -0,0 +1,41 @@
+package xsync
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIntervalMap(t *testing.T) {
+	type iv = Interval[int, string]
+	var m IntervalMap[int, string]
+	m.Set(0, 10, "a")
+	m.Set(20, 30, "b")
+	m.Set(5, 25, "c")
+	m.Set(7, 7, "empty")
+	require(t, slices.Equal([]iv{{0, 5, "a"}, {5, 25, "c"}, {25, 30, "b"}}, m.Intervals()))
+
+	require(t, "a" == m.Get(0) && "a" == m.Get(4) && "c" == m.Get(5) && "b" == m.Get(29))
+	_, ok := m.Lookup(30)
+	require(t, !ok)
+	_, ok = m.Lookup(-1)
+	require(t, !ok)
+
+	require(t, slices.Equal([]iv{{0, 5, "a"}, {5, 25, "c"}}, m.Overlaps(4, 6)))
+	require(t, slices.Equal([]iv{{25, 30, "b"}}, m.Overlaps(25, 100)))
+	require(t, 0 == len(m.Overlaps(30, 40)) && 0 == len(m.Overlaps(3, 3)))
+
+	m.Delete(10, 20)
+	require(t, slices.Equal([]iv{{0, 5, "a"}, {5, 10, "c"}, {20, 25, "c"}, {25, 30, "b"}}, m.Intervals()))
+	ver := m.Version()
+	m.Delete(12, 18)
+	require(t, ver == m.Version())
+
+	require(t, nil != m.Replace([]iv{{0, 10, "x"}, {5, 15, "y"}}))
+	require(t, 4 == m.Len())
+	require(t, nil == m.Replace([]iv{{10, 20, "y"}, {0, 10, "x"}, {3, 3, "empty"}}))
+	require(t, slices.Equal([]iv{{0, 10, "x"}, {10, 20, "y"}}, m.Intervals()))
+	require(t, "y" == m.Get(10))
+
+	m.Clear()
+	require(t, 0 == m.Len())
+}
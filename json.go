@@ -0,0 +1,294 @@
+package xsync
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// marshalJSONKey encodes a map key the same way encoding/json does.
+func marshalJSONKey(key any) ([]byte, error) {
+	rv := reflect.ValueOf(key)
+	if rv.Kind() == reflect.String {
+		return json.Marshal(rv.String())
+	}
+	if tm, ok := key.(encoding.TextMarshaler); ok {
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return []byte(`""`), nil
+		}
+		b, err := tm.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(string(b))
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Marshal(strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return json.Marshal(strconv.FormatUint(rv.Uint(), 10))
+	}
+	return nil, fmt.Errorf("%w: unsupported json map key type %T", ErrTypeMismatch, key)
+}
+
+// sortKeys sorts keys in their natural order if K is a string or numeric type,
+// otherwise in order of their string representations.
+func sortKeys[K comparable](keys []K) {
+	if len(keys) < 2 {
+		return
+	}
+	switch reflect.ValueOf(keys[0]).Kind() {
+	case reflect.String:
+		slices.SortFunc(keys, func(a, b K) int { return cmp.Compare(reflect.ValueOf(a).String(), reflect.ValueOf(b).String()) })
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		slices.SortFunc(keys, func(a, b K) int { return cmp.Compare(reflect.ValueOf(a).Int(), reflect.ValueOf(b).Int()) })
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		slices.SortFunc(keys, func(a, b K) int { return cmp.Compare(reflect.ValueOf(a).Uint(), reflect.ValueOf(b).Uint()) })
+	case reflect.Float32, reflect.Float64:
+		slices.SortFunc(keys, func(a, b K) int { return cmp.Compare(reflect.ValueOf(a).Float(), reflect.ValueOf(b).Float()) })
+	default:
+		slices.SortFunc(keys, func(a, b K) int { return cmp.Compare(encString(a), encString(b)) })
+	}
+}
+
+// unmarshalJSONKey decodes a map key the same way encoding/json does.
+func unmarshalJSONKey[K any](s string) (key K, err error) {
+	if tu, ok := any(&key).(encoding.TextUnmarshaler); ok {
+		err = tu.UnmarshalText([]byte(s))
+		return
+	}
+	rv := reflect.ValueOf(&key).Elem()
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+		return
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		rv.SetInt(n)
+		return key, err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		rv.SetUint(n)
+		return key, err
+	}
+	return key, fmt.Errorf("%w: unsupported json map key type %T", ErrTypeMismatch, key)
+}
+
+// decodeJSONObject reads a JSON object from dec calling fn for each entry in the order of appearance.
+// If fn returns an error, decoding stops.
+func decodeJSONObject[K, T any](dec *json.Decoder, fn func(K, T) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil { // null
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return fmt.Errorf("%w: expected json object, got %v", ErrTypeMismatch, tok)
+	}
+	for dec.More() {
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		key, err := unmarshalJSONKey[K](tok.(string))
+		if err != nil {
+			return err
+		}
+		var val T
+		if err = dec.Decode(&val); err != nil {
+			return err
+		}
+		if err = fn(key, val); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// MarshalJSONSorted encodes the map as a JSON object with keys in sorted order.
+// Numeric keys are sorted by value, not by their string representation as encoding/json does.
+func (m *Map[K, T]) MarshalJSONSorted() ([]byte, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return marshalJSONSorted(m.vals)
+}
+
+func marshalJSONSorted[K comparable, T any](vals map[K]T) ([]byte, error) {
+	keys := mapKeys(vals)
+	sortKeys(keys)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalJSONKey(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(vals[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+// MarshalJSONSorted encodes the set as a JSON array of sorted keys.
+func (m *Set[K]) MarshalJSONSorted() ([]byte, error) {
+	keys := m.Values()
+	sortKeys(keys)
+	return json.Marshal(keys)
+}
+
+// MarshalText implements encoding.TextMarshaler. The text form is JSON with sorted keys.
+func (m *Map[K, T]) MarshalText() ([]byte, error) {
+	return m.MarshalJSONSorted()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. The text form is JSON.
+func (m *Map[K, T]) UnmarshalText(data []byte) error {
+	return m.UnmarshalJSON(data)
+}
+
+// MarshalText implements encoding.TextMarshaler. The text form is a JSON array of sorted keys.
+func (m *Set[K]) MarshalText() ([]byte, error) {
+	return m.MarshalJSONSorted()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. The text form is a JSON array.
+func (m *Set[K]) UnmarshalText(data []byte) error {
+	return m.UnmarshalJSON(data)
+}
+
+// JSONEncode streams the map to w as a JSON object, entry by entry, while holding the read lock.
+// Unlike MarshalJSON it does not materialize the whole document in memory.
+func (m *Map[K, T]) JSONEncode(w io.Writer) error {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('{')
+	first := true
+	for k, v := range m.vals {
+		if !first {
+			bw.WriteByte(',')
+		}
+		first = false
+		key, err := marshalJSONKey(k)
+		if err != nil {
+			return err
+		}
+		val, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		bw.Write(key)
+		bw.WriteByte(':')
+		if _, err = bw.Write(val); err != nil {
+			return err
+		}
+	}
+	bw.WriteByte('}')
+	return bw.Flush()
+}
+
+// JSONDecode reads a JSON object from r entry by entry and replaces the map contents with it.
+// Unlike UnmarshalJSON it does not need the whole document in memory, and it can be made strict by opts.
+// On error the map is left unchanged.
+func (m *Map[K, T]) JSONDecode(r io.Reader, opts ...DecodeOption) error {
+	o := newDecodeOptions(opts)
+	dec := json.NewDecoder(r)
+	if o.disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	vals := map[K]T{}
+	err := decodeJSONObject(dec, func(k K, v T) error {
+		if _, ok := vals[k]; ok && o.disallowDuplicates {
+			return errDuplicateKey(k)
+		}
+		vals[k] = v
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.replace(vals)
+	return nil
+}
+
+// JSONEncode streams the set to w as a JSON array, key by key, while holding the read lock.
+func (m *Set[K]) JSONEncode(w io.Writer) error {
+	parts := m.rlock()
+	defer runlockParts(parts)
+
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('[')
+	first := true
+	for _, s := range parts {
+		for k := range s.vals {
+			if !first {
+				bw.WriteByte(',')
+			}
+			first = false
+			b, err := json.Marshal(k)
+			if err != nil {
+				return err
+			}
+			if _, err = bw.Write(b); err != nil {
+				return err
+			}
+		}
+	}
+	bw.WriteByte(']')
+	return bw.Flush()
+}
+
+// JSONDecode reads a JSON array from r key by key and replaces the set contents with it.
+// On error the set is left unchanged.
+func (m *Set[K]) JSONDecode(r io.Reader, opts ...DecodeOption) error {
+	o := newDecodeOptions(opts)
+	dec := json.NewDecoder(r)
+	if o.disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	vals := map[K]struct{}{}
+	if tok != nil {
+		if d, ok := tok.(json.Delim); !ok || d != '[' {
+			return fmt.Errorf("%w: expected json array, got %v", ErrTypeMismatch, tok)
+		}
+		for dec.More() {
+			var k K
+			if err = dec.Decode(&k); err != nil {
+				return err
+			}
+			if _, ok := vals[k]; ok && o.disallowDuplicates {
+				return errDuplicateKey(k)
+			}
+			vals[k] = struct{}{}
+		}
+		if _, err = dec.Token(); err != nil {
+			return err
+		}
+	}
+	m.replace(vals)
+	return nil
+}
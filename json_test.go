@@ -0,0 +1,89 @@
+package xsync
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMap_JSONEncode(t *testing.T) {
+	var m, m2 Map[int, string]
+	for i := 0; i < 100; i++ {
+		m.Set(i, strings.Repeat("x", i))
+	}
+	var buf bytes.Buffer
+
+	require(t, nil == m.JSONEncode(&buf))
+	require(t, json.Valid(buf.Bytes()))
+	require(t, nil == m2.JSONDecode(&buf))
+
+	require(t, m.Equal(&m2))
+}
+
+func TestMap_JSONDecode_null(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1})
+
+	require(t, nil == m.JSONDecode(strings.NewReader("null")))
+	require(t, 0 == m.Len())
+	require(t, nil != m.JSONDecode(strings.NewReader("[1]")))
+}
+
+func TestSet_JSONEncode(t *testing.T) {
+	s := NewSet([]string{"a", "b", "c"})
+	var s2 Set[string]
+	var buf bytes.Buffer
+
+	require(t, nil == s.JSONEncode(&buf))
+	require(t, nil == s2.JSONDecode(&buf))
+
+	require(t, s.Equal(&s2))
+}
+
+func TestMap_MarshalJSONSorted(t *testing.T) {
+	m := NewMap(map[int]string{10: "j", 2: "b", -1: "z"})
+
+	data, err := m.MarshalJSONSorted()
+
+	require(t, err == nil)
+	require(t, `{"-1":"z","2":"b","10":"j"}` == string(data))
+	require(t, string(data) == m.String())
+}
+
+func TestSet_String(t *testing.T) {
+	s := NewSet([]int{10, 2, 1})
+	var empty Set[string]
+
+	require(t, `[1,2,10]` == s.String())
+	require(t, `[]` == empty.String())
+	require(t, "10" == s.Strings()[2])
+}
+
+func TestMap_MarshalText(t *testing.T) {
+	var _ encoding.TextMarshaler = (*Map[string, int])(nil)
+	var _ encoding.TextUnmarshaler = (*Set[string])(nil)
+	var m Map[string, int]
+
+	require(t, nil == m.UnmarshalText([]byte(`{"b":2,"a":1}`)))
+	text, err := m.MarshalText()
+
+	require(t, err == nil)
+	require(t, `{"a":1,"b":2}` == string(text))
+}
+
+type upperKey string
+
+func (k upperKey) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(string(k))), nil
+}
+
+func TestMap_MarshalJSON_stringKey(t *testing.T) {
+	m := NewMap(map[upperKey]int{"a": 1})
+
+	data, err := m.MarshalJSON()
+	want, _ := json.Marshal(map[upperKey]int{"a": 1})
+
+	require(t, err == nil)
+	require(t, string(want) == string(data))
+}
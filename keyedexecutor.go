@@ -0,0 +1,83 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// A KeyedExecutor runs tasks so that tasks with the same key run sequentially in submission order,
+// while tasks with different keys run concurrently, up to a limit of concurrently running tasks.
+//
+// A KeyedExecutor is safe for use by multiple goroutines simultaneously.
+type KeyedExecutor[K comparable] struct {
+	sem     *Semaphore
+	pending Latch // queued and running tasks
+
+	mx      sync.Mutex
+	queues  map[K][]func() // a key is present while its runner goroutine is active
+	stopped bool
+}
+
+// NewKeyedExecutor returns a KeyedExecutor running at most limit tasks at once; limit <= 0 means no limit.
+func NewKeyedExecutor[K comparable](limit int) *KeyedExecutor[K] {
+	e := &KeyedExecutor[K]{queues: map[K][]func(){}}
+	if limit > 0 {
+		e.sem = NewSemaphore(int64(limit))
+	}
+	return e
+}
+
+// Submit enqueues fn for the key. Returns ErrStopped if the executor is stopped.
+func (e *KeyedExecutor[K]) Submit(key K, fn func()) error {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if e.stopped {
+		return ErrStopped
+	}
+	e.pending.Add(1)
+	q, active := e.queues[key]
+	e.queues[key] = append(q, fn)
+	if !active {
+		go e.run(key)
+	}
+	return nil
+}
+
+// run executes tasks of the key until its queue is empty.
+func (e *KeyedExecutor[K]) run(key K) {
+	for {
+		e.mx.Lock()
+		q := e.queues[key]
+		if len(q) == 0 {
+			delete(e.queues, key)
+			e.mx.Unlock()
+			return
+		}
+		fn := q[0]
+		q[0] = nil
+		e.queues[key] = q[1:]
+		e.mx.Unlock()
+
+		if e.sem != nil {
+			_ = e.sem.Acquire(context.Background(), 1)
+		}
+		fn()
+		if e.sem != nil {
+			e.sem.Release(1)
+		}
+		e.pending.Done()
+	}
+}
+
+// Len returns the number of queued and running tasks.
+func (e *KeyedExecutor[K]) Len() int {
+	return e.pending.Count()
+}
+
+// Stop stops accepting new tasks and waits until all queued tasks are executed or ctx is done.
+func (e *KeyedExecutor[K]) Stop(ctx context.Context) error {
+	e.mx.Lock()
+	e.stopped = true
+	e.mx.Unlock()
+	return e.pending.Wait(ctx)
+}
@@ -0,0 +1,44 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyedExecutor(t *testing.T) {
+	e := NewKeyedExecutor[int](2)
+	var mx sync.Mutex
+	order := map[int][]int{}
+	var running, maxRunning atomic.Int32
+
+	for i := 0; i < 30; i++ {
+		key, i := i%3, i
+		require(t, nil == e.Submit(key, func() {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			mx.Lock()
+			order[key] = append(order[key], i)
+			mx.Unlock()
+		}))
+	}
+	require(t, nil == e.Stop(context.Background()))
+	require(t, ErrStopped == e.Submit(0, func() {}))
+	require(t, 0 == e.Len() && maxRunning.Load() <= 2)
+
+	for key, ii := range order {
+		require(t, 10 == len(ii))
+		for j, i := range ii {
+			require(t, i == key+3*j)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package xsync
+
+import "sync"
+
+// A KeyedMutex is a set of mutual exclusion locks identified by key.
+// Lock entries are reference-counted and removed once no goroutine holds or waits for them.
+//
+// A zero KeyedMutex is ready to use.
+// A KeyedMutex must not be copied after first use.
+type KeyedMutex[K comparable] struct {
+	mx    sync.Mutex
+	locks map[K]*keyedLock
+}
+
+// A KeyedRWMutex is a set of reader/writer mutual exclusion locks identified by key.
+//
+// A zero KeyedRWMutex is ready to use.
+// A KeyedRWMutex must not be copied after first use.
+type KeyedRWMutex[K comparable] struct {
+	mx    sync.Mutex
+	locks map[K]*keyedLock
+}
+
+type keyedLock struct {
+	sync.RWMutex
+	refs int
+}
+
+func acquireLock[K comparable](mx *sync.Mutex, locks *map[K]*keyedLock, key K) *keyedLock {
+	mx.Lock()
+	defer mx.Unlock()
+	if *locks == nil {
+		*locks = map[K]*keyedLock{}
+	}
+	l, ok := (*locks)[key]
+	if !ok {
+		l = new(keyedLock)
+		(*locks)[key] = l
+	}
+	l.refs++
+	return l
+}
+
+func releaseLock[K comparable](mx *sync.Mutex, locks map[K]*keyedLock, key K) *keyedLock {
+	mx.Lock()
+	defer mx.Unlock()
+	l, ok := locks[key]
+	if !ok {
+		panic("xsync: unlock of unlocked key")
+	}
+	if l.refs--; l.refs == 0 {
+		delete(locks, key)
+	}
+	return l
+}
+
+func (m *KeyedMutex[K]) Lock(key K) {
+	acquireLock(&m.mx, &m.locks, key).Lock()
+}
+
+func (m *KeyedMutex[K]) TryLock(key K) bool {
+	l := acquireLock(&m.mx, &m.locks, key)
+	if l.TryLock() {
+		return true
+	}
+	releaseLock(&m.mx, m.locks, key)
+	return false
+}
+
+func (m *KeyedMutex[K]) Unlock(key K) {
+	releaseLock(&m.mx, m.locks, key).Unlock()
+}
+
+// Len returns the number of keys currently locked or waited for.
+func (m *KeyedMutex[K]) Len() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return len(m.locks)
+}
+
+func (m *KeyedRWMutex[K]) Lock(key K) {
+	acquireLock(&m.mx, &m.locks, key).Lock()
+}
+
+func (m *KeyedRWMutex[K]) TryLock(key K) bool {
+	l := acquireLock(&m.mx, &m.locks, key)
+	if l.TryLock() {
+		return true
+	}
+	releaseLock(&m.mx, m.locks, key)
+	return false
+}
+
+func (m *KeyedRWMutex[K]) Unlock(key K) {
+	releaseLock(&m.mx, m.locks, key).Unlock()
+}
+
+func (m *KeyedRWMutex[K]) RLock(key K) {
+	acquireLock(&m.mx, &m.locks, key).RLock()
+}
+
+func (m *KeyedRWMutex[K]) TryRLock(key K) bool {
+	l := acquireLock(&m.mx, &m.locks, key)
+	if l.TryRLock() {
+		return true
+	}
+	releaseLock(&m.mx, m.locks, key)
+	return false
+}
+
+func (m *KeyedRWMutex[K]) RUnlock(key K) {
+	releaseLock(&m.mx, m.locks, key).RUnlock()
+}
+
+// Len returns the number of keys currently locked or waited for.
+func (m *KeyedRWMutex[K]) Len() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return len(m.locks)
+}
@@ -0,0 +1,50 @@
+package xsync
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestKeyedMutex_Lock(t *testing.T) {
+	var m KeyedMutex[string]
+	var wg sync.WaitGroup
+	cnt := map[string]int{}
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Lock("a")
+			defer m.Unlock("a")
+			cnt["a"]++
+		}()
+	}
+	wg.Wait()
+
+	require(t, 100 == cnt["a"])
+	require(t, 0 == m.Len())
+}
+
+func TestKeyedMutex_TryLock(t *testing.T) {
+	var m KeyedMutex[int]
+
+	require(t, m.TryLock(1))
+	require(t, !m.TryLock(1))
+	require(t, m.TryLock(2))
+	m.Unlock(1)
+	m.Unlock(2)
+	require(t, 0 == m.Len())
+}
+
+func TestKeyedRWMutex_RLock(t *testing.T) {
+	var m KeyedRWMutex[int]
+
+	m.RLock(1)
+	require(t, m.TryRLock(1))
+	require(t, !m.TryLock(1))
+	m.RUnlock(1)
+	m.RUnlock(1)
+	require(t, m.TryLock(1))
+	m.Unlock(1)
+	require(t, 0 == m.Len())
+}
@@ -0,0 +1,19 @@
+package xsync
+
+// WithKeyFunc sets a function normalizing keys of a Map, e.g. strings.ToLower for case-insensitive keys.
+// Keys passed to Set, Get, Lookup, Exists, Delete and other single-key methods, and keys of loaded
+// or merged contents, are normalized before use, so the map only ever holds normalized keys.
+// The function type must match the map key type, otherwise the map constructor panics.
+func WithKeyFunc[K comparable](fn func(key K) K) Option {
+	return func(o *options) {
+		o.keyFunc = fn
+	}
+}
+
+// normKey returns the normalized key.
+func (m *Map[K, T]) normKey(key K) K {
+	if m.keyFn != nil {
+		return m.keyFn(key)
+	}
+	return key
+}
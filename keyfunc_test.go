@@ -0,0 +1,49 @@
+package xsync
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithKeyFunc(t *testing.T) {
+	m := NewMapPtr(map[string]int{" A ": 1}, WithKeyFunc(func(k string) string {
+		return strings.ToLower(strings.TrimSpace(k))
+	}))
+	require(t, 1 == m.Get("a") && m.Exists("A"))
+
+	m.Set("B", 2)
+	m.Increment("b", 1)
+	require(t, 3 == m.Get(" b") && 2 == m.Len())
+	require(t, !m.SetIfAbsent("b", 0) && m.SetIfPresent("B", 4) && 4 == m.Get("b"))
+
+	m.Delete("A")
+	require(t, !m.Exists("a") && 1 == m.Len())
+
+	m.MergeMap(map[string]int{"C": 5}, nil)
+	require(t, 5 == m.Get("c"))
+	require(t, nil == m.UnmarshalJSON([]byte(`{"X":1,"Y":2}`)))
+	require(t, 1 == m.Get("x") && strings.HasSuffix(m.String(), `"x":1,"y":2}`))
+
+	defer func() { require(t, recover() != nil) }()
+	NewMapPtr[int, int](nil, WithKeyFunc(strings.ToLower))
+}
+
+func TestWithKeyFunc_clone(t *testing.T) {
+	m := NewMapPtr(map[string]int{"foo": 1, "bar": 2}, WithKeyFunc(strings.ToLower))
+
+	require(t, 1 == m.Clone().Get("FOO"))
+	require(t, 2 == m.FilterClone(func(k string, _ int) bool { return k == "bar" }).Get("BAR"))
+	thawed := m.Freeze().Thaw()
+	thawed.Set("BAZ", 3)
+	require(t, 1 == thawed.Get("FOO") && 3 == thawed.Get("baz"))
+}
+
+func TestWithKeyFunc_freeze(t *testing.T) {
+	m := NewMapPtr(map[string]int{"abc": 1}, WithKeyFunc(strings.ToLower))
+
+	f := m.Freeze()
+	v, ok := f.Lookup("ABC")
+
+	require(t, 1 == f.Get("ABC") && f.Exists("Abc"))
+	require(t, ok && 1 == v)
+}
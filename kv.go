@@ -0,0 +1,17 @@
+package xsync
+
+// KV is the core key-value interface implemented by Map.
+// It allows code written against Map to switch to other storages, e.g. a remote one.
+type KV[K comparable, T any] interface {
+	Set(key K, value T)
+	Get(key K) T
+	Exists(key K) bool
+	Delete(key K)
+	Clear()
+	Len() int
+	Keys() []K
+	KeyValues() map[K]T
+	Version() uint64
+}
+
+var _ KV[string, any] = (*Map[string, any])(nil)
@@ -0,0 +1,69 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// A Latch lets goroutines wait until its counter drops to zero, like sync.WaitGroup,
+// but Wait respects a context and the latch may be reused after the counter reaches zero.
+//
+// A zero Latch has zero count and is ready to use.
+type Latch struct {
+	mx sync.Mutex
+	n  int
+	ch chan struct{} // closed when n drops to zero; nil while n is zero
+}
+
+// NewLatch returns a Latch with the counter set to n.
+func NewLatch(n int) *Latch {
+	l := &Latch{}
+	l.Add(n)
+	return l
+}
+
+// Add adds delta, which may be negative, to the counter. It panics if the counter becomes negative.
+func (l *Latch) Add(delta int) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	n := l.n + delta
+	if n < 0 {
+		panic("xsync: negative Latch counter")
+	}
+	if l.n == 0 && n > 0 {
+		l.ch = make(chan struct{})
+	}
+	if n == 0 && l.ch != nil {
+		close(l.ch)
+		l.ch = nil
+	}
+	l.n = n
+}
+
+// Done decrements the counter by one.
+func (l *Latch) Done() {
+	l.Add(-1)
+}
+
+// Count returns the current counter.
+func (l *Latch) Count() int {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.n
+}
+
+// Wait blocks until the counter is zero or ctx is done, in which case it returns ctx.Err().
+func (l *Latch) Wait(ctx context.Context) error {
+	l.mx.Lock()
+	ch := l.ch
+	l.mx.Unlock()
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,27 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLatch(t *testing.T) {
+	var zero Latch
+	require(t, nil == zero.Wait(context.Background()))
+
+	l := NewLatch(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require(t, context.DeadlineExceeded == l.Wait(ctx))
+
+	go l.Done()
+	go l.Done()
+	require(t, nil == l.Wait(context.Background()))
+	require(t, 0 == l.Count())
+
+	l.Add(1) // reuse
+	require(t, context.DeadlineExceeded == l.Wait(ctx))
+	l.Done()
+	require(t, nil == l.Wait(context.Background()))
+}
@@ -0,0 +1,110 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// A Lifecycle coordinates graceful shutdown: subsystems register stop hooks with OnStop,
+// and Stop runs them in reverse order of registration, so that dependents stop before their dependencies.
+// Background goroutines observe the shutdown through Closed.
+//
+// A zero Lifecycle is ready to use. A Lifecycle is safe for use by multiple goroutines simultaneously.
+type Lifecycle struct {
+	mx      sync.Mutex
+	hooks   []func(ctx context.Context) error
+	closed  chan struct{} // closed when Stop is called
+	stopped chan struct{} // closed when Stop has finished
+	err     error
+}
+
+// channels initializes the channels. l.mx must be held.
+func (l *Lifecycle) channels() {
+	if l.closed == nil {
+		l.closed, l.stopped = make(chan struct{}), make(chan struct{})
+	}
+}
+
+// OnStop registers fn to be called by Stop. fn should return promptly once its context is done.
+// If Stop has already been called, fn is called immediately with a background context.
+func (l *Lifecycle) OnStop(fn func(ctx context.Context) error) {
+	l.mx.Lock()
+	l.channels()
+	select {
+	case <-l.closed:
+		l.mx.Unlock()
+		_ = fn(context.Background())
+		return
+	default:
+	}
+	l.hooks = append(l.hooks, fn)
+	l.mx.Unlock()
+}
+
+// Closed returns a channel that is closed when Stop is called.
+func (l *Lifecycle) Closed() <-chan struct{} {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.channels()
+	return l.closed
+}
+
+// IsClosed reports whether Stop has been called.
+func (l *Lifecycle) IsClosed() bool {
+	select {
+	case <-l.Closed():
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop closes the Closed channel and calls the hooks in reverse order of registration,
+// passing ctx to each of them, and returns their errors joined.
+//
+// If ctx is done before all hooks have returned, Stop does not call the remaining hooks
+// and returns ctx.Err() joined with the errors so far; a running hook is left to finish in the background.
+// Subsequent calls wait for the first one to finish (or ctx to be done) and return the same error.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mx.Lock()
+	l.channels()
+	select {
+	case <-l.closed:
+		l.mx.Unlock()
+		select {
+		case <-l.stopped:
+			return l.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	default:
+	}
+	close(l.closed)
+	hooks := l.hooks
+	l.hooks = nil
+	l.mx.Unlock()
+
+	var errs []error
+run:
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		done := make(chan error, 1)
+		go func(fn func(context.Context) error) { done <- fn(ctx) }(hooks[i])
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+			break run
+		}
+	}
+	l.err = errors.Join(errs...)
+	close(l.stopped)
+	return l.err
+}
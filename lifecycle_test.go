@@ -0,0 +1,51 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLifecycle(t *testing.T) {
+	var l Lifecycle
+	var order []int
+	errHook := errors.New("hook")
+	for i := 0; i < 3; i++ {
+		l.OnStop(func(ctx context.Context) error {
+			order = append(order, i)
+			if i == 1 {
+				return errHook
+			}
+			return nil
+		})
+	}
+	require(t, !l.IsClosed())
+
+	done := make(chan struct{})
+	go func() {
+		<-l.Closed()
+		close(done)
+	}()
+	err := l.Stop(context.Background())
+	<-done
+	require(t, errors.Is(err, errHook) && slices.Equal(order, []int{2, 1, 0}) && l.IsClosed())
+	require(t, l.Stop(context.Background()) == err)
+
+	called := false
+	l.OnStop(func(context.Context) error { called = true; return nil })
+	require(t, called)
+}
+
+func TestLifecycle_deadline(t *testing.T) {
+	var l Lifecycle
+	first := false
+	l.OnStop(func(context.Context) error { first = true; return nil })
+	l.OnStop(func(ctx context.Context) error { time.Sleep(time.Second); return nil }) // ignores ctx
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := l.Stop(ctx)
+	require(t, errors.Is(err, context.DeadlineExceeded) && !first)
+}
@@ -2,61 +2,404 @@ package xsync
 
 import (
 	"bytes"
-	"encoding/gob"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"maps"
-	"math/rand"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 // A Map is a set of temporary objects that may be individually set, get and deleted.
 //
 // A Map is safe for use by multiple goroutines simultaneously.
+//
+// The zero Map is empty and ready to use; it allocates lazily on the first write
+// and behaves exactly like an empty NewMapPtr in every method, including marshaling.
+// Use Init to configure a Map declared as a value with options.
 type Map[K comparable, T any] struct {
-	mx   sync.RWMutex
-	ver  uint64
-	vals map[K]T
+	ver   uint64 // updated atomically under mx, so it can be read without locking
+	size  int64  // len(vals), updated like ver
+	mx    sync.RWMutex
+	vals  map[K]T
+	calls SingleFlight[K, T]
+	opts  options
+	index *keyIndex[K]   // built on first Random call
+	alias *aliasTable[K] // built by RandomByValue
+	wal   *walWriter[K, T]
+	evict evictor[K]       // set if the map is bounded by WithMaxEntries
+	out   *outputCache     // set by WithCachedOutput
+	keyFn func(K) K        // set by WithKeyFunc
+	clone func(T) T        // set by WithCloner
+	meta  map[K]*entryMeta // set by WithEntryStats
+
+	waiters map[K][]chan T
+	verWait chan struct{} // closed by the next commit; nil if nobody waits for a version
 }
 
 func NewMap[K comparable, T any](values map[K]T) Map[K, T] {
 	return Map[K, T]{
 		vals: maps.Clone(values),
+		size: int64(len(values)),
+	}
+}
+
+// NewMapPtr returns a pointer to a new Map with a copy of values, configured by opts.
+func NewMapPtr[K comparable, T any](values map[K]T, opts ...Option) *Map[K, T] {
+	m := &Map[K, T]{}
+	m.init(values, opts)
+	return m
+}
+
+// Init configures a Map declared as a value, e.g. a struct field, with opts like NewMapPtr does, and returns it.
+// The contents of the map are discarded. Init must be called before the map is shared between goroutines.
+//
+// Calling Init without options is never required: the zero Map is an empty map ready to use,
+// which allocates on the first write and behaves like a map created by NewMapPtr in every method,
+// including marshaling.
+func (m *Map[K, T]) Init(opts ...Option) *Map[K, T] {
+	discarded := m.Len() > 0
+	m.init(nil, opts)
+	if discarded {
+		m.commit()
+	}
+	return m
+}
+
+// init applies opts and replaces the contents with a copy of values.
+func (m *Map[K, T]) init(values map[K]T, opts []Option) {
+	m.initWith(values, newOptions(opts))
+}
+
+// initWith is like init but takes options already applied, e.g. those of another map.
+func (m *Map[K, T]) initWith(values map[K]T, o options) {
+	m.opts = o
+	m.out = newOutputCache(m.opts)
+	checkCallback[func(K, T)]("WithOnEvict", m.opts.onEvict)
+	checkCallback[func(K, T, RemovalReason)]("WithOnDelete", m.opts.onDelete)
+	checkCallback[func(K) K]("WithKeyFunc", m.opts.keyFunc)
+	checkCallback[func(T) T]("WithCloner", m.opts.cloner)
+	m.keyFn, _ = m.opts.keyFunc.(func(K) K)
+	m.clone, _ = m.opts.cloner.(func(T) T)
+
+	var vals map[K]T
+	if len(values) > 0 || m.opts.capacity > 0 {
+		vals = make(map[K]T, max(len(values), m.opts.capacity))
+		maps.Copy(vals, values)
+	}
+	m.evict, m.alias = nil, nil
+	if m.opts.maxEntries > 0 {
+		m.evict = newEvictor[K](m.opts.policy, m.opts.rand)
+	}
+	m.reset(vals)
+	m.publishSize()
+}
+
+// publishSize stores the size for Len and reports it to the metrics reporter. m.mx must be held.
+func (m *Map[K, T]) publishSize() {
+	atomic.StoreInt64(&m.size, int64(len(m.vals)))
+	if r := m.opts.metrics; r != nil {
+		r.ReportSize(len(m.vals))
 	}
 }
 
 func (m *Map[K, T]) Clear() {
 	m.mx.Lock()
 	defer m.mx.Unlock()
-	m.vals = nil
-	m.ver++
+	vals := m.vals
+	m.reset(nil)
+	if m.opts.onDelete != nil {
+		for k, v := range vals {
+			m.removed(k, v, RemovedByClear)
+		}
+	}
+	m.commit()
+}
+
+// Reserve presizes the map for n more entries, so that a bulk load does not grow the map incrementally.
+// It does nothing if n <= 0.
+func (m *Map[K, T]) Reserve(n int) {
+	if n <= 0 {
+		return
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	vals := make(map[K]T, len(m.vals)+n)
+	maps.Copy(vals, m.vals)
+	m.vals = vals
+}
+
+// Compact reallocates the map to release memory retained after massive deletions (Go maps never shrink).
+func (m *Map[K, T]) Compact() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if m.vals != nil {
+		// maps.Clone may keep the capacity of the source map
+		vals := make(map[K]T, len(m.vals))
+		maps.Copy(vals, m.vals)
+		m.vals = vals
+	}
 }
 
 func (m *Map[K, T]) Set(key K, value T) {
+	key = m.normKey(key)
+	lockMetered(&m.mx, m.opts.metrics)
+	defer m.mx.Unlock()
+	if r := m.opts.metrics; r != nil {
+		_, ok := m.vals[key]
+		r.ReportOp(OpSet, ok)
+	}
+	m.set(key, value)
+	m.commit()
+}
+
+// SetIfAbsent stores the value only if the key is absent and reports whether it was stored.
+func (m *Map[K, T]) SetIfAbsent(key K, value T) bool {
+	key = m.normKey(key)
 	m.mx.Lock()
 	defer m.mx.Unlock()
-	if m.vals == nil {
-		m.vals = map[K]T{}
+	if _, ok := m.vals[key]; ok {
+		return false
 	}
-	m.vals[key] = value
-	m.ver++
+	m.set(key, value)
+	m.commit()
+	return true
 }
 
-func (m *Map[K, T]) Increment(key K, val T) T {
+// SetIfPresent stores the value only if the key is present and reports whether it was stored.
+func (m *Map[K, T]) SetIfPresent(key K, value T) bool {
+	key = m.normKey(key)
 	m.mx.Lock()
 	defer m.mx.Unlock()
-	if m.vals == nil {
-		m.vals = map[K]T{}
+	if _, ok := m.vals[key]; !ok {
+		return false
 	}
-	if v, ok := m.vals[key]; ok {
+	m.set(key, value)
+	m.commit()
+	return true
+}
+
+// SetIfVersion stores the value only if the map version is ver, checking and updating under a single lock,
+// and returns the new version. If the version differs, it returns the current version and ErrVersionMismatch.
+func (m *Map[K, T]) SetIfVersion(key K, value T, ver uint64) (uint64, error) {
+	key = m.normKey(key)
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if cur := m.Version(); cur != ver {
+		return cur, fmt.Errorf("%w: %d, expected %d", ErrVersionMismatch, cur, ver)
+	}
+	m.set(key, value)
+	m.commit()
+	return m.Version(), nil
+}
+
+// DeleteIfVersion deletes the key only if the map version is ver, checking and updating under a single lock,
+// and returns the new version. If the version differs, it returns the current version and ErrVersionMismatch;
+// if the key is absent, it returns an error wrapping ErrKeyNotFound.
+func (m *Map[K, T]) DeleteIfVersion(key K, ver uint64) (uint64, error) {
+	key = m.normKey(key)
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	cur := m.Version()
+	if cur != ver {
+		return cur, fmt.Errorf("%w: %d, expected %d", ErrVersionMismatch, cur, ver)
+	}
+	if !m.remove(key, RemovedByDelete) {
+		return cur, fmt.Errorf("%w: %v", ErrKeyNotFound, key)
+	}
+	m.commit()
+	return m.Version(), nil
+}
+
+// TrySet stores the value like Set, but if the map is bounded by WithMaxEntries and full,
+// it returns ErrCapacityExceeded for a new key instead of evicting an entry.
+func (m *Map[K, T]) TrySet(key K, value T) error {
+	key = m.normKey(key)
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if _, ok := m.vals[key]; !ok && m.evict != nil && len(m.vals) >= m.opts.maxEntries {
+		return fmt.Errorf("%w: %d entries", ErrCapacityExceeded, m.opts.maxEntries)
+	}
+	m.set(key, value)
+	m.commit()
+	return nil
+}
+
+func (m *Map[K, T]) Increment(key K, val T) T {
+	key = m.normKey(key)
+	lockMetered(&m.mx, m.opts.metrics)
+	defer m.mx.Unlock()
+	v, ok := m.vals[key]
+	if ok {
 		val = add(val, v).(T)
 	}
-	m.vals[key] = val
-	m.ver++
+	if r := m.opts.metrics; r != nil {
+		r.ReportOp(OpSet, ok)
+	}
+	m.set(key, val)
+	m.commit()
 	return val
 }
 
+// set stores the value and wakes up goroutines waiting for the key. m.mx must be held.
+func (m *Map[K, T]) set(key K, val T) {
+	if m.vals == nil {
+		m.vals = make(map[K]T, m.opts.capacity)
+	}
+	if m.evict != nil {
+		if _, ok := m.vals[key]; ok {
+			m.evict.touch(key)
+		} else {
+			m.evictOverflow(1)
+			m.evict.add(key)
+		}
+	}
+	m.vals[key] = val
+	if m.index != nil {
+		m.index.add(key)
+	}
+	if m.meta != nil {
+		m.updated(key)
+	}
+	if m.wal != nil {
+		m.wal.write(walSet, key, val, nil)
+	}
+	if ww, ok := m.waiters[key]; ok {
+		delete(m.waiters, key)
+		for _, ch := range ww {
+			ch <- val
+		}
+	}
+}
+
+// del deletes the key. m.mx must be held.
+func (m *Map[K, T]) del(key K) {
+	delete(m.vals, key)
+	delete(m.meta, key)
+	if m.index != nil {
+		m.index.remove(key)
+	}
+	if m.evict != nil {
+		m.evict.remove(key)
+	}
+	if m.wal != nil {
+		var zero T
+		m.wal.write(walDelete, key, zero, nil)
+	}
+}
+
+// reset replaces the map contents with vals. m.mx must be held.
+func (m *Map[K, T]) reset(vals map[K]T) {
+	if m.keyFn != nil && vals != nil {
+		norm := make(map[K]T, len(vals))
+		for k, v := range vals {
+			norm[m.keyFn(k)] = v
+		}
+		vals = norm
+	}
+	m.vals, m.index = vals, nil
+	m.resetMeta()
+	if m.evict != nil {
+		m.evict.reset()
+		if m.opts.rand != nil {
+			keys := mapKeys(vals)
+			sortKeys(keys)
+			for _, k := range keys {
+				m.evict.add(k)
+			}
+		} else {
+			for k := range vals {
+				m.evict.add(k)
+			}
+		}
+		m.evictOverflow(0)
+	}
+	if m.wal != nil {
+		m.wal.writeSnapshot(m.vals)
+	}
+	m.notifyWaiters()
+}
+
+// replace replaces the map contents with vals.
+func (m *Map[K, T]) replace(vals map[K]T) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.reset(vals)
+	m.commit()
+}
+
+// notifyWaiters wakes up goroutines waiting for keys that are present now. m.mx must be held.
+func (m *Map[K, T]) notifyWaiters() {
+	for key, ww := range m.waiters {
+		if val, ok := m.vals[key]; ok {
+			delete(m.waiters, key)
+			for _, ch := range ww {
+				ch <- val
+			}
+		}
+	}
+}
+
+// WaitFor returns the value for the key, blocking until some goroutine sets the key or ctx is done.
+func (m *Map[K, T]) WaitFor(ctx context.Context, key K) (T, error) {
+	val, ch := m.addWaiter(key)
+	if ch == nil {
+		return m.cloneVal(val), nil
+	}
+	select {
+	case val := <-ch:
+		return m.cloneVal(val), nil
+	case <-ctx.Done():
+	}
+	m.removeWaiter(key, ch)
+
+	select {
+	case val := <-ch: // the key was set concurrently with cancellation
+		return m.cloneVal(val), nil
+	default:
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// addWaiter returns the value if the key is present, otherwise a channel receiving it when the key is set.
+func (m *Map[K, T]) addWaiter(key K) (T, chan T) {
+	key = m.normKey(key)
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if val, ok := m.vals[key]; ok {
+		return val, nil
+	}
+	ch := make(chan T, 1)
+	if m.waiters == nil {
+		m.waiters = map[K][]chan T{}
+	}
+	m.waiters[key] = append(m.waiters[key], ch)
+	return *new(T), ch
+}
+
+// removeWaiter unregisters a channel returned by addWaiter.
+func (m *Map[K, T]) removeWaiter(key K, ch chan T) {
+	key = m.normKey(key)
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	ww := m.waiters[key]
+	for i, c := range ww {
+		if c == ch {
+			ww = append(ww[:i], ww[i+1:]...)
+			break
+		}
+	}
+	if len(ww) == 0 {
+		delete(m.waiters, key)
+	} else {
+		m.waiters[key] = ww
+	}
+}
+
 func add(a, b any) (s any) {
 	switch a.(type) {
 	case int:
@@ -88,60 +431,204 @@ func add(a, b any) (s any) {
 }
 
 func (m *Map[K, T]) Delete(key K) {
-	m.mx.Lock()
+	key = m.normKey(key)
+	lockMetered(&m.mx, m.opts.metrics)
 	defer m.mx.Unlock()
 
+	if r := m.opts.metrics; r != nil {
+		_, ok := m.vals[key]
+		r.ReportOp(OpDelete, ok)
+	}
 	if m.vals != nil {
-		delete(m.vals, key)
-		m.ver++
+		m.remove(key, RemovedByDelete)
+		m.commit()
 	}
 }
 
-func (m *Map[K, T]) Get(key K) (_ T) {
-	m.mx.RLock()
-	defer m.mx.RUnlock()
+// Merge folds entries of other into m atomically with a single version bump.
+// On key collisions the stored value is resolve(key, old, new); if resolve is nil, the new value wins.
+func (m *Map[K, T]) Merge(other *Map[K, T], resolve func(key K, old, new T) T) {
+	m.MergeMap(other.KeyValues(), resolve)
+}
 
-	if m.vals != nil {
-		return m.vals[key]
+// MergeMap is like Merge but takes a plain map.
+func (m *Map[K, T]) MergeMap(values map[K]T, resolve func(key K, old, new T) T) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	for k, v := range values {
+		k = m.normKey(k)
+		if old, ok := m.vals[k]; ok && resolve != nil {
+			v = resolve(k, old, v)
+		}
+		m.set(k, v)
+	}
+	m.commit()
+}
+
+// DeleteFunc deletes all entries for which fn returns true under a single lock
+// and returns the number of deleted entries.
+func (m *Map[K, T]) DeleteFunc(fn func(key K, value T) bool) (n int) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	for k, v := range m.vals {
+		if fn(k, v) {
+			m.remove(k, RemovedByDelete)
+			n++
+		}
+	}
+	if n > 0 {
+		m.commit()
 	}
 	return
 }
 
+func (m *Map[K, T]) Get(key K) (_ T) {
+	v, _ := m.Lookup(key)
+	return v
+}
+
+// GetOrSet returns the value for the key, or calls fn and stores its result if the key is absent.
+// Concurrent calls for the same absent key share a single call of fn.
 func (m *Map[K, T]) GetOrSet(key K, fn func() T) (res T) {
+	key = m.normKey(key)
 	var ok bool
 	m.mx.RLock()
 	if m.vals != nil {
 		res, ok = m.vals[key]
 	}
+	if ok && m.meta != nil {
+		m.accessed(key)
+	}
 	m.mx.RUnlock()
 	if !ok {
-		res = fn()
-		m.Set(key, res)
+		res, _, _ = m.calls.Do(key, func() (T, error) {
+			if v, ok := m.Lookup(key); ok {
+				return v, nil
+			}
+			v := fn()
+			m.Set(key, v)
+			return v, nil
+		})
 	}
-	return
+	return m.cloneVal(res)
 }
 
-func (m *Map[K, T]) Exists(key K) bool {
-	m.mx.RLock()
+// ComputeIfAbsent returns the value for the key, or stores and returns fn(key) if the key is absent.
+// fn is called under the write lock, so it must not call methods of the map.
+func (m *Map[K, T]) ComputeIfAbsent(key K, fn func(key K) T) T {
+	key = m.normKey(key)
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if v, ok := m.vals[key]; ok {
+		return m.cloneVal(v)
+	}
+	v := fn(key)
+	m.set(key, v)
+	m.commit()
+	return m.cloneVal(v)
+}
+
+// ComputeIfPresent replaces the value of a present key with the result of fn.
+// If fn returns false, the key is deleted instead.
+// It returns the new value and whether the key is present after the call.
+// fn is called under the write lock, so it must not call methods of the map.
+func (m *Map[K, T]) ComputeIfPresent(key K, fn func(key K, value T) (T, bool)) (_ T, _ bool) {
+	key = m.normKey(key)
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	old, ok := m.vals[key]
+	if !ok {
+		return
+	}
+	v, keep := fn(key, old)
+	if !keep {
+		m.remove(key, RemovedByDelete)
+		m.commit()
+		return
+	}
+	m.set(key, v)
+	m.commit()
+	return m.cloneVal(v), true
+}
+
+// Lookup returns the value for the key and whether the key is present.
+func (m *Map[K, T]) Lookup(key K) (v T, ok bool) {
+	key = m.normKey(key)
+	rlockMetered(&m.mx, m.opts.metrics)
 	defer m.mx.RUnlock()
+	v, ok = m.vals[key]
+	if ok && m.evict != nil {
+		m.evict.touch(key)
+	}
+	if ok && m.meta != nil {
+		m.accessed(key)
+	}
+	if r := m.opts.metrics; r != nil {
+		r.ReportOp(OpGet, ok)
+	}
+	if ok {
+		v = m.cloneVal(v)
+	}
+	return
+}
 
-	if m.vals == nil {
-		return false
+// Fetch returns the value for the key, or an error wrapping ErrKeyNotFound if the key is absent.
+func (m *Map[K, T]) Fetch(key K) (T, error) {
+	v, ok := m.Lookup(key)
+	if !ok {
+		return v, fmt.Errorf("%w: %v", ErrKeyNotFound, key)
 	}
-	_, ok := m.vals[key]
+	return v, nil
+}
+
+func (m *Map[K, T]) Exists(key K) bool {
+	_, ok := m.Lookup(key)
 	return ok
 }
 
 func (m *Map[K, T]) Len() int {
-	m.mx.RLock()
-	defer m.mx.RUnlock()
-	return len(m.vals)
+	return int(atomic.LoadInt64(&m.size))
 }
 
 func (m *Map[K, T]) Version() uint64 {
-	m.mx.RLock()
-	defer m.mx.RUnlock()
-	return m.ver
+	return atomic.LoadUint64(&m.ver)
+}
+
+// WaitVersion blocks until the version of the map reaches at least minVersion or ctx is done,
+// in which case it returns ctx.Err().
+func (m *Map[K, T]) WaitVersion(ctx context.Context, minVersion uint64) error {
+	for m.Version() < minVersion {
+		m.mx.Lock()
+		if m.Version() >= minVersion {
+			m.mx.Unlock()
+			break
+		}
+		if m.verWait == nil {
+			m.verWait = make(chan struct{})
+		}
+		ch := m.verWait
+		m.mx.Unlock()
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// commit records a mutation: increments the version and publishes the size. m.mx must be held.
+func (m *Map[K, T]) commit() {
+	atomic.AddUint64(&m.ver, 1)
+	m.publishSize()
+	m.compactLog()
+	if m.verWait != nil {
+		close(m.verWait)
+		m.verWait = nil
+	}
 }
 
 func (m *Map[K, T]) KeyValues() map[K]T {
@@ -151,12 +638,54 @@ func (m *Map[K, T]) KeyValues() map[K]T {
 	res := map[K]T{}
 	if m.vals != nil {
 		for k, v := range m.vals {
-			res[k] = v
+			res[k] = m.cloneVal(v)
 		}
 	}
 	return res
 }
 
+// Clone returns an independent copy of the map with the same options.
+// Values are copied with the cloner set by WithCloner, if any.
+func (m *Map[K, T]) Clone() *Map[K, T] {
+	return m.derive(m.KeyValues())
+}
+
+// derive returns a new map with the options of m holding vals.
+func (m *Map[K, T]) derive(vals map[K]T) *Map[K, T] {
+	c := &Map[K, T]{}
+	c.initWith(vals, m.opts)
+	return c
+}
+
+// Equal reports whether m and other contain the same entries.
+// Values are compared with eq if given, otherwise with ==, which panics for non-comparable values.
+func (m *Map[K, T]) Equal(other *Map[K, T], eq ...func(a, b T) bool) bool {
+	if m == other {
+		return true
+	}
+	vals := other.KeyValues()
+
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if len(m.vals) != len(vals) {
+		return false
+	}
+	for k, v := range m.vals {
+		w, ok := vals[k]
+		if !ok {
+			return false
+		}
+		if len(eq) > 0 {
+			if !eq[0](v, w) {
+				return false
+			}
+		} else if any(v) != any(w) {
+			return false
+		}
+	}
+	return true
+}
+
 func (m *Map[K, T]) Keys() []K {
 	m.mx.RLock()
 	defer m.mx.RUnlock()
@@ -164,6 +693,60 @@ func (m *Map[K, T]) Keys() []K {
 	return mapKeys(m.vals)
 }
 
+// KeysWhere returns keys of entries for which fn returns true.
+// fn is called under the read lock, so it must not modify the map.
+func (m *Map[K, T]) KeysWhere(fn func(key K, value T) bool) []K {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	var keys []K
+	for k, v := range m.vals {
+		if fn(k, v) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Max returns the entry with the greatest value according to less; ok is false if the map is empty.
+func (m *Map[K, T]) Max(less func(a, b T) bool) (key K, value T, ok bool) {
+	return m.Min(func(a, b T) bool { return less(b, a) })
+}
+
+// Min returns the entry with the least value according to less; ok is false if the map is empty.
+func (m *Map[K, T]) Min(less func(a, b T) bool) (key K, value T, ok bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	for k, v := range m.vals {
+		if !ok || less(v, value) {
+			key, value, ok = k, v, true
+		}
+	}
+	return key, m.cloneVal(value), ok
+}
+
+// CountWhere returns the number of entries for which fn returns true.
+// fn is called under the read lock, so it must not modify the map.
+func (m *Map[K, T]) CountWhere(fn func(key K, value T) bool) (n int) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	for k, v := range m.vals {
+		if fn(k, v) {
+			n++
+		}
+	}
+	return
+}
+
+// ValuesSortedBy returns values of the map ordered by less.
+func (m *Map[K, T]) ValuesSortedBy(less func(a, b T) bool) []T {
+	vv := m.Values()
+	sort.Slice(vv, func(i, j int) bool { return less(vv[i], vv[j]) })
+	return vv
+}
+
 func (m *Map[K, T]) Values() []T {
 	m.mx.RLock()
 	defer m.mx.RUnlock()
@@ -171,38 +754,119 @@ func (m *Map[K, T]) Values() []T {
 	vv := make([]T, 0, len(m.vals))
 	if m.vals != nil {
 		for _, v := range m.vals {
-			vv = append(vv, v)
+			vv = append(vv, m.cloneVal(v))
 		}
 	}
 	return vv
 }
 
-func (m *Map[K, T]) String() string {
+// AppendKeys appends the keys of the map to dst and returns the extended slice.
+// Reusing dst between calls avoids allocating a new slice for every snapshot.
+func (m *Map[K, T]) AppendKeys(dst []K) []K {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	dst = slices.Grow(dst, len(m.vals))
+	for k := range m.vals {
+		dst = append(dst, k)
+	}
+	return dst
+}
+
+// AppendValues appends the values of the map to dst and returns the extended slice.
+func (m *Map[K, T]) AppendValues(dst []T) []T {
 	m.mx.RLock()
 	defer m.mx.RUnlock()
-	return encString(m.vals)
+
+	dst = slices.Grow(dst, len(m.vals))
+	for _, v := range m.vals {
+		dst = append(dst, m.cloneVal(v))
+	}
+	return dst
+}
+
+// String returns the map as JSON with sorted keys.
+func (m *Map[K, T]) String() string {
+	if m.out != nil {
+		b, _ := cached(&m.out.text, m.Version, m.MarshalJSONSorted)
+		return string(b)
+	}
+	b, _ := m.MarshalJSONSorted()
+	return string(b)
 }
 
 func (m *Map[K, T]) Pop() (key K, value T) {
 	m.mx.Lock()
 	defer m.mx.Unlock()
 
-	if m.vals != nil {
-		for key, value = range m.vals {
-			delete(m.vals, key)
-			m.ver++
-			return
-		}
+	key, value, _ = m.tryPop()
+	return
+}
+
+// TryPop removes and returns an arbitrary entry; ok is false if the map is empty.
+func (m *Map[K, T]) TryPop() (key K, value T, ok bool) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.tryPop()
+}
+
+// tryPop removes an entry: a random one if a source is configured by WithRandSource,
+// otherwise an arbitrary one. m.mx must be held.
+func (m *Map[K, T]) tryPop() (key K, value T, ok bool) {
+	if m.opts.rand != nil && len(m.vals) > 0 {
+		key = m.buildIndex().random()
+		value = m.vals[key]
+		m.remove(key, RemovedByPop)
+		m.commit()
+		return key, m.cloneVal(value), true
+	}
+	for key, value = range m.vals {
+		m.remove(key, RemovedByPop)
+		m.commit()
+		return key, m.cloneVal(value), true
 	}
 	return
 }
 
+// PopN removes and returns up to n arbitrary entries.
+func (m *Map[K, T]) PopN(n int) map[K]T {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	res := make(map[K]T, min(max(n, 0), len(m.vals)))
+	for k, v := range m.vals {
+		if len(res) >= n {
+			break
+		}
+		res[k] = m.cloneVal(v)
+		m.remove(k, RemovedByPop)
+	}
+	if len(res) > 0 {
+		m.commit()
+	}
+	return res
+}
+
 func (m *Map[K, T]) PopAll() (values map[K]T) {
 	m.mx.Lock()
 	defer m.mx.Unlock()
 
-	values, m.vals = m.vals, nil
-	m.ver++
+	values = m.vals
+	if values == nil {
+		values = map[K]T{}
+	}
+	m.reset(nil)
+	if m.opts.onDelete != nil {
+		for k, v := range values {
+			m.removed(k, v, RemovedByPop)
+		}
+	}
+	m.commit()
+	if m.clone != nil {
+		for k, v := range values {
+			values[k] = m.clone(v)
+		}
+	}
 	return
 }
 
@@ -216,24 +880,55 @@ func (m *Map[K, T]) RandomKey() K {
 	return k
 }
 
+// Random returns a random entry in O(1).
+// The first call builds an index of keys, which is maintained by subsequent mutations.
 func (m *Map[K, T]) Random() (key K, value T) {
 	m.mx.RLock()
-	defer m.mx.RUnlock()
+	if m.index != nil {
+		defer m.mx.RUnlock()
+		key = m.index.random()
+		return key, m.cloneVal(m.vals[key])
+	}
+	m.mx.RUnlock()
 
-	if cnt := len(m.vals); cnt > 0 {
-		// todo: optimize it!  (add keys slice)
-		n := rand.Intn(cnt)
-		for k := range m.vals {
-			if n == 0 {
-				return k, m.vals[k]
-			}
-			n--
-		}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if len(m.vals) > 0 {
+		key = m.buildIndex().random()
+		value = m.cloneVal(m.vals[key])
 	}
 	return
 }
 
+// RandomN returns up to n distinct random entries.
+func (m *Map[K, T]) RandomN(n int) map[K]T {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	keys := m.buildIndex().sample(n)
+	res := make(map[K]T, len(keys))
+	for _, k := range keys {
+		res[k] = m.cloneVal(m.vals[k])
+	}
+	return res
+}
+
+func (m *Map[K, T]) buildIndex() *keyIndex[K] {
+	if m.index == nil {
+		m.index = newKeyIndex(m.vals, m.opts.rand)
+	}
+	return m.index
+}
+
 func (m *Map[K, T]) MarshalJSON() ([]byte, error) {
+	if m.out != nil {
+		b, err := cached(&m.out.json, m.Version, m.marshalJSON)
+		return bytes.Clone(b), err
+	}
+	return m.marshalJSON()
+}
+
+func (m *Map[K, T]) marshalJSON() ([]byte, error) {
 	return json.Marshal(m.KeyValues())
 }
 
@@ -242,33 +937,78 @@ func (m *Map[K, T]) UnmarshalJSON(data []byte) error {
 	defer m.mx.Unlock()
 
 	err := json.NewDecoder(bytes.NewReader(data)).Decode(&m.vals)
-	m.ver++
+	m.reset(m.vals)
+	m.commit()
 	return err
 }
 
 func (m *Map[K, T]) MarshalBinary() ([]byte, error) {
-	w := bytes.NewBuffer(nil)
-	err := m.BinaryEncode(w)
-	return w.Bytes(), err
+	buf := getBuffer()
+	defer putBuffer(buf)
+	err := m.BinaryEncode(buf)
+	return bytes.Clone(buf.Bytes()), err
 }
 
 func (m *Map[K, T]) UnmarshalBinary(data []byte) error {
 	return m.BinaryDecode(bytes.NewReader(data))
 }
 
+// GobEncode implements gob.GobEncoder, so that a Map nested in a gob-encoded value keeps its contents.
+func (m *Map[K, T]) GobEncode() ([]byte, error) {
+	return m.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder.
+func (m *Map[K, T]) GobDecode(data []byte) error {
+	return m.UnmarshalBinary(data)
+}
+
+// BinaryEncode writes a versioned gob snapshot of the map to w.
 func (m *Map[K, T]) BinaryEncode(w io.Writer) error {
+	return writeEnvelope(w, func(w io.Writer) error {
+		return m.BinaryEncodeWith(w, GobCodec)
+	})
+}
+
+// BinaryDecode reads a snapshot written by BinaryEncode, including snapshots of older versions.
+func (m *Map[K, T]) BinaryDecode(r io.Reader) error {
+	r, err := readEnvelope(r)
+	if err != nil {
+		return err
+	}
+	return m.BinaryDecodeWith(r, GobCodec)
+}
+
+// WriteTo implements io.WriterTo. It writes the same snapshot as BinaryEncode and returns its size.
+func (m *Map[K, T]) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := m.BinaryEncode(cw)
+	return cw.n, err
+}
+
+// ReadFrom implements io.ReaderFrom. It reads a snapshot like BinaryDecode and returns the number of bytes read.
+func (m *Map[K, T]) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	err := m.BinaryDecode(cr)
+	return cr.n, err
+}
+
+// BinaryEncodeWith encodes the map to w using codec.
+func (m *Map[K, T]) BinaryEncodeWith(w io.Writer, codec Codec) error {
 	m.mx.RLock()
 	defer m.mx.RUnlock()
 
-	return gob.NewEncoder(w).Encode(m.vals)
+	return codec.Encode(w, m.vals)
 }
 
-func (m *Map[K, T]) BinaryDecode(r io.Reader) error {
+// BinaryDecodeWith decodes the map from r using codec.
+func (m *Map[K, T]) BinaryDecodeWith(r io.Reader, codec Codec) error {
 	m.mx.Lock()
 	defer m.mx.Unlock()
 
-	err := gob.NewDecoder(r).Decode(&m.vals)
-	m.ver++
+	err := codec.Decode(r, &m.vals)
+	m.reset(m.vals)
+	m.commit()
 	return err
 }
 
@@ -286,9 +1026,6 @@ func encString(v any) string {
 }
 
 func mapKeys[K comparable, T any](mm map[K]T) []K {
-	if mm == nil {
-		return nil
-	}
 	vv := make([]K, 0, len(mm))
 	for k := range mm {
 		vv = append(vv, k)
@@ -0,0 +1,57 @@
+package xsync
+
+import (
+	"cmp"
+	"slices"
+)
+
+// MapValues returns a new Map with values of m transformed by fn.
+// fn is called over a snapshot of m, so it may safely access m.
+func MapValues[K comparable, T, U any](m *Map[K, T], fn func(key K, value T) U) *Map[K, U] {
+	vals := m.KeyValues()
+	res := make(map[K]U, len(vals))
+	for k, v := range vals {
+		res[k] = fn(k, v)
+	}
+	return &Map[K, U]{vals: res, size: int64(len(res))}
+}
+
+// Reduce folds entries of m into a single value, starting with init.
+// fn is called over a snapshot of m in unspecified order.
+func Reduce[K comparable, T, A any](m *Map[K, T], fn func(acc A, key K, value T) A, init A) A {
+	acc := init
+	for k, v := range m.KeyValues() {
+		acc = fn(acc, k, v)
+	}
+	return acc
+}
+
+// FilterClone returns a new Map with the options of m containing entries of m for which fn returns true.
+// fn is called over a snapshot of m.
+func (m *Map[K, T]) FilterClone(fn func(key K, value T) bool) *Map[K, T] {
+	vals := m.KeyValues()
+	for k, v := range vals {
+		if !fn(k, v) {
+			delete(vals, k)
+		}
+	}
+	return m.derive(vals)
+}
+
+// SortedKeys returns keys of m in ascending order.
+func SortedKeys[K cmp.Ordered, T any](m *Map[K, T]) []K {
+	keys := m.Keys()
+	slices.Sort(keys)
+	return keys
+}
+
+// SumBy returns the sum of fn over values of m, computed under a single read lock.
+func SumBy[K comparable, T any, N Number](m *Map[K, T], fn func(value T) N) (sum N) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	for _, v := range m.vals {
+		sum += fn(v)
+	}
+	return
+}
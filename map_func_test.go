@@ -0,0 +1,61 @@
+package xsync
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestMapValues(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1, "b": 2})
+
+	res := MapValues(&m, func(k string, v int) string { return k + strconv.Itoa(v) })
+
+	require(t, 2 == res.Len())
+	require(t, "b2" == res.Get("b"))
+}
+
+func TestReduce(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1, "b": 2, "c": 3})
+
+	sum := Reduce(&m, func(acc float64, k string, v int) float64 { return acc + float64(v) }, 0.5)
+
+	require(t, 6.5 == sum)
+}
+
+func TestMap_FilterClone(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1, "b": 2, "c": 3})
+
+	res := m.FilterClone(func(k string, v int) bool { return v != 2 })
+
+	require(t, 2 == res.Len())
+	require(t, !res.Exists("b"))
+	require(t, 3 == m.Len())
+}
+
+func TestSortedKeys(t *testing.T) {
+	m := NewMap(map[string]int{"b": 1, "c": 2, "a": 3})
+
+	require(t, slices.Equal([]string{"a", "b", "c"}, SortedKeys(&m)))
+	require(t, slices.Equal([]int{1, 2, 3}, m.ValuesSortedBy(func(a, b int) bool { return a < b })))
+
+	keys := m.KeysWhere(func(k string, v int) bool { return v > 1 })
+	slices.Sort(keys)
+	require(t, slices.Equal([]string{"a", "c"}, keys))
+}
+
+func TestMap_Aggregates(t *testing.T) {
+	m := NewMap(map[string]int{"a": 3, "b": 1, "c": 2})
+	less := func(a, b int) bool { return a < b }
+
+	k, v, ok := m.Max(less)
+	require(t, ok && k == "a" && v == 3)
+	k, v, ok = m.Min(less)
+	require(t, ok && k == "b" && v == 1)
+	require(t, 6.0 == SumBy(&m, func(v int) float64 { return float64(v) }))
+	require(t, 2 == m.CountWhere(func(k string, v int) bool { return v >= 2 }))
+
+	var empty Map[string, int]
+	_, _, ok = empty.Max(less)
+	require(t, !ok)
+}
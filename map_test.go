@@ -1,6 +1,12 @@
 package xsync
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
 
 func TestMap_init(t *testing.T) {
 	var m Map[int, string]
@@ -9,6 +15,31 @@ func TestMap_init(t *testing.T) {
 	require(t, 0 == m.Len())
 }
 
+func TestNewMapPtr(t *testing.T) {
+	values := map[string]int{"a": 1}
+
+	m := NewMapPtr(values, WithCapacity(100))
+	m.Set("b", 2)
+
+	require(t, 2 == m.Len())
+	require(t, 1 == len(values))
+}
+
+func TestMap_Compact(t *testing.T) {
+	var m Map[int, int]
+	m.Reserve(1000)
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i)
+	}
+	m.DeleteFunc(func(k, v int) bool { return k > 0 })
+
+	m.Compact()
+	m.Reserve(-1)
+
+	require(t, 1 == m.Len())
+	require(t, 0 == m.Get(0))
+}
+
 func TestMap_Set(t *testing.T) {
 	var m Map[string, int]
 
@@ -68,6 +99,102 @@ func TestMap_Delete(t *testing.T) {
 	require(t, 2 == m.Len())
 }
 
+func TestMap_DeleteFunc(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	ver := m.Version()
+
+	n := m.DeleteFunc(func(k string, v int) bool { return v > 1 })
+
+	require(t, 2 == n)
+	require(t, 1 == m.Len())
+	require(t, m.Exists("a"))
+	require(t, ver+1 == m.Version())
+}
+
+func TestMap_Merge(t *testing.T) {
+	m1 := NewMap(map[string]int{"a": 1, "b": 2})
+	m2 := NewMap(map[string]int{"b": 20, "c": 30})
+
+	m1.Merge(&m2, func(k string, old, new int) int { return old + new })
+
+	require(t, 3 == m1.Len())
+	require(t, 22 == m1.Get("b"))
+	require(t, 30 == m1.Get("c"))
+	require(t, 1 == m1.Version())
+}
+
+func TestMap_Clone(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1})
+
+	c := m.Clone()
+	c.Set("b", 2)
+
+	require(t, 1 == m.Len())
+	require(t, !m.Equal(c))
+	m.Set("b", 2)
+	require(t, m.Equal(c))
+}
+
+func TestMap_Equal(t *testing.T) {
+	m1 := NewMap(map[int][]int{1: {1, 2}})
+	m2 := NewMap(map[int][]int{1: {1, 2}})
+
+	require(t, m1.Equal(&m2, slices.Equal[[]int]))
+}
+
+func TestMap_Random(t *testing.T) {
+	var m Map[int, int]
+	k, v := m.Random()
+	require(t, 0 == k && 0 == v)
+
+	for i := 0; i < 10; i++ {
+		m.Set(i, i*10)
+	}
+	k, v = m.Random()
+	require(t, k*10 == v)
+
+	m.DeleteFunc(func(k, v int) bool { return k < 9 })
+	for i := 0; i < 10; i++ {
+		k, v = m.Random()
+		require(t, 9 == k && 90 == v)
+	}
+}
+
+func TestMap_RandomN(t *testing.T) {
+	var m Map[int, int]
+	for i := 0; i < 100; i++ {
+		m.Set(i, i)
+	}
+
+	res := m.RandomN(10)
+
+	require(t, 10 == len(res))
+	for k, v := range res {
+		require(t, k == v)
+	}
+}
+
+func TestMap_TryPop(t *testing.T) {
+	m := NewMap(map[string]int{"": 0})
+
+	k, v, ok := m.TryPop()
+	require(t, ok && "" == k && 0 == v)
+	_, _, ok = m.TryPop()
+	require(t, !ok)
+}
+
+func TestMap_PopN(t *testing.T) {
+	m := NewMap(map[int]int{1: 1, 2: 2, 3: 3})
+
+	res := m.PopN(2)
+
+	require(t, 2 == len(res))
+	require(t, 1 == m.Len())
+	for k, v := range res {
+		require(t, k == v && !m.Exists(k))
+	}
+}
+
 func TestMap_Values(t *testing.T) {
 	var m Map[string, int]
 	m.Set("abc", 123)
@@ -101,8 +228,120 @@ func TestMap_MarshalJSON(t *testing.T) {
 	require(t, `{"abc":123,"def":456}` == string(data))
 }
 
+func TestMap_WaitFor(t *testing.T) {
+	var m Map[string, int]
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Set("abc", 123)
+	}()
+
+	v, err := m.WaitFor(context.Background(), "abc")
+
+	require(t, err == nil)
+	require(t, v == 123)
+}
+
+func TestMap_WaitFor_cancel(t *testing.T) {
+	var m Map[string, int]
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := m.WaitFor(ctx, "abc")
+
+	require(t, err == context.DeadlineExceeded)
+	require(t, 0 == len(m.waiters))
+}
+
+func TestMap_SetIfVersion(t *testing.T) {
+	var m Map[string, int]
+	ver, err := m.SetIfVersion("a", 1, 0)
+	require(t, err == nil && ver == 1 && m.Get("a") == 1)
+	ver, err = m.SetIfVersion("a", 2, 0)
+	require(t, errors.Is(err, ErrVersionMismatch) && ver == 1 && m.Get("a") == 1)
+
+	_, err = m.DeleteIfVersion("b", 1)
+	require(t, errors.Is(err, ErrKeyNotFound) && m.Version() == 1)
+	ver, err = m.DeleteIfVersion("a", 1)
+	require(t, err == nil && ver == 2 && m.Len() == 0)
+}
+
+func TestMap_WaitVersion(t *testing.T) {
+	var m Map[string, int]
+	require(t, m.WaitVersion(context.Background(), 0) == nil)
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(time.Millisecond)
+			m.Set("a", i)
+		}
+	}()
+
+	require(t, m.WaitVersion(context.Background(), 3) == nil)
+	require(t, m.Version() >= 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require(t, m.WaitVersion(ctx, 10) == context.DeadlineExceeded)
+}
+
 func require(t *testing.T, ok bool) {
 	if !ok {
 		t.Fatal()
 	}
 }
+
+func TestMap_LenVersionLockFree(t *testing.T) {
+	m := NewMapPtr(map[int]int{1: 1})
+	require(t, m.Len() == 1 && m.Version() == 0)
+
+	m.mx.Lock()
+	done := make(chan bool)
+	go func() { done <- m.Len() == 1 && m.Version() == 0 }()
+	require(t, <-done) // does not block on the write lock
+	m.mx.Unlock()
+
+	m.Set(2, 2)
+	m.Delete(1)
+	m.Set(3, 3)
+	require(t, m.Len() == 2 && m.Version() == 3)
+	m.Clear()
+	require(t, m.Len() == 0 && m.Version() == 4)
+}
+
+func TestMap_Compute(t *testing.T) {
+	var m Map[string, int]
+	calls := 0
+	fn := func(k string) int { calls++; return len(k) }
+	require(t, m.ComputeIfAbsent("abc", fn) == 3)
+	require(t, m.ComputeIfAbsent("abc", fn) == 3 && calls == 1)
+
+	v, ok := m.ComputeIfPresent("abc", func(k string, v int) (int, bool) { return v * 2, true })
+	require(t, ok && v == 6 && m.Get("abc") == 6)
+
+	_, ok = m.ComputeIfPresent("x", func(k string, v int) (int, bool) { return 1, true })
+	require(t, !ok && !m.Exists("x"))
+
+	_, ok = m.ComputeIfPresent("abc", func(k string, v int) (int, bool) { return 0, false })
+	require(t, !ok && m.Len() == 0 && m.Version() == 3)
+}
+
+func TestMap_SetIfAbsent(t *testing.T) {
+	var m Map[string, int]
+	require(t, !m.SetIfPresent("a", 1) && !m.Exists("a"))
+	require(t, m.SetIfAbsent("a", 1))
+	require(t, !m.SetIfAbsent("a", 2) && m.Get("a") == 1)
+	require(t, m.SetIfPresent("a", 3) && m.Get("a") == 3)
+	require(t, m.Version() == 2)
+}
+
+func TestMap_AppendKeys(t *testing.T) {
+	m := NewMapPtr(map[int]int{1: 10, 2: 20})
+	keys := m.AppendKeys([]int{0})
+	slices.Sort(keys)
+	require(t, slices.Equal([]int{0, 1, 2}, keys))
+	vals := m.AppendValues(nil)
+	slices.Sort(vals)
+	require(t, slices.Equal([]int{10, 20}, vals))
+
+	buf := make([]int, 0, 2)
+	require(t, 0 == testing.AllocsPerRun(10, func() { buf = m.AppendKeys(buf[:0]) }))
+}
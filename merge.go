@@ -0,0 +1,49 @@
+package xsync
+
+import "io"
+
+// A MergeStrategy decides how LoadFrom combines loaded entries with the current contents of a map.
+type MergeStrategy[K comparable, T any] struct {
+	replace bool
+	resolve func(key K, current, loaded T) T
+}
+
+// Replace discards the current contents; the map holds exactly the loaded entries.
+func Replace[K comparable, T any]() MergeStrategy[K, T] {
+	return MergeStrategy[K, T]{replace: true}
+}
+
+// Overwrite adds the loaded entries, overwriting the current values of the same keys.
+func Overwrite[K comparable, T any]() MergeStrategy[K, T] {
+	return MergeStrategy[K, T]{}
+}
+
+// KeepExisting adds the loaded entries for missing keys only.
+func KeepExisting[K comparable, T any]() MergeStrategy[K, T] {
+	return MergeStrategy[K, T]{resolve: func(_ K, current, _ T) T { return current }}
+}
+
+// Resolve adds the loaded entries; for keys present in both the stored value is resolve(key, current, loaded).
+func Resolve[K comparable, T any](resolve func(key K, current, loaded T) T) MergeStrategy[K, T] {
+	return MergeStrategy[K, T]{resolve: resolve}
+}
+
+// LoadFrom reads a snapshot written by BinaryEncode (or SaveFile) and combines it with the current contents
+// according to strategy, atomically and with a single version bump.
+// Unlike BinaryDecode it can merge persisted state into state built at runtime.
+func (m *Map[K, T]) LoadFrom(r io.Reader, strategy MergeStrategy[K, T]) error {
+	r, err := readEnvelope(r)
+	if err != nil {
+		return err
+	}
+	var vals map[K]T
+	if err = GobCodec.Decode(r, &vals); err != nil {
+		return err
+	}
+	if strategy.replace {
+		m.replace(vals)
+	} else {
+		m.MergeMap(vals, strategy.resolve)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package xsync
+
+import (
+	"bytes"
+	"maps"
+	"testing"
+)
+
+func TestMap_LoadFrom(t *testing.T) {
+	var buf bytes.Buffer
+	saved := NewMapPtr(map[string]int{"a": 1, "b": 2})
+	require(t, nil == saved.BinaryEncode(&buf))
+	data := buf.Bytes()
+
+	load := func(strategy MergeStrategy[string, int]) map[string]int {
+		m := NewMapPtr(map[string]int{"b": 20, "c": 30})
+		ver := m.Version()
+		require(t, nil == m.LoadFrom(bytes.NewReader(data), strategy))
+		require(t, ver+1 == m.Version())
+		return m.KeyValues()
+	}
+	require(t, maps.Equal(map[string]int{"a": 1, "b": 2}, load(Replace[string, int]())))
+	require(t, maps.Equal(map[string]int{"a": 1, "b": 2, "c": 30}, load(Overwrite[string, int]())))
+	require(t, maps.Equal(map[string]int{"a": 1, "b": 20, "c": 30}, load(KeepExisting[string, int]())))
+	require(t, maps.Equal(map[string]int{"a": 1, "b": 22, "c": 30}, load(Resolve(func(_ string, cur, loaded int) int {
+		return cur + loaded
+	}))))
+
+	m := NewMapPtr(map[string]int{"x": 1})
+	require(t, nil != m.LoadFrom(bytes.NewReader(data[:len(data)-1]), Replace[string, int]()))
+	require(t, 1 == m.Get("x") && 1 == m.Len())
+}
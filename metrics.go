@@ -0,0 +1,103 @@
+package xsync
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Op is a container operation reported to a MetricsReporter.
+type Op uint8
+
+const (
+	OpGet Op = iota
+	OpSet
+	OpDelete
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpGet:
+		return "get"
+	case OpSet:
+		return "set"
+	case OpDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// A MetricsReporter receives instrumentation events of a container configured with WithMetrics.
+// Methods are called synchronously, so they must be fast and safe for concurrent use.
+type MetricsReporter interface {
+	// ReportOp is called for every Get/Set/Delete-like operation; hit reports whether the key was present.
+	// Only OpGet operations are lookups: their hits and misses measure the hit ratio.
+	ReportOp(op Op, hit bool)
+
+	// ReportLockWait is called with the time spent waiting for the container lock.
+	ReportLockWait(d time.Duration)
+
+	// ReportSize is called with the container size after every mutation.
+	ReportSize(n int)
+}
+
+// WithMetrics sets the reporter of container metrics.
+func WithMetrics(r MetricsReporter) Option {
+	return func(o *options) {
+		o.metrics = r
+	}
+}
+
+// Stats is a MetricsReporter accumulating counters. A zero Stats is ready to use.
+type Stats struct {
+	Gets, Sets, Deletes atomic.Uint64
+	Hits, Misses        atomic.Uint64 // of Get operations
+	LockWaits           atomic.Uint64 // number of lock acquisitions
+	LockWaitTime        atomic.Int64  // total lock wait time in nanoseconds
+	Size                atomic.Int64
+}
+
+func (s *Stats) ReportOp(op Op, hit bool) {
+	switch op {
+	case OpGet:
+		s.Gets.Add(1)
+		if hit {
+			s.Hits.Add(1)
+		} else {
+			s.Misses.Add(1)
+		}
+	case OpSet:
+		s.Sets.Add(1)
+	case OpDelete:
+		s.Deletes.Add(1)
+	}
+}
+
+func (s *Stats) ReportLockWait(d time.Duration) {
+	s.LockWaits.Add(1)
+	s.LockWaitTime.Add(int64(d))
+}
+
+func (s *Stats) ReportSize(n int) {
+	s.Size.Store(int64(n))
+}
+
+func lockMetered(mx *sync.RWMutex, r MetricsReporter) {
+	if r == nil {
+		mx.Lock()
+		return
+	}
+	t := time.Now()
+	mx.Lock()
+	r.ReportLockWait(time.Since(t))
+}
+
+func rlockMetered(mx *sync.RWMutex, r MetricsReporter) {
+	if r == nil {
+		mx.RLock()
+		return
+	}
+	t := time.Now()
+	mx.RLock()
+	r.ReportLockWait(time.Since(t))
+}
@@ -0,0 +1,56 @@
+package xsync
+
+import "testing"
+
+func TestMap_WithMetrics(t *testing.T) {
+	var st Stats
+	m := NewMapPtr[string, int](nil, WithMetrics(&st))
+
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Get("a")
+	m.Get("c")
+	m.Delete("b")
+
+	require(t, 2 == st.Sets.Load())
+	require(t, 2 == st.Gets.Load())
+	require(t, 1 == st.Deletes.Load())
+	require(t, 1 == st.Hits.Load())
+	require(t, 1 == st.Misses.Load())
+	require(t, 1 == st.Size.Load())
+	require(t, 5 == st.LockWaits.Load())
+}
+
+func TestMap_WithMetrics_size(t *testing.T) {
+	var st Stats
+	m := NewMapPtr(map[string]int{"a": 1, "b": 2, "c": 3}, WithMetrics(&st))
+	require(t, 3 == st.Size.Load())
+
+	m.Pop()
+	require(t, 2 == st.Size.Load())
+	m.SetIfAbsent("d", 4)
+	require(t, 3 == st.Size.Load())
+	m.Clear()
+	require(t, 0 == st.Size.Load())
+}
+
+func TestSet_WithMetrics_size(t *testing.T) {
+	for _, opts := range [][]SetOption{nil, {WithShards(4)}} {
+		var st Stats
+		s := NewSetPtr([]int{1, 2, 3, 4}, append(opts, WithMetrics(&st))...)
+		require(t, 4 == st.Size.Load())
+
+		s.Clear()
+		require(t, 0 == st.Size.Load())
+		s.AddMany(1, 2, 3)
+		require(t, 3 == st.Size.Load())
+		s.DeleteMany(1)
+		require(t, 2 == st.Size.Load())
+		s.Pop()
+		require(t, 1 == st.Size.Load())
+		require(t, nil == s.UnmarshalJSON([]byte(`[5,6,7]`)))
+		require(t, 3 == st.Size.Load())
+		s.PopAll()
+		require(t, 0 == st.Size.Load())
+	}
+}
@@ -0,0 +1,172 @@
+package xsync
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+// A MultiMap is a map from key to multiple values.
+//
+// DeleteValue compares values with ==, so it panics if V values are not comparable at runtime.
+//
+// A MultiMap is safe for use by multiple goroutines simultaneously.
+type MultiMap[K comparable, V any] struct {
+	mx   sync.RWMutex
+	ver  uint64
+	vals map[K][]V
+}
+
+// GroupBy returns a MultiMap of items grouped by keyFn, preserving the order of items within groups.
+func GroupBy[S any, K comparable](items []S, keyFn func(item S) K) *MultiMap[K, S] {
+	vals := map[K][]S{}
+	for _, item := range items {
+		k := keyFn(item)
+		vals[k] = append(vals[k], item)
+	}
+	return &MultiMap[K, S]{vals: vals}
+}
+
+func (m *MultiMap[K, V]) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.vals = nil
+	m.ver++
+}
+
+// Add appends values to the key and returns the new number of values of the key.
+// Adding no values does not create the key.
+func (m *MultiMap[K, V]) Add(key K, values ...V) int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if len(values) == 0 {
+		return len(m.vals[key])
+	}
+	if m.vals == nil {
+		m.vals = map[K][]V{}
+	}
+	vv := append(m.vals[key], values...)
+	m.vals[key] = vv
+	m.ver++
+	return len(vv)
+}
+
+// Get returns a copy of the values of the key.
+func (m *MultiMap[K, V]) Get(key K) []V {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return slices.Clone(m.vals[key])
+}
+
+func (m *MultiMap[K, V]) Delete(key K) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if _, ok := m.vals[key]; ok {
+		delete(m.vals, key)
+		m.ver++
+	}
+}
+
+// DeleteValue removes all occurrences of value from the key and returns the number of removed values.
+func (m *MultiMap[K, V]) DeleteValue(key K, value V) (n int) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	vv, ok := m.vals[key]
+	if !ok {
+		return
+	}
+	res := vv[:0]
+	for _, v := range vv {
+		if any(v) == any(value) {
+			n++
+		} else {
+			res = append(res, v)
+		}
+	}
+	clear(vv[len(res):])
+	if len(res) == 0 {
+		delete(m.vals, key)
+	} else {
+		m.vals[key] = res
+	}
+	if n > 0 {
+		m.ver++
+	}
+	return
+}
+
+func (m *MultiMap[K, V]) Exists(key K) bool {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	_, ok := m.vals[key]
+	return ok
+}
+
+// CountValues returns the number of values of the key.
+func (m *MultiMap[K, V]) CountValues(key K) int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.vals[key])
+}
+
+// Len returns the number of keys.
+func (m *MultiMap[K, V]) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.vals)
+}
+
+func (m *MultiMap[K, V]) Version() uint64 {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.ver
+}
+
+func (m *MultiMap[K, V]) Keys() []K {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return mapKeys(m.vals)
+}
+
+func (m *MultiMap[K, V]) KeyValues() map[K][]V {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	res := make(map[K][]V, len(m.vals))
+	for k, vv := range m.vals {
+		res[k] = slices.Clone(vv)
+	}
+	return res
+}
+
+// Range calls fn sequentially for each key and its values over a snapshot of the MultiMap.
+// If fn returns false, Range stops the iteration.
+func (m *MultiMap[K, V]) Range(fn func(key K, values []V) bool) {
+	for k, vv := range m.KeyValues() {
+		if !fn(k, vv) {
+			return
+		}
+	}
+}
+
+func (m *MultiMap[K, V]) String() string {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return encString(m.vals)
+}
+
+func (m *MultiMap[K, V]) MarshalJSON() ([]byte, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return json.Marshal(m.vals)
+}
+
+func (m *MultiMap[K, V]) UnmarshalJSON(data []byte) (err error) {
+	var vv map[K][]V
+	if err = json.Unmarshal(data, &vv); err != nil {
+		return
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.vals, m.ver = vv, m.ver+1
+	return
+}
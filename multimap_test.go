@@ -0,0 +1,51 @@
+package xsync
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMultiMap_Add(t *testing.T) {
+	var m MultiMap[string, int]
+
+	m.Add("a", 1)
+	m.Add("a", 2, 1)
+	m.Add("b", 3)
+
+	require(t, 2 == m.Len())
+	require(t, 3 == m.CountValues("a"))
+	require(t, 0 == m.CountValues("c"))
+	require(t, 2 == m.Get("a")[1])
+	require(t, 3 == m.Add("a") && 0 == m.Add("c"))
+	require(t, !m.Exists("c") && 2 == m.Len())
+}
+
+func TestMultiMap_DeleteValue(t *testing.T) {
+	var m MultiMap[string, int]
+	m.Add("a", 1, 2, 1)
+
+	require(t, 2 == m.DeleteValue("a", 1))
+	require(t, 0 == m.DeleteValue("a", 1))
+	require(t, 1 == m.DeleteValue("a", 2))
+	require(t, !m.Exists("a"))
+}
+
+func TestMultiMap_MarshalJSON(t *testing.T) {
+	var m, m2 MultiMap[string, int]
+	m.Add("a", 1, 2)
+
+	data, err := json.Marshal(&m)
+	require(t, err == nil)
+	require(t, `{"a":[1,2]}` == string(data))
+
+	require(t, nil == json.Unmarshal(data, &m2))
+	require(t, 2 == m2.CountValues("a"))
+}
+
+func TestGroupBy(t *testing.T) {
+	m := GroupBy([]string{"apple", "bob", "avocado", "cat", "banana"}, func(s string) byte { return s[0] })
+
+	require(t, 3 == m.Len())
+	require(t, 2 == m.CountValues('a'))
+	require(t, "banana" == m.Get('b')[1])
+}
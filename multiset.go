@@ -0,0 +1,180 @@
+package xsync
+
+import (
+	"encoding/json"
+	"maps"
+	"sync"
+)
+
+// A MultiSet is a set that counts occurrences of its elements (a bag).
+//
+// A MultiSet is safe for use by multiple goroutines simultaneously.
+type MultiSet[K comparable] struct {
+	mx    sync.RWMutex
+	ver   uint64
+	total int
+	vals  map[K]int
+}
+
+// NewMultiSet returns a MultiSet with the given element counts. Non-positive counts are ignored.
+func NewMultiSet[K comparable](counts map[K]int) *MultiSet[K] {
+	s := &MultiSet[K]{}
+	for k, n := range counts {
+		s.add(k, n)
+	}
+	return s
+}
+
+// MultiSetFromMap returns a MultiSet with the element counts stored in m. Non-positive counts are ignored.
+func MultiSetFromMap[K comparable](m *Map[K, int]) *MultiSet[K] {
+	return NewMultiSet(m.KeyValues())
+}
+
+func (s *MultiSet[K]) Clear() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.vals, s.total = nil, 0
+	s.ver++
+}
+
+// Add adds one occurrence of the key and returns the new count.
+func (s *MultiSet[K]) Add(key K) int {
+	return s.AddN(key, 1)
+}
+
+// AddN adds n occurrences of the key and returns the new count.
+func (s *MultiSet[K]) AddN(key K, n int) int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.ver++
+	return s.add(key, n)
+}
+
+func (s *MultiSet[K]) add(key K, n int) int {
+	if n <= 0 {
+		return s.vals[key]
+	}
+	if s.vals == nil {
+		s.vals = map[K]int{}
+	}
+	s.vals[key] += n
+	s.total += n
+	return s.vals[key]
+}
+
+// Remove removes one occurrence of the key and returns the new count.
+func (s *MultiSet[K]) Remove(key K) int {
+	return s.RemoveN(key, 1)
+}
+
+// RemoveN removes up to n occurrences of the key and returns the new count.
+func (s *MultiSet[K]) RemoveN(key K, n int) int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	c, ok := s.vals[key]
+	if !ok || n <= 0 {
+		return c
+	}
+	n = min(n, c)
+	s.total -= n
+	s.ver++
+	if c -= n; c == 0 {
+		delete(s.vals, key)
+	} else {
+		s.vals[key] = c
+	}
+	return c
+}
+
+// Delete removes all occurrences of the key.
+func (s *MultiSet[K]) Delete(key K) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if c, ok := s.vals[key]; ok {
+		delete(s.vals, key)
+		s.total -= c
+		s.ver++
+	}
+}
+
+func (s *MultiSet[K]) Count(key K) int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.vals[key]
+}
+
+func (s *MultiSet[K]) Exists(key K) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	_, ok := s.vals[key]
+	return ok
+}
+
+// Distinct returns the number of distinct elements.
+func (s *MultiSet[K]) Distinct() int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return len(s.vals)
+}
+
+// TotalCount returns the number of occurrences of all elements.
+func (s *MultiSet[K]) TotalCount() int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.total
+}
+
+func (s *MultiSet[K]) Version() uint64 {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.ver
+}
+
+// Values returns distinct elements.
+func (s *MultiSet[K]) Values() []K {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return mapKeys(s.vals)
+}
+
+// Counts returns a copy of element counts.
+func (s *MultiSet[K]) Counts() map[K]int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	res := maps.Clone(s.vals)
+	if res == nil {
+		res = map[K]int{}
+	}
+	return res
+}
+
+// ToMap returns element counts as a Map.
+func (s *MultiSet[K]) ToMap() *Map[K, int] {
+	vals := s.Counts()
+	return &Map[K, int]{vals: vals, size: int64(len(vals))}
+}
+
+func (s *MultiSet[K]) String() string {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return encString(s.vals)
+}
+
+func (s *MultiSet[K]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Counts())
+}
+
+func (s *MultiSet[K]) UnmarshalJSON(data []byte) (err error) {
+	var counts map[K]int
+	if err = json.Unmarshal(data, &counts); err != nil {
+		return
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.vals, s.total = nil, 0
+	for k, n := range counts {
+		s.add(k, n)
+	}
+	s.ver++
+	return
+}
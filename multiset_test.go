@@ -0,0 +1,38 @@
+package xsync
+
+import "testing"
+
+func TestMultiSet_Add(t *testing.T) {
+	var s MultiSet[string]
+
+	require(t, 1 == s.Add("a"))
+	require(t, 2 == s.Add("a"))
+	require(t, 1 == s.Add("b"))
+	require(t, 1 == s.Remove("a"))
+	require(t, 0 == s.Remove("b"))
+	require(t, 0 == s.Remove("c"))
+
+	require(t, 1 == s.Distinct())
+	require(t, 1 == s.TotalCount())
+	require(t, !s.Exists("b"))
+}
+
+func TestMultiSet_ToMap(t *testing.T) {
+	s := NewMultiSet(map[string]int{"a": 2, "b": 3, "c": 0})
+
+	m := s.ToMap()
+
+	require(t, 5 == s.TotalCount())
+	require(t, 2 == m.Len())
+	require(t, 3 == m.Get("b"))
+}
+
+func TestMultiSetFromMap(t *testing.T) {
+	m := NewMapPtr(map[string]int{"a": 2, "b": -1})
+
+	s := MultiSetFromMap(m)
+
+	require(t, 2 == s.TotalCount())
+	require(t, 1 == s.Distinct())
+	require(t, s.ToMap().Equal(NewMapPtr(map[string]int{"a": 2})))
+}
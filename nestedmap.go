@@ -0,0 +1,226 @@
+package xsync
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"sync"
+)
+
+// A NestedMap is a two-level map: values are stored by key within namespaces, e.g. per-tenant caches.
+// Both levels are guarded by a single lock, so namespace operations are atomic with respect to
+// operations on their keys. Empty namespaces are removed automatically.
+//
+// A zero NestedMap is ready to use. A NestedMap is safe for use by multiple goroutines simultaneously.
+type NestedMap[K1, K2 comparable, T any] struct {
+	mx       sync.RWMutex
+	ver      uint64
+	vals     map[K1]map[K2]T
+	watchers map[K1]*Broadcast[NestedEvent[K2, T]]
+
+	pubMx sync.Mutex // keeps events in the order of changes without holding mx while publishing
+}
+
+// A NestedEvent is a change of a namespace delivered by NestedMap.Watch.
+type NestedEvent[K comparable, T any] struct {
+	Key       K
+	Value     T    // the new value; zero if Deleted
+	Deleted   bool // the key was deleted, or the whole namespace if Namespace is true
+	Namespace bool // the event applies to the whole namespace
+}
+
+// unlockAndPublish unlocks m.mx and publishes events to the watchers of the namespace. m.mx must be held.
+func (m *NestedMap[K1, K2, T]) unlockAndPublish(ns K1, events ...NestedEvent[K2, T]) {
+	b := m.watchers[ns]
+	if b == nil {
+		m.mx.Unlock()
+		return
+	}
+	m.pubMx.Lock()
+	defer m.pubMx.Unlock()
+	m.mx.Unlock()
+	for _, e := range events {
+		b.Publish(e)
+	}
+}
+
+// Watch returns a channel receiving the changes of the namespace until ctx is done.
+// Delivery is configured by opts as for Broadcast.Subscribe; by default writers wait for the watcher.
+func (m *NestedMap[K1, K2, T]) Watch(ctx context.Context, ns K1, opts ...SubscribeOption) <-chan NestedEvent[K2, T] {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	b := m.watchers[ns]
+	if b == nil {
+		if m.watchers == nil {
+			m.watchers = map[K1]*Broadcast[NestedEvent[K2, T]]{}
+		}
+		b = &Broadcast[NestedEvent[K2, T]]{}
+		m.watchers[ns] = b
+	}
+	return b.Subscribe(ctx, opts...)
+}
+
+func (m *NestedMap[K1, K2, T]) Set(ns K1, key K2, value T) {
+	m.mx.Lock()
+	if m.vals == nil {
+		m.vals = map[K1]map[K2]T{}
+	}
+	vv := m.vals[ns]
+	if vv == nil {
+		vv = map[K2]T{}
+		m.vals[ns] = vv
+	}
+	vv[key] = value
+	m.ver++
+	m.unlockAndPublish(ns, NestedEvent[K2, T]{Key: key, Value: value})
+}
+
+func (m *NestedMap[K1, K2, T]) Get(ns K1, key K2) T {
+	v, _ := m.Lookup(ns, key)
+	return v
+}
+
+func (m *NestedMap[K1, K2, T]) Lookup(ns K1, key K2) (v T, ok bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	v, ok = m.vals[ns][key]
+	return
+}
+
+// Delete removes the key from the namespace and reports whether it was present.
+func (m *NestedMap[K1, K2, T]) Delete(ns K1, key K2) bool {
+	m.mx.Lock()
+	vv := m.vals[ns]
+	if _, ok := vv[key]; !ok {
+		m.mx.Unlock()
+		return false
+	}
+	delete(vv, key)
+	if len(vv) == 0 {
+		delete(m.vals, ns)
+	}
+	m.ver++
+	m.unlockAndPublish(ns, NestedEvent[K2, T]{Key: key, Deleted: true})
+	return true
+}
+
+// GetNamespace returns a copy of the namespace.
+func (m *NestedMap[K1, K2, T]) GetNamespace(ns K1) map[K2]T {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	res := make(map[K2]T, len(m.vals[ns]))
+	maps.Copy(res, m.vals[ns])
+	return res
+}
+
+// SetNamespace atomically replaces the contents of the namespace with a copy of values.
+func (m *NestedMap[K1, K2, T]) SetNamespace(ns K1, values map[K2]T) {
+	m.mx.Lock()
+	events := []NestedEvent[K2, T]{{Deleted: true, Namespace: true}}
+	if len(values) == 0 {
+		delete(m.vals, ns)
+	} else {
+		if m.vals == nil {
+			m.vals = map[K1]map[K2]T{}
+		}
+		m.vals[ns] = maps.Clone(values)
+		for k, v := range values {
+			events = append(events, NestedEvent[K2, T]{Key: k, Value: v})
+		}
+	}
+	m.ver++
+	m.unlockAndPublish(ns, events...)
+}
+
+// DeleteNamespace removes the namespace with all its keys and reports whether it was present.
+func (m *NestedMap[K1, K2, T]) DeleteNamespace(ns K1) bool {
+	m.mx.Lock()
+	if _, ok := m.vals[ns]; !ok {
+		m.mx.Unlock()
+		return false
+	}
+	delete(m.vals, ns)
+	m.ver++
+	m.unlockAndPublish(ns, NestedEvent[K2, T]{Deleted: true, Namespace: true})
+	return true
+}
+
+// LenNamespace returns the number of keys of the namespace.
+func (m *NestedMap[K1, K2, T]) LenNamespace(ns K1) int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.vals[ns])
+}
+
+// Namespaces returns the non-empty namespaces.
+func (m *NestedMap[K1, K2, T]) Namespaces() []K1 {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return mapKeys(m.vals)
+}
+
+// Len returns the number of keys in all namespaces.
+func (m *NestedMap[K1, K2, T]) Len() (n int) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	for _, vv := range m.vals {
+		n += len(vv)
+	}
+	return
+}
+
+func (m *NestedMap[K1, K2, T]) Version() uint64 {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.ver
+}
+
+// Clear removes all namespaces.
+func (m *NestedMap[K1, K2, T]) Clear() {
+	m.mx.Lock()
+	cleared := mapKeys(m.vals)
+	m.vals = nil
+	m.ver++
+	watchers := maps.Clone(m.watchers)
+	m.pubMx.Lock()
+	defer m.pubMx.Unlock()
+	m.mx.Unlock()
+	for _, ns := range cleared {
+		if b := watchers[ns]; b != nil {
+			b.Publish(NestedEvent[K2, T]{Deleted: true, Namespace: true})
+		}
+	}
+}
+
+// KeyValues returns a deep copy of all namespaces.
+func (m *NestedMap[K1, K2, T]) KeyValues() map[K1]map[K2]T {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	res := make(map[K1]map[K2]T, len(m.vals))
+	for ns, vv := range m.vals {
+		res[ns] = maps.Clone(vv)
+	}
+	return res
+}
+
+func (m *NestedMap[K1, K2, T]) String() string {
+	return encString(m.KeyValues())
+}
+
+// MarshalJSON encodes the NestedMap as a JSON object of objects.
+func (m *NestedMap[K1, K2, T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.KeyValues())
+}
+
+// UnmarshalJSON replaces the contents of the NestedMap. Watchers are not notified.
+func (m *NestedMap[K1, K2, T]) UnmarshalJSON(data []byte) error {
+	var vv map[K1]map[K2]T
+	if err := json.Unmarshal(data, &vv); err != nil {
+		return err
+	}
+	maps.DeleteFunc(vv, func(_ K1, v map[K2]T) bool { return len(v) == 0 })
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.vals, m.ver = vv, m.ver+1
+	return nil
+}
@@ -0,0 +1,49 @@
+package xsync
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNestedMap(t *testing.T) {
+	var m NestedMap[string, string, int]
+	m.Set("t1", "a", 1)
+	m.Set("t1", "b", 2)
+	m.Set("t2", "a", 3)
+	require(t, m.Get("t1", "b") == 2 && m.LenNamespace("t1") == 2 && m.Len() == 3 && len(m.Namespaces()) == 2)
+
+	ns := m.GetNamespace("t1")
+	ns["c"] = 3 // a copy
+	require(t, m.LenNamespace("t1") == 2 && len(m.GetNamespace("none")) == 0)
+
+	require(t, m.Delete("t2", "a") && !m.Delete("t2", "a"))
+	require(t, len(m.Namespaces()) == 1) // the empty namespace is removed
+
+	m.SetNamespace("t2", map[string]int{"x": 1})
+	require(t, m.DeleteNamespace("t1") && !m.DeleteNamespace("t1") && m.Len() == 1)
+
+	data, err := json.Marshal(&m)
+	require(t, err == nil && string(data) == `{"t2":{"x":1}}`)
+	var m2 NestedMap[string, string, int]
+	require(t, json.Unmarshal(data, &m2) == nil && m2.Get("t2", "x") == 1)
+}
+
+func TestNestedMap_Watch(t *testing.T) {
+	var m NestedMap[string, string, int]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := m.Watch(ctx, "t1", WithBuffer(10))
+
+	m.Set("t2", "a", 1) // another namespace
+	m.Set("t1", "a", 1)
+	m.Delete("t1", "a")
+	m.Set("t1", "b", 2)
+	m.Clear()
+
+	require(t, <-ch == NestedEvent[string, int]{Key: "a", Value: 1})
+	require(t, <-ch == NestedEvent[string, int]{Key: "a", Deleted: true})
+	require(t, <-ch == NestedEvent[string, int]{Key: "b", Value: 2})
+	require(t, <-ch == NestedEvent[string, int]{Deleted: true, Namespace: true})
+	require(t, len(ch) == 0)
+}
@@ -0,0 +1,69 @@
+package xsync
+
+// An Option configures a Map or a Set at construction.
+type Option func(*options)
+
+// A SetOption configures a Set at construction. Every Option is a SetOption;
+// options applying to a Set only, such as WithShards, are not Options, so passing them to a Map does not compile.
+type SetOption interface {
+	applySet(*options)
+}
+
+func (fn Option) applySet(o *options) {
+	fn(o)
+}
+
+// setOnlyOption is a SetOption that is not an Option.
+type setOnlyOption func(*options)
+
+func (fn setOnlyOption) applySet(o *options) {
+	fn(o)
+}
+
+type options struct {
+	capacity int
+	sqlCodec Codec
+	metrics  MetricsReporter
+	logLimit int
+	shards   int
+	rand     *randSource
+
+	cacheOutput bool
+
+	maxEntries int
+	policy     EvictionPolicy
+	onEvict    any   // func(K, T)
+	onDelete   any   // func(K, T, RemovalReason)
+	keyFunc    any   // func(K) K
+	cloner     any   // func(T) T
+	entryClock Clock // set by WithEntryStats
+}
+
+func newOptions(opts []Option) (o options) {
+	for _, fn := range opts {
+		fn(&o)
+	}
+	return
+}
+
+func newSetOptions(opts []SetOption) (o options) {
+	for _, opt := range opts {
+		opt.applySet(&o)
+	}
+	return
+}
+
+// WithCapacity sets the initial capacity hint of the container.
+// The hint is also used when the container is reallocated after Clear or PopAll.
+func WithCapacity(n int) Option {
+	return func(o *options) {
+		o.capacity = n
+	}
+}
+
+// WithSQLCodec sets the codec used by Value and Scan (database/sql support). JSON is used by default.
+func WithSQLCodec(c Codec) Option {
+	return func(o *options) {
+		o.sqlCodec = c
+	}
+}
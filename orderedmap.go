@@ -0,0 +1,178 @@
+package xsync
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"sync"
+)
+
+// An OrderedMap is a map that preserves insertion order of keys.
+// Updating an existing key does not change its position.
+//
+// An OrderedMap is safe for use by multiple goroutines simultaneously.
+type OrderedMap[K comparable, T any] struct {
+	mx    sync.RWMutex
+	ver   uint64
+	vals  map[K]*list.Element
+	order list.List
+}
+
+type orderedEntry[K comparable, T any] struct {
+	key K
+	val T
+}
+
+func (m *OrderedMap[K, T]) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.vals = nil
+	m.order.Init()
+	m.ver++
+}
+
+func (m *OrderedMap[K, T]) Set(key K, value T) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.set(key, value)
+	m.ver++
+}
+
+func (m *OrderedMap[K, T]) set(key K, value T) {
+	if e, ok := m.vals[key]; ok {
+		e.Value.(*orderedEntry[K, T]).val = value
+		return
+	}
+	if m.vals == nil {
+		m.vals = map[K]*list.Element{}
+	}
+	m.vals[key] = m.order.PushBack(&orderedEntry[K, T]{key, value})
+}
+
+func (m *OrderedMap[K, T]) Get(key K) (_ T) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if e, ok := m.vals[key]; ok {
+		return e.Value.(*orderedEntry[K, T]).val
+	}
+	return
+}
+
+func (m *OrderedMap[K, T]) Exists(key K) bool {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	_, ok := m.vals[key]
+	return ok
+}
+
+func (m *OrderedMap[K, T]) Delete(key K) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if e, ok := m.vals[key]; ok {
+		m.order.Remove(e)
+		delete(m.vals, key)
+		m.ver++
+	}
+}
+
+func (m *OrderedMap[K, T]) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.vals)
+}
+
+func (m *OrderedMap[K, T]) Version() uint64 {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.ver
+}
+
+// Keys returns keys in insertion order.
+func (m *OrderedMap[K, T]) Keys() []K {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	kk := make([]K, 0, len(m.vals))
+	for e := m.order.Front(); e != nil; e = e.Next() {
+		kk = append(kk, e.Value.(*orderedEntry[K, T]).key)
+	}
+	return kk
+}
+
+// Values returns values in insertion order of their keys.
+func (m *OrderedMap[K, T]) Values() []T {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	vv := make([]T, 0, len(m.vals))
+	for e := m.order.Front(); e != nil; e = e.Next() {
+		vv = append(vv, e.Value.(*orderedEntry[K, T]).val)
+	}
+	return vv
+}
+
+// Range calls fn for each entry in insertion order over a snapshot of the map.
+// If fn returns false, Range stops the iteration.
+func (m *OrderedMap[K, T]) Range(fn func(key K, value T) bool) {
+	for _, e := range m.entries() {
+		if !fn(e.key, e.val) {
+			return
+		}
+	}
+}
+
+func (m *OrderedMap[K, T]) entries() []orderedEntry[K, T] {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	ee := make([]orderedEntry[K, T], 0, len(m.vals))
+	for e := m.order.Front(); e != nil; e = e.Next() {
+		ee = append(ee, *e.Value.(*orderedEntry[K, T]))
+	}
+	return ee
+}
+
+func (m *OrderedMap[K, T]) String() string {
+	b, _ := m.MarshalJSON()
+	return string(b)
+}
+
+// MarshalJSON encodes the map as a JSON object with keys in insertion order.
+func (m *OrderedMap[K, T]) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, e := range m.entries() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := marshalJSONKey(e.key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(e.val)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON object keeping the order of its keys.
+func (m *OrderedMap[K, T]) UnmarshalJSON(data []byte) error {
+	var ee []orderedEntry[K, T]
+	if err := decodeJSONObject(json.NewDecoder(bytes.NewReader(data)), func(k K, v T) error {
+		ee = append(ee, orderedEntry[K, T]{k, v})
+		return nil
+	}); err != nil {
+		return err
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.vals = nil
+	m.order.Init()
+	for _, e := range ee {
+		m.set(e.key, e.val)
+	}
+	m.ver++
+	return nil
+}
@@ -0,0 +1,44 @@
+package xsync
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrderedMap_Keys(t *testing.T) {
+	var m OrderedMap[string, int]
+	m.Set("c", 3)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 33)
+	m.Delete("a")
+
+	kk := m.Keys()
+
+	require(t, 2 == len(kk))
+	require(t, "c" == kk[0] && "b" == kk[1])
+	require(t, 33 == m.Get("c"))
+}
+
+func TestOrderedMap_MarshalJSON(t *testing.T) {
+	var m OrderedMap[string, int]
+	m.Set("z", 1)
+	m.Set("a", 2)
+
+	data, err := json.Marshal(&m)
+
+	require(t, err == nil)
+	require(t, `{"z":1,"a":2}` == string(data))
+}
+
+func TestOrderedMap_UnmarshalJSON(t *testing.T) {
+	var m OrderedMap[int, string]
+
+	err := json.Unmarshal([]byte(`{"3":"c","1":"a","2":"b"}`), &m)
+
+	require(t, err == nil)
+	require(t, `{"3":"c","1":"a","2":"b"}` == m.String())
+
+	m.Delete(1)
+	require(t, `{"3":"c","2":"b"}` == m.String())
+}
@@ -0,0 +1,44 @@
+package xsync
+
+import "sync/atomic"
+
+// WithCachedOutput makes a Map or a Set cache the results of MarshalJSON and String
+// and reuse them while the container version is unchanged.
+// It pays off when an unchanged container is serialized many times between updates.
+func WithCachedOutput() Option {
+	return func(o *options) {
+		o.cacheOutput = true
+	}
+}
+
+// An outputCache holds the last encoded outputs of a container.
+type outputCache struct {
+	json, text atomic.Pointer[cachedOutput]
+}
+
+type cachedOutput struct {
+	ver  uint64
+	data []byte
+}
+
+func newOutputCache(o options) *outputCache {
+	if !o.cacheOutput {
+		return nil
+	}
+	return &outputCache{}
+}
+
+// cached returns the output stored in p if it was encoded at the current version,
+// otherwise it encodes and stores a new one.
+// The version is read before encoding, so a concurrent update at worst causes a cache miss.
+func cached(p *atomic.Pointer[cachedOutput], version func() uint64, encode func() ([]byte, error)) ([]byte, error) {
+	ver := version()
+	if c := p.Load(); c != nil && c.ver == ver {
+		return c.data, nil
+	}
+	data, err := encode()
+	if err == nil {
+		p.Store(&cachedOutput{ver, data})
+	}
+	return data, err
+}
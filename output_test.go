@@ -0,0 +1,22 @@
+package xsync
+
+import "testing"
+
+func TestWithCachedOutput(t *testing.T) {
+	m := NewMapPtr(map[string]int{"b": 2, "a": 1}, WithCachedOutput())
+	b, err := m.MarshalJSON()
+	require(t, err == nil && `{"a":1,"b":2}` == string(b))
+	b[0] = 'x' // callers get a copy
+	b, _ = m.MarshalJSON()
+	require(t, `{"a":1,"b":2}` == string(b) && `{"a":1,"b":2}` == m.String())
+	require(t, 1 >= testing.AllocsPerRun(10, func() { _ = m.String() })) // string conversion only
+
+	m.Set("c", 3)
+	b, _ = m.MarshalJSON()
+	require(t, `{"a":1,"b":2,"c":3}` == string(b) && `{"a":1,"b":2,"c":3}` == m.String())
+
+	s := NewSetPtr([]int{2, 1}, WithCachedOutput())
+	require(t, "[1,2]" == s.String())
+	s.Set(3)
+	require(t, "[1,2,3]" == s.String())
+}
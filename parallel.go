@@ -0,0 +1,57 @@
+package xsync
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// RangeParallel calls fn for each entry of the map using up to workers goroutines
+// (GOMAXPROCS if workers <= 0) and returns the first error returned by fn.
+//
+// The keys are snapshotted first, so no lock is held while fn runs and fn may modify the map.
+// The value passed to fn is read when the key is processed; keys deleted in the meantime are skipped.
+// On the first error, or when ctx is done, no more calls are started, and the error or ctx.Err() is returned.
+func (m *Map[K, T]) RangeParallel(ctx context.Context, workers int, fn func(key K, value T) error) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	keys := m.Keys()
+	workers = min(workers, len(keys))
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	ch := make(chan K)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for k := range ch {
+				if v, ok := m.Lookup(k); ok {
+					if err := fn(k, v); err != nil {
+						cancel(err)
+						return
+					}
+				}
+			}
+		}()
+	}
+feed:
+	for _, k := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case ch <- k:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(ch)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMap_RangeParallel(t *testing.T) {
+	var m Map[int, int]
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i)
+	}
+	var sum atomic.Int64
+	err := m.RangeParallel(context.Background(), 4, func(k, v int) error {
+		sum.Add(int64(v))
+		m.Delete(k + 1) // writers are not blocked; deleted keys may be skipped
+		return nil
+	})
+	require(t, err == nil && sum.Load() > 0 && sum.Load() <= 499500)
+
+	var n Map[int, int]
+	require(t, n.RangeParallel(context.Background(), 0, nil) == nil)
+}
+
+func TestMap_RangeParallel_error(t *testing.T) {
+	var m Map[int, int]
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i)
+	}
+	errStop := errors.New("stop")
+	var calls atomic.Int32
+	err := m.RangeParallel(context.Background(), 2, func(k, v int) error {
+		calls.Add(1)
+		return errStop
+	})
+	require(t, err == errStop && calls.Load() <= 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = m.RangeParallel(ctx, 2, func(k, v int) error { return nil })
+	require(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,135 @@
+package xsync
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// The pipeline functions below start goroutines connecting channels. Every output channel is closed
+// once its inputs are closed and drained or ctx is done, so that the goroutines never leak.
+// Values in flight when ctx is done are dropped.
+
+// recv receives a value from ch; ok is false if ch is closed or ctx is done.
+func recv[T any](ctx context.Context, ch <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-ch:
+		return
+	case <-ctx.Done():
+		return v, false
+	}
+}
+
+// send sends v on ch and reports whether it was sent before ctx was done.
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// FanIn merges the values of chs into a single channel, in no particular order.
+func FanIn[T any](ctx context.Context, chs ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(chs))
+	for _, ch := range chs {
+		go func() {
+			defer wg.Done()
+			for v, ok := recv(ctx, ch); ok; v, ok = recv(ctx, ch) {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// FanOut distributes the values of ch over n channels: each value is received from exactly one of them,
+// whichever is ready first, so a slow consumer does not hold up the others.
+func FanOut[T any](ctx context.Context, ch <-chan T, n int) []<-chan T {
+	res := make([]<-chan T, n)
+	for i := range res {
+		out := make(chan T)
+		res[i] = out
+		go func() {
+			defer close(out)
+			for v, ok := recv(ctx, ch); ok; v, ok = recv(ctx, ch) {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}()
+	}
+	return res
+}
+
+// MapChan passes the values of ch transformed by fn to the returned channel.
+func MapChan[T, U any](ctx context.Context, ch <-chan T, fn func(T) U) <-chan U {
+	out := make(chan U)
+	go func() {
+		defer close(out)
+		for v, ok := recv(ctx, ch); ok; v, ok = recv(ctx, ch) {
+			if !send(ctx, out, fn(v)) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Buffer returns a channel receiving the values of ch through a buffer of n values,
+// decoupling a bursty producer from the consumer.
+func Buffer[T any](ctx context.Context, ch <-chan T, n int) <-chan T {
+	out := make(chan T, n)
+	go func() {
+		defer close(out)
+		for v, ok := recv(ctx, ch); ok; v, ok = recv(ctx, ch) {
+			if !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Tee copies every value of ch to n channels. A value is passed on only after all outputs have received it,
+// in any order, so the slowest consumer sets the pace.
+func Tee[T any](ctx context.Context, ch <-chan T, n int) []<-chan T {
+	outs := make([]chan T, n)
+	res := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+		res[i] = outs[i]
+	}
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		cases := make([]reflect.SelectCase, n+1)
+		cases[n] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+		for v, ok := recv(ctx, ch); ok; v, ok = recv(ctx, ch) {
+			rv := reflect.ValueOf(&v).Elem()
+			for i, out := range outs {
+				cases[i] = reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(out), Send: rv}
+			}
+			for left := n; left > 0; left-- {
+				i, _, _ := reflect.Select(cases)
+				if i == n {
+					return
+				}
+				cases[i].Chan = reflect.Value{} // sent; a zero channel is ignored
+			}
+		}
+	}()
+	return res
+}
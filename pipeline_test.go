@@ -0,0 +1,78 @@
+package xsync
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func gen(n int) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for i := 0; i < n; i++ {
+			ch <- i
+		}
+	}()
+	return ch
+}
+
+func collect[T any](ch <-chan T) (res []T) {
+	for v := range ch {
+		res = append(res, v)
+	}
+	return
+}
+
+func TestFanInFanOut(t *testing.T) {
+	ctx := context.Background()
+	outs := FanOut(ctx, gen(100), 4)
+	require(t, len(outs) == 4)
+	doubled := make([]<-chan int, len(outs))
+	for i, ch := range outs {
+		doubled[i] = MapChan(ctx, ch, func(v int) int { return v * 2 })
+	}
+	res := collect(FanIn(ctx, doubled...))
+	slices.Sort(res)
+	require(t, len(res) == 100 && res[0] == 0 && res[99] == 198)
+}
+
+func TestBuffer(t *testing.T) {
+	ch := make(chan int)
+	out := Buffer(context.Background(), ch, 3)
+	for i := 0; i < 3; i++ {
+		ch <- i
+	}
+	close(ch)
+	require(t, slices.Equal(collect(out), []int{0, 1, 2}))
+}
+
+func TestTee(t *testing.T) {
+	outs := Tee(context.Background(), gen(10), 2)
+	var wg sync.WaitGroup
+	res := make([][]int, 2)
+	for i := range outs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res[i] = collect(outs[i])
+		}()
+	}
+	wg.Wait()
+	require(t, len(res[0]) == 10 && slices.Equal(res[0], res[1]))
+}
+
+func TestPipeline_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int) // never closed
+	out := FanIn(ctx, MapChan(ctx, in, func(v int) int { return v }))
+	outs := Tee(ctx, in, 2)
+	cancel()
+	for range out {
+	}
+	for _, ch := range outs {
+		for range ch {
+		}
+	}
+}
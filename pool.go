@@ -0,0 +1,38 @@
+package xsync
+
+import "sync"
+
+// A Pool is a typed wrapper over sync.Pool.
+//
+// New, if set, is called by Get when the pool is empty.
+// Reset, if set, is called by Put before the object is returned to the pool.
+//
+// A Pool is safe for use by multiple goroutines simultaneously.
+// A Pool must not be copied after first use.
+type Pool[T any] struct {
+	New   func() T
+	Reset func(T)
+
+	pool sync.Pool
+}
+
+func NewPool[T any](newFn func() T, reset func(T)) *Pool[T] {
+	return &Pool[T]{New: newFn, Reset: reset}
+}
+
+func (p *Pool[T]) Get() (obj T) {
+	if v := p.pool.Get(); v != nil {
+		return v.(T)
+	}
+	if p.New != nil {
+		obj = p.New()
+	}
+	return
+}
+
+func (p *Pool[T]) Put(obj T) {
+	if p.Reset != nil {
+		p.Reset(obj)
+	}
+	p.pool.Put(obj)
+}
@@ -0,0 +1,26 @@
+package xsync
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPool_Get(t *testing.T) {
+	var p Pool[int]
+
+	require(t, 0 == p.Get())
+}
+
+func TestPool_Reset(t *testing.T) {
+	p := NewPool(
+		func() *bytes.Buffer { return new(bytes.Buffer) },
+		func(b *bytes.Buffer) { b.Reset() },
+	)
+
+	b := p.Get()
+	b.WriteString("abc")
+	p.Put(b)
+
+	require(t, 0 == b.Len())
+	require(t, p.Get() != nil)
+}
@@ -0,0 +1,300 @@
+package xsync
+
+import (
+	"strings"
+	"sync"
+)
+
+// A PrefixMap is a map with string keys supporting prefix queries, backed by a radix tree.
+// Lookups take time proportional to the key length, not to the number of keys.
+//
+// A PrefixMap is safe for use by multiple goroutines simultaneously.
+type PrefixMap[T any] struct {
+	mx   sync.RWMutex
+	ver  uint64
+	size int
+	root prefixNode[T]
+}
+
+type prefixNode[T any] struct {
+	prefix   string // edge label from the parent
+	val      T
+	has      bool
+	children []*prefixNode[T] // sorted by the first byte of prefix
+}
+
+// child returns the child starting with b, or the insert position if there is none.
+func (n *prefixNode[T]) child(b byte) (int, *prefixNode[T]) {
+	for i, c := range n.children {
+		if c.prefix[0] == b {
+			return i, c
+		} else if c.prefix[0] > b {
+			return i, nil
+		}
+	}
+	return len(n.children), nil
+}
+
+func (n *prefixNode[T]) set(key string, val T) (added bool) {
+	for key != "" {
+		i, c := n.child(key[0])
+		if c == nil {
+			c = &prefixNode[T]{prefix: key}
+			n.children = append(n.children, nil)
+			copy(n.children[i+1:], n.children[i:])
+			n.children[i] = c
+		} else if l := commonPrefixLen(key, c.prefix); l < len(c.prefix) {
+			mid := &prefixNode[T]{prefix: c.prefix[:l], children: []*prefixNode[T]{c}}
+			c.prefix = c.prefix[l:]
+			n.children[i] = mid
+			c = mid
+		}
+		key = key[len(c.prefix):]
+		n = c
+	}
+	added = !n.has
+	n.val, n.has = val, true
+	return
+}
+
+func (n *prefixNode[T]) find(key string) *prefixNode[T] {
+	for key != "" {
+		_, c := n.child(key[0])
+		if c == nil || !strings.HasPrefix(key, c.prefix) {
+			return nil
+		}
+		key = key[len(c.prefix):]
+		n = c
+	}
+	return n
+}
+
+// delete removes the key below n, compacting the nodes on the path.
+func (n *prefixNode[T]) delete(key string) bool {
+	i, c := n.child(key[0])
+	if c == nil || !strings.HasPrefix(key, c.prefix) {
+		return false
+	}
+	if rest := key[len(c.prefix):]; rest != "" {
+		if !c.delete(rest) {
+			return false
+		}
+	} else if c.has {
+		var zero T
+		c.val, c.has = zero, false
+	} else {
+		return false
+	}
+	n.compact(i)
+	return true
+}
+
+// deletePrefix removes all keys below n starting with prefix and returns their number.
+func (n *prefixNode[T]) deletePrefix(prefix string) (cnt int) {
+	i, c := n.child(prefix[0])
+	switch {
+	case c == nil:
+	case strings.HasPrefix(c.prefix, prefix):
+		c.walk(c.prefix, func(string, T) bool { cnt++; return true })
+		n.children = append(n.children[:i], n.children[i+1:]...)
+	case strings.HasPrefix(prefix, c.prefix):
+		if cnt = c.deletePrefix(prefix[len(c.prefix):]); cnt > 0 {
+			n.compact(i)
+		}
+	}
+	return
+}
+
+// compact removes the i-th child if it is empty, or merges it with its only child.
+func (n *prefixNode[T]) compact(i int) {
+	c := n.children[i]
+	if c.has {
+		return
+	}
+	switch len(c.children) {
+	case 0:
+		n.children = append(n.children[:i], n.children[i+1:]...)
+	case 1:
+		gc := c.children[0]
+		gc.prefix = c.prefix + gc.prefix
+		n.children[i] = gc
+	}
+}
+
+// walk calls fn for n and its descendants in lexicographic order of keys; path is the key of n.
+func (n *prefixNode[T]) walk(path string, fn func(string, T) bool) bool {
+	if n.has && !fn(path, n.val) {
+		return false
+	}
+	for _, c := range n.children {
+		if !c.walk(path+c.prefix, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func commonPrefixLen(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func (m *PrefixMap[T]) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.root = prefixNode[T]{}
+	m.size = 0
+	m.ver++
+}
+
+func (m *PrefixMap[T]) Set(key string, value T) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if m.root.set(key, value) {
+		m.size++
+	}
+	m.ver++
+}
+
+func (m *PrefixMap[T]) Get(key string) T {
+	v, _ := m.Lookup(key)
+	return v
+}
+
+func (m *PrefixMap[T]) Lookup(key string) (value T, ok bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if n := m.root.find(key); n != nil && n.has {
+		return n.val, true
+	}
+	return
+}
+
+func (m *PrefixMap[T]) Exists(key string) bool {
+	_, ok := m.Lookup(key)
+	return ok
+}
+
+// Delete removes the key and reports whether it was present.
+func (m *PrefixMap[T]) Delete(key string) bool {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	var ok bool
+	if key == "" {
+		var zero T
+		ok = m.root.has
+		m.root.val, m.root.has = zero, false
+	} else {
+		ok = m.root.delete(key)
+	}
+	if ok {
+		m.size--
+		m.ver++
+	}
+	return ok
+}
+
+// DeletePrefix removes all keys starting with prefix and returns the number of removed keys.
+func (m *PrefixMap[T]) DeletePrefix(prefix string) int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	var cnt int
+	if prefix == "" {
+		cnt = m.size
+		m.root = prefixNode[T]{}
+	} else {
+		cnt = m.root.deletePrefix(prefix)
+	}
+	if cnt > 0 {
+		m.size -= cnt
+		m.ver++
+	}
+	return cnt
+}
+
+// GetLongestPrefix returns the longest key that is a prefix of s, and its value.
+func (m *PrefixMap[T]) GetLongestPrefix(s string) (key string, value T, ok bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	n, path := &m.root, 0
+	for {
+		if n.has {
+			key, value, ok = s[:path], n.val, true
+		}
+		if path == len(s) {
+			return
+		}
+		_, c := n.child(s[path])
+		if c == nil || !strings.HasPrefix(s[path:], c.prefix) {
+			return
+		}
+		path += len(c.prefix)
+		n = c
+	}
+}
+
+// WalkPrefix calls fn in lexicographic order for each entry whose key starts with prefix.
+// Iteration is done over a snapshot; if fn returns false, WalkPrefix stops the iteration.
+func (m *PrefixMap[T]) WalkPrefix(prefix string, fn func(key string, value T) bool) {
+	var keys []string
+	var vals []T
+	m.mx.RLock()
+	m.walkPrefix(prefix, func(k string, v T) bool {
+		keys, vals = append(keys, k), append(vals, v)
+		return true
+	})
+	m.mx.RUnlock()
+
+	for i, k := range keys {
+		if !fn(k, vals[i]) {
+			return
+		}
+	}
+}
+
+func (m *PrefixMap[T]) walkPrefix(prefix string, fn func(string, T) bool) {
+	n, path := &m.root, ""
+	for rest := prefix; rest != ""; {
+		_, c := n.child(rest[0])
+		switch {
+		case c == nil:
+			return
+		case strings.HasPrefix(c.prefix, rest):
+			c.walk(path+c.prefix, fn)
+			return
+		case !strings.HasPrefix(rest, c.prefix):
+			return
+		}
+		path += c.prefix
+		rest = rest[len(c.prefix):]
+		n = c
+	}
+	n.walk(path, fn)
+}
+
+func (m *PrefixMap[T]) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.size
+}
+
+func (m *PrefixMap[T]) Version() uint64 {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.ver
+}
+
+// Keys returns all keys in lexicographic order.
+func (m *PrefixMap[T]) Keys() []string {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	keys := make([]string, 0, m.size)
+	m.root.walk("", func(k string, _ T) bool {
+		keys = append(keys, k)
+		return true
+	})
+	return keys
+}
@@ -0,0 +1,44 @@
+package xsync
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPrefixMap(t *testing.T) {
+	var m PrefixMap[int]
+	for i, k := range []string{"/api/users", "/api", "/api/user", "/static", "/", "/api/users/admin", ""} {
+		m.Set(k, i)
+	}
+	m.Set("/api", 10)
+	require(t, 7 == m.Len())
+	require(t, 10 == m.Get("/api") && 2 == m.Get("/api/user") && 6 == m.Get(""))
+	require(t, !m.Exists("/ap") && !m.Exists("/api/u") && !m.Exists("/api/usersx"))
+	require(t, slices.Equal([]string{"", "/", "/api", "/api/user", "/api/users", "/api/users/admin", "/static"}, m.Keys()))
+
+	k, v, ok := m.GetLongestPrefix("/api/users/42")
+	require(t, ok && "/api/users" == k && 0 == v)
+	k, _, ok = m.GetLongestPrefix("/other")
+	require(t, ok && "/" == k)
+	k, _, ok = m.GetLongestPrefix("/api")
+	require(t, ok && "/api" == k)
+
+	var keys []string
+	m.WalkPrefix("/api/u", func(k string, _ int) bool { keys = append(keys, k); return true })
+	require(t, slices.Equal([]string{"/api/user", "/api/users", "/api/users/admin"}, keys))
+	keys = nil
+	m.WalkPrefix("/api/", func(k string, _ int) bool { keys = append(keys, k); return len(keys) < 2 })
+	require(t, slices.Equal([]string{"/api/user", "/api/users"}, keys))
+	m.WalkPrefix("/x", func(string, int) bool { t.Fatal("unexpected"); return true })
+
+	require(t, m.Delete("/api/user") && !m.Delete("/api/user") && !m.Delete("/ap"))
+	require(t, m.Exists("/api/users") && 6 == m.Len())
+
+	require(t, 2 == m.DeletePrefix("/api/u"))
+	require(t, 0 == m.DeletePrefix("/api/u"))
+	require(t, slices.Equal([]string{"", "/", "/api", "/static"}, m.Keys()))
+	require(t, m.Delete("") && 3 == m.Len())
+	require(t, 3 == m.DeletePrefix("") && 0 == m.Len())
+	_, _, ok = m.GetLongestPrefix("/api")
+	require(t, !ok)
+}
@@ -0,0 +1,135 @@
+package xsync
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// WithRandSource makes Random, RandomN, Pop, TryPop, the weighted samplers and EvictRandom
+// draw from src instead of the global math/rand source, e.g. a crypto-backed source.
+// src does not need to be safe for concurrent use.
+//
+// Keys are indexed in sorted order, so that for unsharded containers the selection depends only on
+// the source and the sequence of operations; a sharded Set assigns keys to shards with a random hash seed.
+func WithRandSource(src rand.Source) Option {
+	return func(o *options) {
+		o.rand = &randSource{r: rand.New(src)}
+	}
+}
+
+// WithRandSeed makes random selection reproducible: it is WithRandSource(rand.NewSource(seed)).
+func WithRandSeed(seed int64) Option {
+	return WithRandSource(rand.NewSource(seed))
+}
+
+// randSource is a mutex-guarded rand.Rand. A nil *randSource uses the global source.
+type randSource struct {
+	mx sync.Mutex
+	r  *rand.Rand
+}
+
+func (s *randSource) intn(n int) int {
+	if s == nil {
+		return rand.Intn(n)
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.r.Intn(n)
+}
+
+func (s *randSource) float64() float64 {
+	if s == nil {
+		return rand.Float64()
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.r.Float64()
+}
+
+func (s *randSource) shuffle(n int, swap func(i, j int)) {
+	if s == nil {
+		rand.Shuffle(n, swap)
+		return
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.r.Shuffle(n, swap)
+}
+
+// keyIndex is a dense index of map keys that allows O(1) random selection.
+type keyIndex[K comparable] struct {
+	keys []K
+	pos  map[K]int
+	rnd  *randSource
+}
+
+// newKeyIndex indexes the keys of vals. With a configured source the keys are indexed in sorted order.
+func newKeyIndex[K comparable, T any](vals map[K]T, rnd *randSource) *keyIndex[K] {
+	x := &keyIndex[K]{
+		keys: mapKeys(vals),
+		pos:  make(map[K]int, len(vals)),
+		rnd:  rnd,
+	}
+	if rnd != nil {
+		sortKeys(x.keys)
+	}
+	for i, k := range x.keys {
+		x.pos[k] = i
+	}
+	return x
+}
+
+func (x *keyIndex[K]) add(key K) {
+	if _, ok := x.pos[key]; !ok {
+		x.pos[key] = len(x.keys)
+		x.keys = append(x.keys, key)
+	}
+}
+
+func (x *keyIndex[K]) remove(key K) {
+	i, ok := x.pos[key]
+	if !ok {
+		return
+	}
+	last := len(x.keys) - 1
+	if i != last {
+		x.keys[i] = x.keys[last]
+		x.pos[x.keys[i]] = i
+	}
+	var zero K
+	x.keys[last] = zero
+	x.keys = x.keys[:last]
+	delete(x.pos, key)
+}
+
+func (x *keyIndex[K]) random() (key K) {
+	if len(x.keys) > 0 {
+		key = x.keys[x.rnd.intn(len(x.keys))]
+	}
+	return
+}
+
+// sample returns min(n, len) distinct random keys (Floyd's algorithm).
+func (x *keyIndex[K]) sample(n int) []K {
+	cnt := len(x.keys)
+	if n >= cnt {
+		res := make([]K, cnt)
+		copy(res, x.keys)
+		x.rnd.shuffle(cnt, func(i, j int) { res[i], res[j] = res[j], res[i] })
+		return res
+	}
+	if n <= 0 {
+		return nil
+	}
+	res := make([]K, 0, n)
+	seen := make(map[int]struct{}, n)
+	for j := cnt - n; j < cnt; j++ {
+		i := x.rnd.intn(j + 1)
+		if _, ok := seen[i]; ok {
+			i = j
+		}
+		seen[i] = struct{}{}
+		res = append(res, x.keys[i])
+	}
+	return res
+}
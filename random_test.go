@@ -0,0 +1,44 @@
+package xsync
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestWithRandSeed(t *testing.T) {
+	draw := func() (res []int) {
+		vals := map[int]int{}
+		for i := 0; i < 100; i++ {
+			vals[i] = i
+		}
+		m := NewMapPtr(vals, WithRandSeed(42))
+		for i := 0; i < 10; i++ {
+			k, _ := m.Random()
+			res = append(res, k)
+		}
+		for i := 0; i < 10; i++ {
+			k, _ := m.Pop()
+			res = append(res, k)
+		}
+		k, _ := RandomByValue(m)
+		w, _ := m.RandomWeighted(func(k, v int) float64 { return float64(v) })
+		return append(res, k, w)
+	}
+	require(t, slices.Equal(draw(), draw()))
+
+	s1 := NewSetPtr([]string{"a", "b", "c", "d", "e"}, WithRandSeed(1))
+	s2 := NewSetPtr([]string{"e", "d", "c", "b", "a"}, WithRandSeed(1))
+	require(t, slices.Equal(s1.RandomN(5), s2.RandomN(5)))
+	require(t, s1.Pop() == s2.Pop())
+}
+
+func TestWithRandSource(t *testing.T) {
+	m := NewMapPtr(map[int]int{1: 1, 2: 2, 3: 3}, WithRandSource(rand.NewSource(7)), WithMaxEntries(3, EvictRandom))
+	m.Set(4, 4)
+	require(t, m.Len() == 3)
+	for m.Len() > 0 {
+		k, v, ok := m.TryPop()
+		require(t, ok && k == v)
+	}
+}
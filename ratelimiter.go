@@ -0,0 +1,284 @@
+package xsync
+
+import (
+	"context"
+	"hash/maphash"
+	"math"
+	"sync"
+	"time"
+)
+
+// A RateLimiter is a token bucket: tokens are added at rate per second up to burst,
+// and every event takes one token.
+//
+// A RateLimiter is safe for use by multiple goroutines simultaneously.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+	clock Clock
+
+	mx     sync.Mutex
+	tokens float64
+	last   time.Time // time of the last advance
+	used   time.Time // time of the last token taken
+}
+
+// NewRateLimiter returns a RateLimiter allowing rate events per second with bursts of up to burst events.
+// The bucket is initially full. rate must be positive.
+func NewRateLimiter(rate float64, burst int, opts ...ClockOption) *RateLimiter {
+	return newRateLimiter(rate, burst, newClock(opts))
+}
+
+func newRateLimiter(rate float64, burst int, clock Clock) *RateLimiter {
+	return &RateLimiter{rate: rate, burst: float64(burst), clock: clock, tokens: float64(burst), last: clock.Now()}
+}
+
+// advance adds tokens accumulated since the last call. l.mx must be held.
+func (l *RateLimiter) advance(now time.Time) {
+	if now.After(l.last) {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+	}
+}
+
+// Allow takes a token and reports whether it was available.
+func (l *RateLimiter) Allow() bool {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.used = l.clock.Now()
+	l.advance(l.used)
+	if l.tokens >= 1 {
+		l.tokens--
+		return true
+	}
+	return false
+}
+
+// Reserve takes a token, possibly in advance, and returns how long to wait before the event may happen.
+func (l *RateLimiter) Reserve() time.Duration {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.used = l.clock.Now()
+	l.advance(l.used)
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Wait blocks until a token is available or ctx is done, in which case the token is returned
+// and Wait returns ctx.Err().
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	d := l.Reserve()
+	if d == 0 {
+		return nil
+	}
+	t := l.clock.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		l.mx.Lock()
+		l.tokens++
+		l.mx.Unlock()
+		return ctx.Err()
+	}
+}
+
+// idle reports whether the bucket is full and unused since before t.
+func (l *RateLimiter) idle(t time.Time) bool {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.advance(l.clock.Now())
+	return l.used.Before(t) && l.tokens >= l.burst
+}
+
+// A SlidingWindowLimiter allows up to limit events in any sliding window of the given duration.
+// It approximates the sliding window by weighting the count of the previous fixed window
+// with the part of it still inside the sliding window, so it takes constant memory.
+// Unlike a RateLimiter it does not allow bursts above limit at the start of a window.
+//
+// A SlidingWindowLimiter is safe for use by multiple goroutines simultaneously.
+type SlidingWindowLimiter struct {
+	limit  float64
+	window time.Duration
+	clock  Clock
+
+	mx        sync.Mutex
+	start     time.Time // start of the current fixed window
+	cur, prev float64   // event counts of the current and previous fixed windows
+}
+
+// NewSlidingWindowLimiter returns a SlidingWindowLimiter allowing limit events per window. window must be positive.
+func NewSlidingWindowLimiter(limit int, window time.Duration, opts ...ClockOption) *SlidingWindowLimiter {
+	if window <= 0 {
+		panic("xsync: non-positive SlidingWindowLimiter window")
+	}
+	clock := newClock(opts)
+	return &SlidingWindowLimiter{limit: float64(limit), window: window, clock: clock, start: clock.Now()}
+}
+
+// advance moves the fixed windows to now and returns the elapsed fraction of the current one. l.mx must be held.
+func (l *SlidingWindowLimiter) advance(now time.Time) float64 {
+	if n := now.Sub(l.start) / l.window; n > 0 {
+		if n == 1 {
+			l.prev = l.cur
+		} else {
+			l.prev = 0
+		}
+		l.cur = 0
+		l.start = l.start.Add(n * l.window)
+	}
+	return float64(now.Sub(l.start)) / float64(l.window)
+}
+
+// Allow records an event and reports whether it is within the limit. Rejected events are not counted.
+func (l *SlidingWindowLimiter) Allow() bool {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	f := l.advance(l.clock.Now())
+	if l.prev*(1-f)+l.cur+1 > l.limit {
+		return false
+	}
+	l.cur++
+	return true
+}
+
+// delay returns the time until an event is allowed. l.mx must be held.
+func (l *SlidingWindowLimiter) delay() time.Duration {
+	f := l.advance(l.clock.Now())
+	room := l.limit - 1 - l.cur // events allowed in the current window if prev no longer counted
+	var target float64          // the elapsed fraction at which the event fits
+	switch {
+	case l.limit < 1:
+		return l.window // never allowed; poll once per window
+	case room >= 0 && l.prev > 0:
+		target = 1 - room/l.prev
+	default: // the current window is full: wait until its count decays in the next one
+		target = 1 + max(0, 1-(l.limit-1)/l.cur)
+	}
+	return max(time.Duration(math.Ceil((target-f)*float64(l.window))), 1)
+}
+
+// Wait blocks until an event is allowed and records it, or until ctx is done.
+func (l *SlidingWindowLimiter) Wait(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if l.Allow() {
+			return nil
+		}
+		l.mx.Lock()
+		d := l.delay()
+		l.mx.Unlock()
+
+		t := l.clock.NewTimer(d)
+		select {
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+const keyedLimiterShards = 16
+
+// A KeyedLimiter maintains a RateLimiter per key.
+// Limiters idle for the idle duration are removed, so memory stays proportional to the number of active keys.
+//
+// A KeyedLimiter is safe for use by multiple goroutines simultaneously.
+type KeyedLimiter[K comparable] struct {
+	rate  float64
+	burst int
+	idle  time.Duration
+	seed  maphash.Seed
+	clock Clock
+
+	shards [keyedLimiterShards]limiterShard[K]
+}
+
+type limiterShard[K comparable] struct {
+	mx       sync.Mutex
+	limiters map[K]*RateLimiter
+	cleaned  time.Time
+}
+
+// cleanup removes limiters idle since before t. s.mx must be held.
+func (s *limiterShard[K]) cleanup(now, t time.Time) {
+	s.cleaned = now
+	for k, rl := range s.limiters {
+		if rl.idle(t) {
+			delete(s.limiters, k)
+		}
+	}
+}
+
+// NewKeyedLimiter returns a KeyedLimiter with limiters of the given rate and burst.
+// A limiter is removed when its bucket is full and it was not used for the idle duration.
+func NewKeyedLimiter[K comparable](rate float64, burst int, idle time.Duration, opts ...ClockOption) *KeyedLimiter[K] {
+	l := &KeyedLimiter[K]{rate: rate, burst: burst, idle: idle, seed: maphash.MakeSeed(), clock: newClock(opts)}
+	for i := range l.shards {
+		l.shards[i].limiters = map[K]*RateLimiter{}
+		l.shards[i].cleaned = l.clock.Now()
+	}
+	return l
+}
+
+// Limiter returns the limiter of the key, creating it if necessary.
+func (l *KeyedLimiter[K]) Limiter(key K) *RateLimiter {
+	s := &l.shards[hashKey(l.seed, key)%keyedLimiterShards]
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := l.clock.Now()
+	if l.idle > 0 && now.Sub(s.cleaned) >= l.idle {
+		s.cleanup(now, now.Add(-l.idle))
+	}
+	rl, ok := s.limiters[key]
+	if !ok {
+		rl = newRateLimiter(l.rate, l.burst, l.clock)
+		s.limiters[key] = rl
+	}
+	return rl
+}
+
+// Allow takes a token of the key and reports whether it was available.
+func (l *KeyedLimiter[K]) Allow(key K) bool {
+	return l.Limiter(key).Allow()
+}
+
+// Reserve takes a token of the key and returns how long to wait before the event may happen.
+func (l *KeyedLimiter[K]) Reserve(key K) time.Duration {
+	return l.Limiter(key).Reserve()
+}
+
+// Wait blocks until a token of the key is available or ctx is done.
+func (l *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+	return l.Limiter(key).Wait(ctx)
+}
+
+// Cleanup removes idle limiters of all keys. It is also done gradually by Limiter calls.
+func (l *KeyedLimiter[K]) Cleanup() {
+	now := l.clock.Now()
+	for i := range l.shards {
+		s := &l.shards[i]
+		s.mx.Lock()
+		s.cleanup(now, now.Add(-l.idle))
+		s.mx.Unlock()
+	}
+}
+
+// Len returns the number of tracked keys.
+func (l *KeyedLimiter[K]) Len() (n int) {
+	for i := range l.shards {
+		s := &l.shards[i]
+		s.mx.Lock()
+		n += len(s.limiters)
+		s.mx.Unlock()
+	}
+	return
+}
@@ -0,0 +1,68 @@
+package xsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(100, 2)
+	require(t, l.Allow() && l.Allow())
+	require(t, !l.Allow())
+
+	d := l.Reserve()
+	require(t, d > 0 && d <= 10*time.Millisecond)
+
+	start := time.Now()
+	require(t, nil == l.Wait(context.Background()))
+	require(t, time.Since(start) >= 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require(t, context.Canceled == NewRateLimiter(1, 0).Wait(ctx))
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	l := NewKeyedLimiter[string](1000, 1, 5*time.Millisecond)
+	require(t, l.Allow("a") && !l.Allow("a"))
+	require(t, l.Allow("b"))
+	require(t, 2 == l.Len())
+
+	time.Sleep(10 * time.Millisecond)
+	require(t, l.Allow("a"))
+	l.Cleanup()
+	require(t, 1 == l.Len())
+}
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	l := NewSlidingWindowLimiter(10, time.Second, WithClock(clock))
+	for i := 0; i < 10; i++ {
+		require(t, l.Allow())
+	}
+	require(t, !l.Allow())
+
+	clock.Advance(time.Second) // the previous window still counts fully
+	require(t, !l.Allow())
+	clock.Advance(500 * time.Millisecond) // half of it slid out
+	for i := 0; i < 5; i++ {
+		require(t, l.Allow())
+	}
+	require(t, !l.Allow())
+
+	clock.Advance(2 * time.Second)
+	require(t, l.Allow())
+
+	done := make(chan error)
+	full := NewSlidingWindowLimiter(1, time.Second, WithClock(clock))
+	full.Allow()
+	go func() { done <- full.Wait(context.Background()) }()
+	clock.WaitTimers(1)
+	clock.Advance(2 * time.Second)
+	require(t, nil == <-done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require(t, context.Canceled == full.Wait(ctx))
+}
@@ -0,0 +1,134 @@
+package xsync
+
+import (
+	"encoding/json"
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// A ReadMostlyMap is a map optimized for rare writes and frequent reads, e.g. configuration or routing tables.
+// Reads are served from an immutable snapshot without locking.
+// Writes copy the whole map under a mutex and atomically publish the new snapshot, so they are O(n).
+//
+// A zero ReadMostlyMap is empty and ready to use. It is safe for use by multiple goroutines simultaneously.
+type ReadMostlyMap[K comparable, T any] struct {
+	mx   sync.Mutex // serializes writers
+	snap atomic.Pointer[readMostlySnapshot[K, T]]
+}
+
+type readMostlySnapshot[K comparable, T any] struct {
+	ver  uint64
+	vals map[K]T
+}
+
+func NewReadMostlyMap[K comparable, T any](values map[K]T) *ReadMostlyMap[K, T] {
+	m := &ReadMostlyMap[K, T]{}
+	m.snap.Store(&readMostlySnapshot[K, T]{vals: maps.Clone(values)})
+	return m
+}
+
+func (m *ReadMostlyMap[K, T]) load() *readMostlySnapshot[K, T] {
+	if s := m.snap.Load(); s != nil {
+		return s
+	}
+	return &readMostlySnapshot[K, T]{}
+}
+
+// Update calls fn with a copy of the map contents and publishes the modified copy as a new snapshot.
+// Use Update to apply several changes with a single copy.
+func (m *ReadMostlyMap[K, T]) Update(fn func(vals map[K]T)) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	s := m.load()
+	vals := make(map[K]T, len(s.vals)+1)
+	maps.Copy(vals, s.vals)
+	fn(vals)
+	m.snap.Store(&readMostlySnapshot[K, T]{ver: s.ver + 1, vals: vals})
+}
+
+func (m *ReadMostlyMap[K, T]) Set(key K, value T) {
+	m.Update(func(vals map[K]T) { vals[key] = value })
+}
+
+func (m *ReadMostlyMap[K, T]) Delete(key K) {
+	m.Update(func(vals map[K]T) { delete(vals, key) })
+}
+
+func (m *ReadMostlyMap[K, T]) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.snap.Store(&readMostlySnapshot[K, T]{ver: m.load().ver + 1})
+}
+
+// Replace publishes a copy of values as the new map contents.
+func (m *ReadMostlyMap[K, T]) Replace(values map[K]T) {
+	vals := maps.Clone(values)
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.snap.Store(&readMostlySnapshot[K, T]{ver: m.load().ver + 1, vals: vals})
+}
+
+func (m *ReadMostlyMap[K, T]) Get(key K) T {
+	return m.load().vals[key]
+}
+
+func (m *ReadMostlyMap[K, T]) Lookup(key K) (v T, ok bool) {
+	v, ok = m.load().vals[key]
+	return
+}
+
+func (m *ReadMostlyMap[K, T]) Exists(key K) bool {
+	_, ok := m.load().vals[key]
+	return ok
+}
+
+func (m *ReadMostlyMap[K, T]) Len() int {
+	return len(m.load().vals)
+}
+
+func (m *ReadMostlyMap[K, T]) Version() uint64 {
+	return m.load().ver
+}
+
+func (m *ReadMostlyMap[K, T]) Keys() []K {
+	return mapKeys(m.load().vals)
+}
+
+func (m *ReadMostlyMap[K, T]) Values() []T {
+	vals := m.load().vals
+	vv := make([]T, 0, len(vals))
+	for _, v := range vals {
+		vv = append(vv, v)
+	}
+	return vv
+}
+
+func (m *ReadMostlyMap[K, T]) KeyValues() map[K]T {
+	return maps.Clone(m.load().vals)
+}
+
+// Range calls fn for each entry of the current snapshot until fn returns false.
+// Concurrent writes do not affect the iteration.
+func (m *ReadMostlyMap[K, T]) Range(fn func(key K, value T) bool) {
+	for k, v := range m.load().vals {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+func (m *ReadMostlyMap[K, T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.load().vals)
+}
+
+func (m *ReadMostlyMap[K, T]) UnmarshalJSON(data []byte) error {
+	var vals map[K]T
+	if err := json.Unmarshal(data, &vals); err != nil {
+		return err
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.snap.Store(&readMostlySnapshot[K, T]{ver: m.load().ver + 1, vals: vals})
+	return nil
+}
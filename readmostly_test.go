@@ -0,0 +1,60 @@
+package xsync
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestReadMostlyMap(t *testing.T) {
+	var m ReadMostlyMap[string, int]
+	require(t, m.Len() == 0 && m.Get("a") == 0 && m.Version() == 0)
+
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Delete("b")
+	require(t, m.Len() == 1 && m.Get("a") == 1 && !m.Exists("b"))
+	require(t, m.Version() == 3)
+
+	kv := m.KeyValues()
+	kv["c"] = 3
+	require(t, !m.Exists("c"))
+
+	m.Update(func(vals map[string]int) {
+		vals["x"], vals["y"] = 10, 20
+	})
+	require(t, m.Len() == 3 && m.Version() == 4)
+
+	b, err := json.Marshal(&m)
+	require(t, err == nil)
+	m2 := NewReadMostlyMap[string, int](nil)
+	require(t, json.Unmarshal(b, m2) == nil)
+	require(t, m2.Len() == 3 && m2.Get("y") == 20)
+
+	m.Clear()
+	require(t, m.Len() == 0)
+}
+
+func TestReadMostlyMap_concurrent(t *testing.T) {
+	m := NewReadMostlyMap(map[int]int{0: 0})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Set(j, j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if v, ok := m.Lookup(j % 100); ok && v != j%100 {
+					t.Error(v)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require(t, m.Len() == 100)
+}
@@ -0,0 +1,174 @@
+package xsync
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// A RefMap holds reference-counted values, such as connections keyed by remote address.
+// Acquire creates a value on first use and shares it between its holders; when the last holder
+// releases it, the value is destroyed, immediately or after an idle grace period set by WithIdleGrace.
+//
+// A RefMap is safe for use by multiple goroutines simultaneously.
+type RefMap[K comparable, T any] struct {
+	opts    refMapOptions
+	destroy func(K, T)
+
+	mx   sync.Mutex
+	vals map[K]*refEntry[T]
+}
+
+type refEntry[T any] struct {
+	refs  int
+	gen   uint64 // incremented by every Acquire, to tell stale idle timers
+	ready chan struct{}
+	val   T
+	err   error
+	timer Timer // pending destruction of an idle value
+}
+
+// A RefMapOption configures a RefMap.
+type RefMapOption func(*refMapOptions)
+
+type refMapOptions struct {
+	grace time.Duration
+	clock Clock
+}
+
+// WithIdleGrace keeps released values for d before destroying them, so that a value acquired again
+// within d is reused.
+func WithIdleGrace(d time.Duration) RefMapOption {
+	return func(o *refMapOptions) {
+		o.grace = d
+	}
+}
+
+// WithRefMapClock sets the clock measuring the idle grace period.
+func WithRefMapClock(c Clock) RefMapOption {
+	return func(o *refMapOptions) {
+		o.clock = c
+	}
+}
+
+var errRefCreatePanic = errors.New("xsync: RefMap create function panicked")
+
+// NewRefMap returns an empty RefMap calling destroy, if not nil, for every value no longer in use.
+func NewRefMap[K comparable, T any](destroy func(K, T), opts ...RefMapOption) *RefMap[K, T] {
+	m := &RefMap[K, T]{destroy: destroy, vals: map[K]*refEntry[T]{}}
+	for _, fn := range opts {
+		fn(&m.opts)
+	}
+	m.opts.clock = clockOrReal(m.opts.clock)
+	return m
+}
+
+// Acquire returns the value of the key, calling create if there is none, and increments its reference count.
+// Concurrent callers for the same key wait for a single create call.
+// If create fails, its error is returned to all of them and nothing is acquired.
+// Every successful Acquire must be followed by a Release.
+func (m *RefMap[K, T]) Acquire(key K, create func() (T, error)) (T, error) {
+	m.mx.Lock()
+	if e := m.vals[key]; e != nil {
+		e.refs++
+		e.gen++
+		if e.timer != nil {
+			e.timer.Stop()
+			e.timer = nil
+		}
+		m.mx.Unlock()
+		<-e.ready
+		return e.val, e.err
+	}
+	e := &refEntry[T]{refs: 1, ready: make(chan struct{}), err: errRefCreatePanic}
+	m.vals[key] = e
+	m.mx.Unlock()
+
+	defer func() {
+		m.mx.Lock()
+		if e.err != nil {
+			delete(m.vals, key)
+		}
+		m.mx.Unlock()
+		close(e.ready)
+	}()
+	e.val, e.err = create()
+	return e.val, e.err
+}
+
+// Release decrements the reference count of the key, destroying its value when the count drops to zero.
+// It panics if the key is not acquired.
+func (m *RefMap[K, T]) Release(key K) {
+	m.mx.Lock()
+	e := m.vals[key]
+	if e == nil || e.refs == 0 {
+		m.mx.Unlock()
+		panic("xsync: release of unacquired key")
+	}
+	if e.refs--; e.refs > 0 {
+		m.mx.Unlock()
+		return
+	}
+	if m.opts.grace > 0 {
+		gen := e.gen
+		e.timer = m.opts.clock.AfterFunc(m.opts.grace, func() { m.expire(key, e, gen) })
+		m.mx.Unlock()
+		return
+	}
+	delete(m.vals, key)
+	m.mx.Unlock()
+	m.destroyVal(key, e.val)
+}
+
+// expire destroys the idle value of e unless it was acquired since the timer started.
+func (m *RefMap[K, T]) expire(key K, e *refEntry[T], gen uint64) {
+	m.mx.Lock()
+	if m.vals[key] != e || e.refs > 0 || e.gen != gen {
+		m.mx.Unlock()
+		return
+	}
+	delete(m.vals, key)
+	m.mx.Unlock()
+	m.destroyVal(key, e.val)
+}
+
+func (m *RefMap[K, T]) destroyVal(key K, v T) {
+	if m.destroy != nil {
+		m.destroy(key, v)
+	}
+}
+
+// Flush destroys all idle values without waiting for their grace period and returns their number.
+func (m *RefMap[K, T]) Flush() int {
+	m.mx.Lock()
+	idle := map[K]T{}
+	for k, e := range m.vals {
+		if e.refs == 0 {
+			e.timer.Stop()
+			delete(m.vals, k)
+			idle[k] = e.val
+		}
+	}
+	m.mx.Unlock()
+	for k, v := range idle {
+		m.destroyVal(k, v)
+	}
+	return len(idle)
+}
+
+// Refs returns the reference count of the key.
+func (m *RefMap[K, T]) Refs(key K) int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if e := m.vals[key]; e != nil {
+		return e.refs
+	}
+	return 0
+}
+
+// Len returns the number of values, including idle ones awaiting destruction.
+func (m *RefMap[K, T]) Len() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return len(m.vals)
+}
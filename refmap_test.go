@@ -0,0 +1,67 @@
+package xsync
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefMap(t *testing.T) {
+	var created, destroyed atomic.Int32
+	m := NewRefMap[string, int](func(string, int) { destroyed.Add(1) })
+	create := func() (int, error) { return int(created.Add(1)), nil }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := m.Acquire("a", create)
+			require(t, err == nil && v == 1)
+		}()
+	}
+	wg.Wait()
+	require(t, created.Load() == 1 && m.Refs("a") == 10)
+
+	for i := 0; i < 9; i++ {
+		m.Release("a")
+	}
+	require(t, destroyed.Load() == 0 && m.Len() == 1)
+	m.Release("a")
+	require(t, destroyed.Load() == 1 && m.Len() == 0)
+
+	v, _ := m.Acquire("a", create)
+	require(t, v == 2)
+
+	_, err := m.Acquire("b", func() (int, error) { return 0, ErrStopped })
+	require(t, errors.Is(err, ErrStopped) && m.Len() == 1)
+
+	defer func() { require(t, recover() != nil) }()
+	m.Release("b")
+}
+
+func TestRefMap_grace(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	var destroyed []int
+	m := NewRefMap[string, int](func(_ string, v int) { destroyed = append(destroyed, v) },
+		WithIdleGrace(time.Minute), WithRefMapClock(clock))
+	n := 0
+	create := func() (int, error) { n++; return n, nil }
+
+	m.Acquire("a", create)
+	m.Release("a")
+	clock.Advance(30 * time.Second)
+	v, _ := m.Acquire("a", create) // reused within the grace period
+	require(t, v == 1 && clock.Timers() == 0)
+	m.Release("a")
+	clock.Advance(30 * time.Second)
+	require(t, len(destroyed) == 0 && m.Len() == 1)
+	clock.Advance(30 * time.Second)
+	require(t, len(destroyed) == 1 && m.Len() == 0)
+
+	m.Acquire("b", create)
+	m.Release("b")
+	require(t, m.Flush() == 1 && clock.Timers() == 0 && len(destroyed) == 2)
+}
@@ -0,0 +1,324 @@
+package xsync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A ResourcePool keeps a bounded set of reusable resources, such as network connections.
+// Resources are created on demand up to the maximum size; released ones stay idle for reuse,
+// most recently used first. Optionally the pool keeps a minimum number of resources, closes
+// resources idle for too long and periodically checks the health of idle ones.
+//
+// A ResourcePool is safe for use by multiple goroutines simultaneously.
+type ResourcePool[T any] struct {
+	opts    resourcePoolOptions
+	create  func(context.Context) (T, error)
+	destroy func(T)
+	check   func(context.Context, T) error
+	sem     *Semaphore // held by resources in use, being created or being checked
+	sched   *Scheduler
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mx     sync.Mutex
+	idle   []poolItem[T] // the most recently released last
+	size   int           // all resources, including the ones being created
+	inUse  int
+	closed bool
+	stats  ResourcePoolStats
+}
+
+type poolItem[T any] struct {
+	val   T
+	since time.Time
+}
+
+// ResourcePoolStats are pool gauges and counters.
+type ResourcePoolStats struct {
+	Size           int    // resources, including the ones being created
+	Idle           int    // resources waiting to be acquired
+	InUse          int    // acquired resources
+	Acquires       uint64 // successful Acquire calls
+	Waits          uint64 // Acquire calls that had to wait for a resource
+	Creates        uint64 // resources created
+	Destroys       uint64 // resources destroyed
+	HealthFailures uint64 // resources failing the health check
+}
+
+// A ResourcePoolOption configures a ResourcePool.
+type ResourcePoolOption func(*resourcePoolOptions)
+
+type resourcePoolOptions struct {
+	min            int
+	idleTimeout    time.Duration
+	healthInterval time.Duration
+	healthCheck    any // func(context.Context, T) error
+	clock          Clock
+}
+
+// WithMinSize makes the pool create resources in the background until it holds at least n of them.
+func WithMinSize(n int) ResourcePoolOption {
+	return func(o *resourcePoolOptions) {
+		o.min = n
+	}
+}
+
+// WithIdleTimeout closes resources idle for about d, keeping the minimum size.
+func WithIdleTimeout(d time.Duration) ResourcePoolOption {
+	return func(o *resourcePoolOptions) {
+		o.idleTimeout = d
+	}
+}
+
+// WithHealthCheck calls check for every idle resource every interval; resources failing the check are destroyed.
+// Its type must match the pool type, otherwise NewResourcePool panics.
+func WithHealthCheck[T any](interval time.Duration, check func(context.Context, T) error) ResourcePoolOption {
+	return func(o *resourcePoolOptions) {
+		o.healthInterval, o.healthCheck = interval, check
+	}
+}
+
+// WithPoolClock sets the clock used for idle timeouts and health checks.
+func WithPoolClock(c Clock) ResourcePoolOption {
+	return func(o *resourcePoolOptions) {
+		o.clock = c
+	}
+}
+
+// NewResourcePool returns a pool of up to maxSize resources made by create.
+// destroy, if not nil, is called for every resource removed from the pool.
+func NewResourcePool[T any](maxSize int, create func(context.Context) (T, error), destroy func(T), opts ...ResourcePoolOption) *ResourcePool[T] {
+	p := &ResourcePool[T]{create: create, destroy: destroy, sem: NewSemaphore(int64(maxSize))}
+	for _, fn := range opts {
+		fn(&p.opts)
+	}
+	p.opts.clock = clockOrReal(p.opts.clock)
+	if fn := p.opts.healthCheck; fn != nil {
+		check, ok := fn.(func(context.Context, T) error)
+		if !ok {
+			panic(fmt.Sprintf("xsync: health check callback type %T does not match the pool", fn))
+		}
+		p.check = check
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.sched = NewScheduler(WithClock(p.opts.clock))
+	if p.opts.idleTimeout > 0 {
+		p.sched.Every(max(p.opts.idleTimeout/2, 1), p.expireIdle)
+	}
+	if p.check != nil && p.opts.healthInterval > 0 {
+		p.sched.Every(p.opts.healthInterval, p.checkHealth)
+	}
+	go p.fill()
+	return p
+}
+
+// Acquire returns an idle resource or creates a new one, blocking while the pool is at its maximum size
+// and all resources are in use. It returns ErrStopped if the pool is closed.
+// The resource must be returned to the pool by Release or Discard.
+func (p *ResourcePool[T]) Acquire(ctx context.Context) (v T, err error) {
+	waited := !p.sem.TryAcquire(1)
+	if waited {
+		p.mx.Lock()
+		p.stats.Waits++
+		p.mx.Unlock()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		defer context.AfterFunc(p.ctx, cancel)()
+		if err = p.sem.Acquire(ctx, 1); err != nil {
+			if p.ctx.Err() != nil {
+				err = ErrStopped
+			}
+			return
+		}
+	}
+
+	p.mx.Lock()
+	if p.closed {
+		p.mx.Unlock()
+		p.sem.Release(1)
+		return v, ErrStopped
+	}
+	if n := len(p.idle); n > 0 {
+		v = p.idle[n-1].val
+		p.idle[n-1] = poolItem[T]{}
+		p.idle = p.idle[:n-1]
+		p.inUse++
+		p.stats.Acquires++
+		p.mx.Unlock()
+		return v, nil
+	}
+	p.size++
+	p.mx.Unlock()
+
+	v, err = p.create(ctx)
+	p.mx.Lock()
+	if err != nil {
+		p.size--
+		p.mx.Unlock()
+		p.sem.Release(1)
+		return v, err
+	}
+	p.stats.Creates++
+	p.stats.Acquires++
+	p.inUse++
+	p.mx.Unlock()
+	return v, nil
+}
+
+// Release returns an acquired resource to the pool. Resources released to a closed pool are destroyed.
+func (p *ResourcePool[T]) Release(v T) {
+	p.mx.Lock()
+	p.inUse--
+	if p.closed {
+		p.size--
+		p.stats.Destroys++
+		p.mx.Unlock()
+		p.destroyRes(v)
+	} else {
+		p.idle = append(p.idle, poolItem[T]{v, p.opts.clock.Now()})
+		p.mx.Unlock()
+	}
+	p.sem.Release(1)
+}
+
+// Discard destroys an acquired resource instead of returning it to the pool, e.g. a broken connection.
+func (p *ResourcePool[T]) Discard(v T) {
+	p.mx.Lock()
+	p.inUse--
+	p.size--
+	p.stats.Destroys++
+	p.mx.Unlock()
+	p.destroyRes(v)
+	p.sem.Release(1)
+	p.fill()
+}
+
+func (p *ResourcePool[T]) destroyRes(v T) {
+	if p.destroy != nil {
+		p.destroy(v)
+	}
+}
+
+// fill creates idle resources until the pool has the minimum size.
+func (p *ResourcePool[T]) fill() {
+	for {
+		p.mx.Lock()
+		if p.closed || p.size >= p.opts.min || !p.sem.TryAcquire(1) {
+			p.mx.Unlock()
+			return
+		}
+		p.size++
+		p.mx.Unlock()
+
+		v, err := p.create(p.ctx)
+		p.mx.Lock()
+		switch {
+		case err != nil:
+			p.size--
+		case p.closed:
+			p.size--
+			p.stats.Creates++
+			p.stats.Destroys++
+		default:
+			p.stats.Creates++
+			p.idle = append(p.idle, poolItem[T]{v, p.opts.clock.Now()})
+		}
+		closed := p.closed
+		p.mx.Unlock()
+		p.sem.Release(1)
+		if err != nil {
+			return
+		}
+		if closed {
+			p.destroyRes(v)
+			return
+		}
+	}
+}
+
+// expireIdle destroys resources idle for longer than the idle timeout, oldest first.
+func (p *ResourcePool[T]) expireIdle() {
+	p.mx.Lock()
+	now := p.opts.clock.Now()
+	n := 0
+	for n < len(p.idle) && p.size > p.opts.min && now.Sub(p.idle[n].since) >= p.opts.idleTimeout {
+		p.size--
+		n++
+	}
+	expired := make([]poolItem[T], n)
+	copy(expired, p.idle)
+	p.idle = append(p.idle[:0], p.idle[n:]...)
+	p.stats.Destroys += uint64(n)
+	p.mx.Unlock()
+	for _, it := range expired {
+		p.destroyRes(it.val)
+	}
+}
+
+// checkHealth checks the idle resources, destroying the failing ones.
+func (p *ResourcePool[T]) checkHealth() {
+	p.mx.Lock()
+	var items []poolItem[T]
+	for len(p.idle) > 0 && p.sem.TryAcquire(1) {
+		n := len(p.idle)
+		items = append(items, p.idle[n-1])
+		p.idle[n-1] = poolItem[T]{}
+		p.idle = p.idle[:n-1]
+	}
+	p.mx.Unlock()
+
+	for _, it := range items {
+		err := p.check(p.ctx, it.val)
+		p.mx.Lock()
+		drop := err != nil || p.closed
+		if drop {
+			p.size--
+			p.stats.Destroys++
+			if err != nil {
+				p.stats.HealthFailures++
+			}
+		} else {
+			p.idle = append(p.idle, it)
+		}
+		p.mx.Unlock()
+		if drop {
+			p.destroyRes(it.val)
+		}
+		p.sem.Release(1)
+	}
+	p.fill()
+}
+
+// Stats returns the current pool statistics.
+func (p *ResourcePool[T]) Stats() ResourcePoolStats {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	s := p.stats
+	s.Size, s.Idle, s.InUse = p.size, len(p.idle), p.inUse
+	return s
+}
+
+// Close destroys the idle resources and stops background work. Resources in use are destroyed
+// when released; pending and later Acquire calls return ErrStopped.
+func (p *ResourcePool[T]) Close() {
+	p.mx.Lock()
+	if p.closed {
+		p.mx.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.size -= len(idle)
+	p.stats.Destroys += uint64(len(idle))
+	p.mx.Unlock()
+
+	p.cancel()
+	p.sched.Stop()
+	for _, it := range idle {
+		p.destroyRes(it.val)
+	}
+}
@@ -0,0 +1,99 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitPoolStats[T any](t *testing.T, p *ResourcePool[T], cond func(ResourcePoolStats) bool) {
+	t.Helper()
+	for i := 0; i < 1000 && !cond(p.Stats()); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	require(t, cond(p.Stats()))
+}
+
+func TestResourcePool(t *testing.T) {
+	var n, destroyed atomic.Int32
+	create := func(context.Context) (int, error) { return int(n.Add(1)), nil }
+	p := NewResourcePool(2, create, func(int) { destroyed.Add(1) })
+	ctx := context.Background()
+
+	a, err := p.Acquire(ctx)
+	require(t, err == nil && a == 1)
+	b, _ := p.Acquire(ctx)
+	require(t, b == 2)
+
+	ctx2, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = p.Acquire(ctx2) // the pool is exhausted
+	require(t, errors.Is(err, context.DeadlineExceeded))
+
+	go p.Release(b)
+	c, err := p.Acquire(ctx)
+	require(t, err == nil && c == 2) // reused
+
+	p.Discard(c)
+	s := p.Stats()
+	require(t, s.Size == 1 && s.InUse == 1 && s.Creates == 2 && s.Destroys == 1 && s.Acquires == 3 && s.Waits == 2)
+
+	p.Close()
+	_, err = p.Acquire(ctx)
+	require(t, err == ErrStopped)
+	p.Release(a)
+	require(t, destroyed.Load() == 2 && p.Stats().Size == 0)
+}
+
+func TestResourcePool_minSize(t *testing.T) {
+	var n atomic.Int32
+	create := func(context.Context) (int, error) { return int(n.Add(1)), nil }
+	clock := NewFakeClock(time.Now())
+	p := NewResourcePool(5, create, nil, WithMinSize(2), WithIdleTimeout(time.Minute), WithPoolClock(clock))
+	defer p.Close()
+	waitPoolStats(t, p, func(s ResourcePoolStats) bool { return s.Idle == 2 })
+
+	var res []int
+	for i := 0; i < 4; i++ {
+		v, _ := p.Acquire(context.Background())
+		res = append(res, v)
+	}
+	for _, v := range res {
+		p.Release(v)
+	}
+	require(t, p.Stats().Idle == 4)
+
+	clock.WaitTimers(1)
+	clock.Advance(time.Minute)
+	waitPoolStats(t, p, func(s ResourcePoolStats) bool { return s.Idle == 2 && s.Destroys == 2 })
+
+	NewResourcePool(1, create, nil, WithIdleTimeout(time.Nanosecond), WithPoolClock(clock)).Close()
+}
+
+func TestResourcePool_healthCheck(t *testing.T) {
+	var n atomic.Int32
+	create := func(context.Context) (int, error) { return int(n.Add(1)), nil }
+	check := func(_ context.Context, v int) error {
+		if v%2 == 0 {
+			return ErrCorrupt
+		}
+		return nil
+	}
+	clock := NewFakeClock(time.Now())
+	p := NewResourcePool(5, create, nil, WithHealthCheck(time.Second, check), WithPoolClock(clock))
+	defer p.Close()
+
+	a, _ := p.Acquire(context.Background())
+	b, _ := p.Acquire(context.Background())
+	p.Release(a)
+	p.Release(b)
+
+	clock.WaitTimers(1)
+	clock.Advance(time.Second)
+	waitPoolStats(t, p, func(s ResourcePoolStats) bool { return s.HealthFailures == 1 && s.Idle == 1 })
+
+	defer func() { require(t, recover() != nil) }()
+	NewResourcePool(1, create, nil, WithHealthCheck(time.Second, func(context.Context, string) error { return nil }))
+}
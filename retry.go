@@ -0,0 +1,83 @@
+package xsync
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// A RetryPolicy configures Retry. The zero value retries every error forever,
+// with delays doubling from 100ms up to 1 minute and full jitter.
+type RetryPolicy struct {
+	MaxAttempts  int           // the number of calls; 0 means unlimited
+	InitialDelay time.Duration // the delay before the second call; 100ms if 0
+	MaxDelay     time.Duration // the upper bound of delays; 1 minute if 0
+	Multiplier   float64       // the growth factor of delays; 2 if less than 1
+
+	// Jitter is the fraction of each delay that is randomized: a delay d becomes a uniformly random
+	// duration in [d*(1-Jitter), d]. 0 means full jitter (1); use a negative value to disable jitter.
+	Jitter float64
+
+	// Retryable reports whether an error is worth retrying. If nil, all errors are retried.
+	Retryable func(error) bool
+
+	// Clock measures the delays; RealClock if nil.
+	Clock Clock
+}
+
+// Delay returns the delay after the given failed attempt, numbered from 1, before jitter.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d, maxDelay, mul := p.InitialDelay, p.MaxDelay, p.Multiplier
+	if d <= 0 {
+		d = 100 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = time.Minute
+	}
+	if mul < 1 {
+		mul = 2
+	}
+	f := float64(d)
+	for i := 1; i < attempt && f < float64(maxDelay); i++ {
+		f *= mul
+	}
+	return time.Duration(min(f, float64(maxDelay)))
+}
+
+// jittered returns a random delay in [d*(1-jitter), d].
+func (p RetryPolicy) jittered(d time.Duration) time.Duration {
+	j := p.Jitter
+	switch {
+	case j < 0:
+		return d
+	case j == 0 || j > 1:
+		j = 1
+	}
+	return d - time.Duration(j*rand.Float64()*float64(d))
+}
+
+// Retry calls fn until it succeeds, returns an error that is not retryable, the policy runs out
+// of attempts or ctx is done. Between calls it waits with exponential backoff and jitter.
+// It returns nil or the last error of fn; if ctx is done while waiting, the returned error wraps
+// both ctx.Err() and the last error of fn.
+func Retry(ctx context.Context, policy RetryPolicy, fn func(context.Context) error) error {
+	clock := clockOrReal(policy.Clock)
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := fn(ctx)
+		if err == nil || policy.Retryable != nil && !policy.Retryable(err) ||
+			policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return err
+		}
+		t := clock.NewTimer(policy.jittered(policy.Delay(attempt)))
+		select {
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%w (last error: %w)", ctx.Err(), err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{InitialDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 3}
+	require(t, p.Delay(1) == time.Second && p.Delay(2) == 3*time.Second && p.Delay(3) == 9*time.Second)
+	require(t, p.Delay(4) == 10*time.Second && p.Delay(1000) == 10*time.Second)
+	require(t, RetryPolicy{}.Delay(2) == 200*time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		d := RetryPolicy{Jitter: 0.25}.jittered(time.Second)
+		require(t, d >= 750*time.Millisecond && d <= time.Second)
+	}
+	require(t, RetryPolicy{Jitter: -1}.jittered(time.Second) == time.Second)
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond}
+
+	n := 0
+	err := Retry(ctx, policy, func(context.Context) error {
+		if n++; n < 3 {
+			return ErrCorrupt
+		}
+		return nil
+	})
+	require(t, err == nil && n == 3)
+
+	n = 0
+	err = Retry(ctx, policy, func(context.Context) error { n++; return ErrCorrupt })
+	require(t, err == ErrCorrupt && n == 3)
+
+	n = 0
+	policy.Retryable = func(err error) bool { return err != ErrKeyNotFound }
+	err = Retry(ctx, policy, func(context.Context) error { n++; return ErrKeyNotFound })
+	require(t, err == ErrKeyNotFound && n == 1)
+}
+
+func TestRetry_cancel(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		clock.WaitTimers(1)
+		cancel()
+	}()
+	err := Retry(ctx, RetryPolicy{Clock: clock}, func(context.Context) error { return ErrCorrupt })
+	require(t, errors.Is(err, context.Canceled) && errors.Is(err, ErrCorrupt))
+}
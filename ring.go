@@ -0,0 +1,112 @@
+package xsync
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// A Ring is a fixed-capacity buffer keeping the most recent values.
+// By default pushing to a full ring overwrites the oldest value; WithRejectWhenFull makes Push fail instead.
+//
+// A Ring is safe for use by multiple goroutines simultaneously.
+type Ring[T any] struct {
+	opts ringOptions
+
+	mx   sync.Mutex
+	buf  []T
+	head int // index of the oldest value
+	n    int
+}
+
+// A RingOption configures a Ring.
+type RingOption func(*ringOptions)
+
+type ringOptions struct {
+	reject bool
+}
+
+// WithRejectWhenFull makes Push to a full ring return false instead of overwriting the oldest value.
+func WithRejectWhenFull() RingOption {
+	return func(o *ringOptions) {
+		o.reject = true
+	}
+}
+
+// NewRing returns an empty Ring holding up to capacity values. capacity must be positive.
+func NewRing[T any](capacity int, opts ...RingOption) *Ring[T] {
+	if capacity <= 0 {
+		panic("xsync: non-positive Ring capacity")
+	}
+	r := &Ring[T]{buf: make([]T, capacity)}
+	for _, fn := range opts {
+		fn(&r.opts)
+	}
+	return r
+}
+
+// Push appends v and reports whether it was stored.
+// A full ring overwrites its oldest value, or rejects v if created with WithRejectWhenFull.
+func (r *Ring[T]) Push(v T) bool {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if r.n == len(r.buf) {
+		if r.opts.reject {
+			return false
+		}
+		r.buf[r.head] = v
+		r.head = (r.head + 1) % len(r.buf)
+		return true
+	}
+	r.buf[(r.head+r.n)%len(r.buf)] = v
+	r.n++
+	return true
+}
+
+// Pop removes and returns the oldest value; ok is false if the ring is empty.
+func (r *Ring[T]) Pop() (v T, ok bool) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if r.n == 0 {
+		return
+	}
+	var zero T
+	v, r.buf[r.head] = r.buf[r.head], zero
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+	return v, true
+}
+
+// Snapshot returns a copy of the values from the oldest to the newest.
+func (r *Ring[T]) Snapshot() []T {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	res := make([]T, r.n)
+	k := copy(res, r.buf[r.head:min(r.head+r.n, len(r.buf))])
+	copy(res[k:], r.buf[:r.n-k])
+	return res
+}
+
+// Len returns the number of values in the ring.
+func (r *Ring[T]) Len() int {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.n
+}
+
+// Cap returns the capacity of the ring.
+func (r *Ring[T]) Cap() int {
+	return len(r.buf)
+}
+
+// Clear removes all values.
+func (r *Ring[T]) Clear() {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	clear(r.buf)
+	r.head, r.n = 0, 0
+}
+
+// MarshalJSON encodes the ring as a JSON array from the oldest to the newest value.
+func (r *Ring[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Snapshot())
+}
@@ -0,0 +1,50 @@
+package xsync
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := NewRing[int](3)
+	require(t, r.Len() == 0 && r.Cap() == 3 && len(r.Snapshot()) == 0)
+	for i := 1; i <= 5; i++ {
+		require(t, r.Push(i))
+	}
+	require(t, slices.Equal(r.Snapshot(), []int{3, 4, 5}) && r.Len() == 3)
+
+	v, ok := r.Pop()
+	require(t, ok && v == 3)
+	r.Push(6)
+	b, err := json.Marshal(r)
+	require(t, err == nil && string(b) == `[4,5,6]`)
+
+	r.Clear()
+	_, ok = r.Pop()
+	require(t, !ok && r.Len() == 0)
+}
+
+func TestRing_rejectWhenFull(t *testing.T) {
+	r := NewRing[string](2, WithRejectWhenFull())
+	require(t, r.Push("a") && r.Push("b") && !r.Push("c"))
+	require(t, slices.Equal(r.Snapshot(), []string{"a", "b"}))
+}
+
+func TestRing_concurrent(t *testing.T) {
+	r := NewRing[int](100)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				r.Push(i)
+				r.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+	require(t, r.Len() == 100)
+}
@@ -0,0 +1,176 @@
+package xsync
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// A Scheduler runs functions after a delay, at a given time or periodically.
+// Tasks are kept in a heap served by a single goroutine; every run happens in a new goroutine.
+//
+// A Scheduler is safe for use by multiple goroutines simultaneously.
+type Scheduler struct {
+	clock Clock
+
+	mx      sync.Mutex
+	tasks   taskHeap
+	stopped bool
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+// A Task is a function scheduled by a Scheduler.
+type Task struct {
+	s     *Scheduler
+	at    time.Time
+	every time.Duration
+	fn    func()
+	idx   int // index in the heap, -1 if not scheduled
+}
+
+// NewScheduler starts a Scheduler.
+func NewScheduler(opts ...ClockOption) *Scheduler {
+	s := &Scheduler{clock: newClock(opts), wake: make(chan struct{}, 1), done: make(chan struct{})}
+	go s.loop()
+	return s
+}
+
+// After runs fn once after d.
+func (s *Scheduler) After(d time.Duration, fn func()) *Task {
+	return s.schedule(&Task{at: s.clock.Now().Add(d), fn: fn})
+}
+
+// At runs fn once at t.
+func (s *Scheduler) At(t time.Time, fn func()) *Task {
+	return s.schedule(&Task{at: t, fn: fn})
+}
+
+// Every runs fn every d, starting after d. Runs missed by a late scheduler are skipped.
+// d must be positive.
+func (s *Scheduler) Every(d time.Duration, fn func()) *Task {
+	if d <= 0 {
+		panic("xsync: non-positive Scheduler.Every period")
+	}
+	return s.schedule(&Task{at: s.clock.Now().Add(d), every: d, fn: fn})
+}
+
+// schedule adds the task; the task is canceled if the scheduler is stopped.
+func (s *Scheduler) schedule(t *Task) *Task {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	t.s, t.idx = s, -1
+	if s.stopped {
+		return t
+	}
+	heap.Push(&s.tasks, t)
+	if t.idx == 0 {
+		s.notify()
+	}
+	return t
+}
+
+// notify wakes up the loop to recompute the next deadline. s.mx must be held.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Cancel unschedules the task and reports whether it was scheduled.
+// A running function is not interrupted.
+func (t *Task) Cancel() bool {
+	s := t.s
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if t.idx < 0 {
+		return false
+	}
+	heap.Remove(&s.tasks, t.idx)
+	return true
+}
+
+// Len returns the number of scheduled tasks.
+func (s *Scheduler) Len() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return len(s.tasks)
+}
+
+// Stop cancels all tasks and stops the scheduler goroutine. Tasks scheduled later are never run.
+func (s *Scheduler) Stop() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if !s.stopped {
+		s.stopped = true
+		for _, t := range s.tasks {
+			t.idx = -1
+		}
+		s.tasks = nil
+		close(s.done)
+	}
+}
+
+func (s *Scheduler) loop() {
+	timer := s.clock.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		s.mx.Lock()
+		now := s.clock.Now()
+		for len(s.tasks) > 0 && !s.tasks[0].at.After(now) {
+			t := s.tasks[0]
+			go t.fn()
+			if t.every > 0 {
+				t.at = t.at.Add((now.Sub(t.at)/t.every + 1) * t.every)
+				heap.Fix(&s.tasks, 0)
+			} else {
+				heap.Pop(&s.tasks)
+			}
+		}
+		wait := time.Hour
+		if len(s.tasks) > 0 {
+			wait = s.tasks[0].at.Sub(now)
+		}
+		s.mx.Unlock()
+
+		timer.Reset(wait)
+		select {
+		case <-timer.C():
+		case <-s.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C():
+				default:
+				}
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+type taskHeap []*Task
+
+func (h taskHeap) Len() int           { return len(h) }
+func (h taskHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+
+func (h taskHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].idx, h[j].idx = i, j
+}
+
+func (h *taskHeap) Push(x any) {
+	t := x.(*Task)
+	t.idx = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *taskHeap) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	t.idx = -1
+	*h = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,45 @@
+package xsync
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler()
+	defer s.Stop()
+
+	done := make(chan int, 10)
+	s.After(20*time.Millisecond, func() { done <- 2 })
+	s.At(time.Now().Add(10*time.Millisecond), func() { done <- 1 })
+	canceled := s.After(5*time.Millisecond, func() { done <- 0 })
+	require(t, canceled.Cancel() && !canceled.Cancel())
+	require(t, 1 == <-done && 2 == <-done)
+
+	var n atomic.Int32
+	task := s.Every(5*time.Millisecond, func() { n.Add(1) })
+	time.Sleep(28 * time.Millisecond)
+	require(t, task.Cancel())
+	require(t, n.Load() >= 3 && 0 == s.Len())
+
+	s.Stop()
+	task = s.After(time.Millisecond, func() { done <- 3 })
+	require(t, !task.Cancel())
+}
+
+func TestScheduler_Every(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	s := NewScheduler(WithClock(clock))
+	defer s.Stop()
+
+	runs := make(chan struct{}, 10)
+	s.Every(time.Microsecond, func() { runs <- struct{}{} })
+	clock.WaitTimers(1)
+	clock.Advance(1000 * time.Hour) // missed runs are skipped in O(1)
+	<-runs
+	require(t, s.Len() == 1)
+
+	defer func() { require(t, recover() != nil) }()
+	s.Every(0, func() {})
+}
@@ -0,0 +1,142 @@
+package xsync
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// A Semaphore is a weighted semaphore. Waiters are served in FIFO order.
+//
+// A Semaphore is safe for use by multiple goroutines simultaneously.
+type Semaphore struct {
+	mx      sync.Mutex
+	size    int64
+	cur     int64
+	waiters list.List
+}
+
+type semWaiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+func NewSemaphore(n int64) *Semaphore {
+	return &Semaphore{size: n}
+}
+
+// Acquire acquires the semaphore with a weight of n, blocking until resources are available or ctx is done.
+// On failure, returns ctx.Err() and leaves the semaphore unchanged.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	done := ctx.Done()
+
+	s.mx.Lock()
+	select {
+	case <-done:
+		s.mx.Unlock()
+		return ctx.Err()
+	default:
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mx.Unlock()
+		return nil
+	}
+	if n > s.size {
+		// never can be satisfied
+		s.mx.Unlock()
+		<-done
+		return ctx.Err()
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(semWaiter{n: n, ready: ready})
+	s.mx.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-done:
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	select {
+	case <-ready: // acquired concurrently with cancellation
+		return nil
+	default:
+	}
+	isFront := s.waiters.Front() == elem
+	s.waiters.Remove(elem)
+	if isFront && s.size > s.cur {
+		s.notifyWaiters()
+	}
+	return ctx.Err()
+}
+
+// TryAcquire acquires the semaphore with a weight of n without blocking.
+// On success, returns true. On failure, returns false and leaves the semaphore unchanged.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release releases the semaphore with a weight of n.
+func (s *Semaphore) Release(n int64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.cur -= n; s.cur < 0 {
+		panic("xsync: semaphore released more than held")
+	}
+	s.notifyWaiters()
+}
+
+func (s *Semaphore) notifyWaiters() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(semWaiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
+
+// A Limiter bounds the number of concurrently running goroutines.
+type Limiter struct {
+	sem *Semaphore
+	wg  sync.WaitGroup
+}
+
+// Limit returns a Limiter that runs at most n goroutines at a time. It panics if n <= 0.
+func Limit(n int) *Limiter {
+	if n <= 0 {
+		panic("xsync: non-positive Limit")
+	}
+	return &Limiter{sem: NewSemaphore(int64(n))}
+}
+
+// Go calls fn in a new goroutine, blocking until the number of running goroutines is below the limit.
+func (l *Limiter) Go(fn func()) {
+	_ = l.sem.Acquire(context.Background(), 1)
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		defer l.sem.Release(1)
+		fn()
+	}()
+}
+
+// Wait blocks until all goroutines started by Go have returned.
+func (l *Limiter) Wait() {
+	l.wg.Wait()
+}
@@ -0,0 +1,56 @@
+package xsync
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSemaphore_TryAcquire(t *testing.T) {
+	s := NewSemaphore(3)
+
+	require(t, s.TryAcquire(2))
+	require(t, !s.TryAcquire(2))
+	require(t, s.TryAcquire(1))
+	s.Release(3)
+	require(t, s.TryAcquire(3))
+}
+
+func TestSemaphore_Acquire(t *testing.T) {
+	s := NewSemaphore(1)
+	require(t, nil == s.Acquire(context.Background(), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require(t, context.DeadlineExceeded == s.Acquire(ctx, 1))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.Release(1)
+	}()
+	require(t, nil == s.Acquire(context.Background(), 1))
+}
+
+func TestLimit(t *testing.T) {
+	var cur, peak atomic.Int32
+	l := Limit(3)
+
+	for i := 0; i < 20; i++ {
+		l.Go(func() {
+			n := cur.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(time.Millisecond)
+			cur.Add(-1)
+		})
+	}
+	l.Wait()
+
+	require(t, peak.Load() <= 3)
+}
+
+func TestLimit_nonPositive(t *testing.T) {
+	defer func() { require(t, recover() != nil) }()
+	Limit(0)
+}
@@ -0,0 +1,125 @@
+package xsync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// A Sequencer restores the order of results produced out of order: workers Submit results tagged
+// with consecutive sequence numbers, and the consumer receives them from Next strictly in sequence order.
+// At most limit results beyond the next expected one are buffered; Submit blocks for results further ahead.
+//
+// A Sequencer is safe for use by multiple goroutines simultaneously.
+type Sequencer[T any] struct {
+	limit uint64
+
+	mx      sync.Mutex
+	next    uint64
+	pending map[uint64]T
+	closed  bool
+	changed chan struct{} // closed and replaced on every change
+}
+
+// NewSequencer returns a Sequencer expecting sequence numbers from start, buffering up to limit results.
+// A limit <= 0 means no limit.
+func NewSequencer[T any](start uint64, limit int) *Sequencer[T] {
+	s := &Sequencer[T]{next: start, pending: map[uint64]T{}, changed: make(chan struct{})}
+	if limit > 0 {
+		s.limit = uint64(limit)
+	}
+	return s
+}
+
+// notify wakes up waiting goroutines. s.mx must be held.
+func (s *Sequencer[T]) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Submit stores the result with sequence number seq, blocking while seq is limit or more ahead
+// of the next expected number. It returns ErrStopped if the sequencer is closed, ctx.Err() if ctx is done first,
+// and an error if seq was already submitted or consumed.
+func (s *Sequencer[T]) Submit(ctx context.Context, seq uint64, v T) error {
+	s.mx.Lock()
+	for {
+		if s.closed {
+			s.mx.Unlock()
+			return ErrStopped
+		}
+		if _, ok := s.pending[seq]; ok || seq < s.next {
+			s.mx.Unlock()
+			return fmt.Errorf("xsync: sequence number %d already submitted", seq)
+		}
+		if s.limit == 0 || seq-s.next < s.limit {
+			break
+		}
+		ch := s.changed
+		s.mx.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		s.mx.Lock()
+	}
+	s.pending[seq] = v
+	if seq == s.next {
+		s.notify()
+	}
+	s.mx.Unlock()
+	return nil
+}
+
+// Next returns the result with the next sequence number, blocking until it is submitted.
+// It returns ErrStopped if the sequencer is closed and the next result is missing, or ctx.Err() if ctx is done first.
+func (s *Sequencer[T]) Next(ctx context.Context) (T, error) {
+	s.mx.Lock()
+	for {
+		if v, ok := s.pending[s.next]; ok {
+			delete(s.pending, s.next)
+			s.next++
+			s.notify()
+			s.mx.Unlock()
+			return v, nil
+		}
+		if s.closed {
+			s.mx.Unlock()
+			var zero T
+			return zero, ErrStopped
+		}
+		ch := s.changed
+		s.mx.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+		s.mx.Lock()
+	}
+}
+
+// NextSeq returns the next expected sequence number.
+func (s *Sequencer[T]) NextSeq() uint64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.next
+}
+
+// Pending returns the number of buffered results.
+func (s *Sequencer[T]) Pending() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return len(s.pending)
+}
+
+// Close makes Submit return ErrStopped. Results already in sequence remain available to Next.
+func (s *Sequencer[T]) Close() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if !s.closed {
+		s.closed = true
+		s.notify()
+	}
+}
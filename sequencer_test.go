@@ -0,0 +1,48 @@
+package xsync
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSequencer(t *testing.T) {
+	ctx := context.Background()
+	s := NewSequencer[int](1, 4)
+	for _, seq := range rand.Perm(100) {
+		go func() {
+			time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+			if err := s.Submit(ctx, uint64(seq+1), seq+1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	for i := 1; i <= 100; i++ {
+		v, err := s.Next(ctx)
+		require(t, err == nil && v == i)
+		require(t, s.Pending() <= 4)
+	}
+	require(t, s.NextSeq() == 101)
+	require(t, s.Submit(ctx, 50, 0) != nil) // already consumed
+}
+
+func TestSequencer_limit(t *testing.T) {
+	s := NewSequencer[string](0, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require(t, s.Submit(ctx, 1, "b") == nil)
+	require(t, s.Submit(ctx, 1, "b") != nil) // duplicate
+	require(t, s.Submit(ctx, 2, "c") == context.DeadlineExceeded)
+
+	_, err := s.Next(ctx)
+	require(t, err == context.DeadlineExceeded)
+
+	require(t, s.Submit(context.Background(), 0, "a") == nil)
+	s.Close()
+	require(t, s.Submit(context.Background(), 2, "c") == ErrStopped)
+	a, _ := s.Next(context.Background())
+	b, _ := s.Next(context.Background())
+	_, err = s.Next(context.Background())
+	require(t, a == "a" && b == "b" && err == ErrStopped)
+}
@@ -1,20 +1,36 @@
 package xsync
 
 import (
-	"encoding/gob"
+	"bytes"
 	"encoding/json"
+	"hash/maphash"
 	"io"
-	"math/rand"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 )
 
 // A Set is a set of temporary objects that may be individually set, get and deleted.
 //
 // A Set is safe for use by multiple goroutines simultaneously.
+//
+// The zero Set is empty and ready to use; it allocates lazily on the first write
+// and behaves exactly like an empty NewSetPtr in every method, including marshaling.
+// Use Init to configure a Set declared as a value with options.
 type Set[K comparable] struct {
-	mx   sync.RWMutex
-	ver  uint64
-	vals map[K]struct{}
+	ver   uint64 // updated atomically under mx, so it can be read without locking
+	size  int64  // len(vals), updated like ver
+	mx    sync.RWMutex
+	vals  map[K]struct{}
+	opts  options
+	index *keyIndex[K] // built on first Random call
+	out   *outputCache // set by WithCachedOutput
+
+	shards []*Set[K] // set by WithShards; the shards hold the keys instead of vals
+	owner  *Set[K]   // the sharded set a shard belongs to
+	seed   maphash.Seed
+	dirty  bool // mutated under the current lock, see commitParts
 }
 
 func NewSet[K comparable](values []K) Set[K] {
@@ -22,117 +38,544 @@ func NewSet[K comparable](values []K) Set[K] {
 	for _, v := range values {
 		vv[v] = struct{}{}
 	}
-	return Set[K]{vals: vv}
+	return Set[K]{vals: vv, size: int64(len(vv))}
+}
+
+// NewSetPtr returns a pointer to a new Set with the given values, configured by opts.
+func NewSetPtr[K comparable](values []K, opts ...SetOption) *Set[K] {
+	m := &Set[K]{}
+	m.init(values, opts)
+	return m
+}
+
+// Init configures a Set declared as a value, e.g. a struct field, with opts like NewSetPtr does, and returns it.
+// The contents of the set are discarded. Init must be called before the set is shared between goroutines.
+//
+// Calling Init without options is never required: the zero Set is an empty set ready to use,
+// which allocates on the first write and behaves like a set created by NewSetPtr in every method.
+func (m *Set[K]) Init(opts ...SetOption) *Set[K] {
+	discarded := m.Size() > 0
+	ver := m.Version()
+	m.init(nil, opts)
+	if discarded {
+		ver++
+	}
+	atomic.StoreUint64(&m.ver, ver) // the version of a sharded set is the sum of its shards
+	return m
+}
+
+// init applies opts and replaces the contents with values.
+func (m *Set[K]) init(values []K, opts []SetOption) {
+	m.initWith(values, newSetOptions(opts))
+}
+
+// initWith is like init but takes options already applied, e.g. those of another set.
+func (m *Set[K]) initWith(values []K, o options) {
+	m.opts = o
+	m.out = newOutputCache(m.opts)
+	m.shards, m.index = nil, nil
+	if n := m.opts.shards; n > 1 {
+		m.shards, m.seed = make([]*Set[K], n), maphash.MakeSeed()
+		for i := range m.shards {
+			m.shards[i] = &Set[K]{opts: options{capacity: m.opts.capacity / n, rand: m.opts.rand}, owner: m}
+		}
+		m.vals = nil
+		m.publishSize()
+		if len(values) > 0 {
+			m.replace(sliceToMap(values))
+		}
+		return
+	}
+	m.vals = nil
+	if len(values) > 0 || m.opts.capacity > 0 {
+		m.vals = make(map[K]struct{}, max(len(values), m.opts.capacity))
+		for _, v := range values {
+			m.vals[v] = struct{}{}
+		}
+	}
+	m.publishSize()
+}
+
+// shard returns the shard holding the key, or the set itself if it is not sharded.
+func (m *Set[K]) shard(key K) *Set[K] {
+	if m.shards == nil {
+		return m
+	}
+	return m.shards[hashKey(m.seed, key)%uint64(len(m.shards))]
+}
+
+// parts returns the shards of the set, or the set itself if it is not sharded.
+func (m *Set[K]) parts() []*Set[K] {
+	if m.shards == nil {
+		return []*Set[K]{m}
+	}
+	return m.shards
+}
+
+// lock locks all parts of the set in order and returns them.
+func (m *Set[K]) lock() []*Set[K] {
+	parts := m.parts()
+	for _, s := range parts {
+		s.mx.Lock()
+	}
+	return parts
+}
+
+// rlock read-locks all parts of the set in order and returns them.
+func (m *Set[K]) rlock() []*Set[K] {
+	parts := m.parts()
+	for _, s := range parts {
+		s.mx.RLock()
+	}
+	return parts
+}
+
+func unlockParts[K comparable](parts []*Set[K]) {
+	for _, s := range parts {
+		s.mx.Unlock()
+	}
+}
+
+// commitParts commits the dirty parts. The parts must be locked.
+func commitParts[K comparable](parts []*Set[K]) {
+	for _, s := range parts {
+		if s.dirty {
+			s.dirty = false
+			s.commit()
+		}
+	}
+}
+
+func runlockParts[K comparable](parts []*Set[K]) {
+	for _, s := range parts {
+		s.mx.RUnlock()
+	}
 }
 
 func (m *Set[K]) Clear() {
-	m.mx.Lock()
-	defer m.mx.Unlock()
-	m.vals = map[K]struct{}{}
-	m.ver++
+	parts := m.lock()
+	defer unlockParts(parts)
+	for _, s := range parts {
+		s.vals, s.index = nil, nil
+		s.commit()
+	}
+}
+
+// replace replaces the set contents with vals.
+func (m *Set[K]) replace(vals map[K]struct{}) {
+	parts := m.lock()
+	defer unlockParts(parts)
+	if m.shards == nil {
+		m.vals, m.index = vals, nil
+		m.commit()
+		return
+	}
+	for _, s := range parts {
+		s.vals, s.index = make(map[K]struct{}, len(vals)/len(parts)), nil
+	}
+	for k := range vals {
+		m.shard(k).vals[k] = struct{}{}
+	}
+	for _, s := range parts {
+		s.commit()
+	}
+}
+
+// Reserve presizes the set for n more keys, so that a bulk load does not grow the set incrementally.
+func (m *Set[K]) Reserve(n int) {
+	if n <= 0 {
+		return
+	}
+	parts := m.lock()
+	defer unlockParts(parts)
+	for _, s := range parts {
+		vals := make(map[K]struct{}, len(s.vals)+n/len(parts))
+		maps.Copy(vals, s.vals)
+		s.vals = vals
+	}
+}
+
+// Compact reallocates the set to release memory retained after massive deletions (Go maps never shrink).
+func (m *Set[K]) Compact() {
+	parts := m.lock()
+	defer unlockParts(parts)
+	for _, s := range parts {
+		if s.vals != nil {
+			// maps.Clone may keep the capacity of the source map
+			vals := make(map[K]struct{}, len(s.vals))
+			maps.Copy(vals, s.vals)
+			s.vals = vals
+		}
+	}
 }
 
 func (m *Set[K]) Set(key K) {
-	m.mx.Lock()
-	defer m.mx.Unlock()
-	if m.vals == nil {
-		m.vals = map[K]struct{}{}
+	s := m.shard(key)
+	lockMetered(&s.mx, m.opts.metrics)
+	defer s.mx.Unlock()
+	if s.vals == nil {
+		s.vals = make(map[K]struct{}, s.opts.capacity)
+	}
+	added := s.add(key)
+	s.commit()
+	if r := m.opts.metrics; r != nil {
+		r.ReportOp(OpSet, !added)
 	}
-	m.vals[key] = struct{}{}
-	m.ver++
 }
 
 func (m *Set[K]) Delete(key K) {
-	m.mx.Lock()
-	defer m.mx.Unlock()
+	s := m.shard(key)
+	lockMetered(&s.mx, m.opts.metrics)
+	defer s.mx.Unlock()
 
-	if m.vals != nil {
-		delete(m.vals, key)
-		m.ver++
+	if s.vals != nil {
+		deleted := s.del(key)
+		s.commit()
+		if r := m.opts.metrics; r != nil {
+			r.ReportOp(OpDelete, deleted)
+		}
 	}
 }
 
-func (m *Set[K]) Exists(key K) bool {
-	m.mx.RLock()
-	defer m.mx.RUnlock()
+// add adds the key and reports whether it was not present. m.mx must be held and m.vals allocated.
+func (m *Set[K]) add(key K) bool {
+	if _, ok := m.vals[key]; ok {
+		return false
+	}
+	m.vals[key] = struct{}{}
+	if m.index != nil {
+		m.index.add(key)
+	}
+	return true
+}
 
-	if m.vals == nil {
+// del deletes the key and reports whether it was present. m.mx must be held.
+func (m *Set[K]) del(key K) bool {
+	if _, ok := m.vals[key]; !ok {
 		return false
 	}
-	_, ok := m.vals[key]
+	delete(m.vals, key)
+	if m.index != nil {
+		m.index.remove(key)
+	}
+	return true
+}
+
+// AddMany adds keys under a single lock and returns the number of keys that were not present before.
+func (m *Set[K]) AddMany(keys ...K) (n int) {
+	parts := m.lock()
+	defer unlockParts(parts)
+	for _, key := range keys {
+		s := m.shard(key)
+		if s.vals == nil {
+			s.vals = make(map[K]struct{}, max(len(keys)/len(parts), s.opts.capacity))
+		}
+		if s.add(key) {
+			s.dirty = true
+			n++
+		}
+	}
+	commitParts(parts)
+	return
+}
+
+// DeleteMany deletes keys under a single lock and returns the number of keys that were present.
+func (m *Set[K]) DeleteMany(keys ...K) (n int) {
+	parts := m.lock()
+	defer unlockParts(parts)
+	for _, key := range keys {
+		if s := m.shard(key); s.del(key) {
+			s.dirty = true
+			n++
+		}
+	}
+	commitParts(parts)
+	return
+}
+
+// Filter deletes all keys for which fn returns true under a single lock
+// and returns the number of deleted keys.
+func (m *Set[K]) Filter(fn func(key K) bool) (n int) {
+	parts := m.lock()
+	defer unlockParts(parts)
+	for _, s := range parts {
+		for key := range s.vals {
+			if fn(key) {
+				s.del(key)
+				s.dirty = true
+				n++
+			}
+		}
+	}
+	commitParts(parts)
+	return
+}
+
+// ContainsAll reports whether all keys are present.
+func (m *Set[K]) ContainsAll(keys ...K) bool {
+	defer runlockParts(m.rlock())
+	for _, key := range keys {
+		if _, ok := m.shard(key).vals[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny reports whether at least one of keys is present.
+func (m *Set[K]) ContainsAny(keys ...K) bool {
+	defer runlockParts(m.rlock())
+	for _, key := range keys {
+		if _, ok := m.shard(key).vals[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Set[K]) Exists(key K) bool {
+	s := m.shard(key)
+	rlockMetered(&s.mx, m.opts.metrics)
+	defer s.mx.RUnlock()
+
+	_, ok := s.vals[key]
+	if r := m.opts.metrics; r != nil {
+		r.ReportOp(OpGet, ok)
+	}
 	return ok
 }
 
-func (m *Set[K]) Size() int {
-	m.mx.RLock()
-	defer m.mx.RUnlock()
-	return len(m.vals)
+func (m *Set[K]) Size() (n int) {
+	for _, s := range m.shards {
+		n += s.Size()
+	}
+	return n + int(atomic.LoadInt64(&m.size))
 }
 
-func (m *Set[K]) Version() uint64 {
-	m.mx.RLock()
-	defer m.mx.RUnlock()
-	return m.ver
+// Version returns the number of mutations of the set; for a sharded set it is the sum over shards.
+func (m *Set[K]) Version() (v uint64) {
+	for _, s := range m.shards {
+		v += s.Version()
+	}
+	return v + atomic.LoadUint64(&m.ver)
+}
+
+// commit records a mutation: increments the version and publishes the size. m.mx must be held.
+func (m *Set[K]) commit() {
+	atomic.AddUint64(&m.ver, 1)
+	m.publishSize()
+}
+
+// publishSize stores the size for Size and reports the size of the whole set,
+// including the other shards of a sharded set, to the metrics reporter. m.mx must be held.
+func (m *Set[K]) publishSize() {
+	atomic.StoreInt64(&m.size, int64(len(m.vals)))
+	root := m
+	if m.owner != nil {
+		root = m.owner
+	}
+	if r := root.opts.metrics; r != nil {
+		r.ReportSize(root.Size())
+	}
+}
+
+// Clone returns an independent copy of the set with the same options.
+func (m *Set[K]) Clone() *Set[K] {
+	c := &Set[K]{}
+	c.initWith(m.Values(), m.opts)
+	return c
+}
+
+// Equal reports whether m and other contain the same keys.
+func (m *Set[K]) Equal(other *Set[K]) bool {
+	if m == other {
+		return true
+	}
+	keys := other.Values()
+
+	defer runlockParts(m.rlock())
+	if m.Size() != len(keys) {
+		return false
+	}
+	for _, key := range keys {
+		if _, ok := m.shard(key).vals[key]; !ok {
+			return false
+		}
+	}
+	return true
 }
 
 func (m *Set[K]) Values() []K {
-	m.mx.RLock()
-	defer m.mx.RUnlock()
-	return mapKeys(m.vals)
+	defer runlockParts(m.rlock())
+	return m.keys()
+}
+
+// AppendValues appends the keys of the set to dst and returns the extended slice.
+// Reusing dst between calls avoids allocating a new slice for every snapshot.
+func (m *Set[K]) AppendValues(dst []K) []K {
+	parts := m.rlock()
+	defer runlockParts(parts)
+
+	for _, s := range parts {
+		dst = slices.Grow(dst, len(s.vals))
+		for k := range s.vals {
+			dst = append(dst, k)
+		}
+	}
+	return dst
+}
+
+// keys returns all keys of the set. The parts of the set must be locked.
+func (m *Set[K]) keys() []K {
+	if m.shards == nil {
+		return mapKeys(m.vals)
+	}
+	keys := make([]K, 0, m.Size())
+	for _, s := range m.shards {
+		for k := range s.vals {
+			keys = append(keys, k)
+		}
+	}
+	return keys
 }
 
+// String returns the set as a JSON array of sorted keys.
 func (m *Set[K]) String() string {
-	return encString(m.Strings())
+	if m.out != nil {
+		b, _ := cached(&m.out.text, m.Version, m.MarshalJSONSorted)
+		return string(b)
+	}
+	b, _ := m.MarshalJSONSorted()
+	return string(b)
 }
 
+// Strings returns string representations of keys in sorted order.
 func (m *Set[K]) Strings() []string {
-	ss := make([]string, 0, len(m.vals))
-	for k := range m.Values() {
+	keys := m.Values()
+	sortKeys(keys)
+	ss := make([]string, 0, len(keys))
+	for _, k := range keys {
 		ss = append(ss, encString(k))
 	}
 	return ss
 }
 
 func (m *Set[K]) Pop() (key K) {
-	m.mx.Lock()
-	defer m.mx.Unlock()
-	if m.vals != nil {
-		for key = range m.vals {
-			delete(m.vals, key)
-			m.ver++
+	key, _ = m.TryPop()
+	return
+}
+
+// TryPop removes and returns an arbitrary key; ok is false if the set is empty.
+func (m *Set[K]) TryPop() (key K, ok bool) {
+	for _, s := range m.parts() {
+		if key, ok = s.tryPop(); ok {
 			return
 		}
 	}
 	return
 }
 
-func (m *Set[K]) PopAll() (values []K) {
+func (m *Set[K]) tryPop() (key K, ok bool) {
 	m.mx.Lock()
 	defer m.mx.Unlock()
-	values, m.vals = mapKeys(m.vals), nil
-	m.ver++
+	if m.opts.rand != nil && len(m.vals) > 0 {
+		key = m.buildIndex().random()
+		m.del(key)
+		m.commit()
+		return key, true
+	}
+	for key = range m.vals {
+		m.del(key)
+		m.commit()
+		return key, true
+	}
 	return
 }
 
-func (m *Set[K]) Random() (key K) {
-	m.mx.RLock()
-	defer m.mx.RUnlock()
-
-	if cnt := len(m.vals); cnt > 0 {
-		// todo: optimize it!  (add keys slice)
-		n := rand.Intn(cnt)
-		for k := range m.vals {
-			if n == 0 {
-				return k
+// PopN removes and returns up to n arbitrary keys.
+func (m *Set[K]) PopN(n int) []K {
+	parts := m.lock()
+	defer unlockParts(parts)
+	res := make([]K, 0, min(max(n, 0), m.Size()))
+	for _, s := range parts {
+		for key := range s.vals {
+			if len(res) >= n {
+				break
 			}
-			n--
+			res = append(res, key)
+			s.del(key)
+			s.dirty = true
 		}
-		panic(1)
+	}
+	commitParts(parts)
+	return res
+}
+
+func (m *Set[K]) PopAll() (values []K) {
+	parts := m.lock()
+	defer unlockParts(parts)
+	values = m.keys()
+	for _, s := range parts {
+		s.vals, s.index = nil, nil
+		s.commit()
 	}
 	return
 }
 
+// Random returns a random key in O(1).
+// The first call builds an index of keys, which is maintained by subsequent mutations.
+func (m *Set[K]) Random() (key K) {
+	if m.shards != nil {
+		i := m.opts.rand.intn(max(m.Size(), 1))
+		for _, s := range m.shards {
+			if n := s.Size(); i >= n {
+				i -= n
+			} else {
+				return s.Random()
+			}
+		}
+		return
+	}
+	m.mx.RLock()
+	if m.index != nil {
+		defer m.mx.RUnlock()
+		return m.index.random()
+	}
+	m.mx.RUnlock()
+
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.buildIndex().random()
+}
+
+// RandomN returns up to n distinct random keys.
+func (m *Set[K]) RandomN(n int) []K {
+	if m.shards != nil {
+		keys := m.Values()
+		m.opts.rand.shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		return keys[:min(max(n, 0), len(keys))]
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.buildIndex().sample(n)
+}
+
+func (m *Set[K]) buildIndex() *keyIndex[K] {
+	if m.index == nil {
+		m.index = newKeyIndex(m.vals, m.opts.rand)
+	}
+	return m.index
+}
+
 func (m *Set[K]) MarshalJSON() ([]byte, error) {
+	if m.out != nil {
+		b, err := cached(&m.out.json, m.Version, m.marshalJSON)
+		return bytes.Clone(b), err
+	}
+	return m.marshalJSON()
+}
+
+func (m *Set[K]) marshalJSON() ([]byte, error) {
 	return json.Marshal(m.Values())
 }
 
@@ -141,24 +584,73 @@ func (m *Set[K]) UnmarshalJSON(data []byte) (err error) {
 	if err = json.Unmarshal(data, &vv); err != nil {
 		return
 	}
-	m.mx.Lock()
-	defer m.mx.Unlock()
-	m.vals, m.ver = sliceToMap(vv), m.ver+1
+	m.replace(sliceToMap(vv))
 	return
 }
 
+func (m *Set[K]) MarshalBinary() ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	err := m.BinaryEncode(buf)
+	return bytes.Clone(buf.Bytes()), err
+}
+
+func (m *Set[K]) UnmarshalBinary(data []byte) error {
+	return m.BinaryDecode(bytes.NewReader(data))
+}
+
+// GobEncode implements gob.GobEncoder, so that a Set nested in a gob-encoded value keeps its contents.
+func (m *Set[K]) GobEncode() ([]byte, error) {
+	return m.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder.
+func (m *Set[K]) GobDecode(data []byte) error {
+	return m.UnmarshalBinary(data)
+}
+
+// BinaryEncode writes a versioned gob snapshot of the set to w.
 func (m *Set[K]) BinaryEncode(w io.Writer) error {
-	return gob.NewEncoder(w).Encode(m.Values())
+	return writeEnvelope(w, func(w io.Writer) error {
+		return m.BinaryEncodeWith(w, GobCodec)
+	})
+}
+
+// BinaryDecode reads a snapshot written by BinaryEncode, including snapshots of older versions.
+func (m *Set[K]) BinaryDecode(r io.Reader) error {
+	r, err := readEnvelope(r)
+	if err != nil {
+		return err
+	}
+	return m.BinaryDecodeWith(r, GobCodec)
+}
+
+// WriteTo implements io.WriterTo. It writes the same snapshot as BinaryEncode and returns its size.
+func (m *Set[K]) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := m.BinaryEncode(cw)
+	return cw.n, err
 }
 
-func (m *Set[K]) BinaryDecode(r io.Reader) (err error) {
+// ReadFrom implements io.ReaderFrom. It reads a snapshot like BinaryDecode and returns the number of bytes read.
+func (m *Set[K]) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	err := m.BinaryDecode(cr)
+	return cr.n, err
+}
+
+// BinaryEncodeWith encodes the set to w using codec.
+func (m *Set[K]) BinaryEncodeWith(w io.Writer, codec Codec) error {
+	return codec.Encode(w, m.Values())
+}
+
+// BinaryDecodeWith decodes the set from r using codec.
+func (m *Set[K]) BinaryDecodeWith(r io.Reader, codec Codec) (err error) {
 	var vv []K
-	if err = gob.NewDecoder(r).Decode(&vv); err != nil {
+	if err = codec.Decode(r, &vv); err != nil {
 		return
 	}
-	m.mx.Lock()
-	defer m.mx.Unlock()
-	m.vals, m.ver = sliceToMap(vv), m.ver+1
+	m.replace(sliceToMap(vv))
 	return
 }
 
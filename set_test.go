@@ -0,0 +1,181 @@
+package xsync
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestNewSetPtr(t *testing.T) {
+	s := NewSetPtr([]int{1, 2, 2}, WithCapacity(100))
+
+	require(t, 2 == s.Size())
+}
+
+func TestSet_AddMany(t *testing.T) {
+	var s Set[int]
+	s.Set(1)
+
+	require(t, 2 == s.AddMany(1, 2, 3))
+	require(t, 3 == s.Size())
+	require(t, 0 == s.AddMany(1, 2))
+}
+
+func TestSet_DeleteMany(t *testing.T) {
+	s := NewSet([]int{1, 2, 3})
+
+	require(t, 2 == s.DeleteMany(1, 3, 5))
+	require(t, 1 == s.Size())
+	require(t, s.Exists(2))
+}
+
+func TestSet_ContainsAll(t *testing.T) {
+	s := NewSet([]string{"a", "b"})
+
+	require(t, s.ContainsAll("a", "b"))
+	require(t, !s.ContainsAll("a", "c"))
+	require(t, s.ContainsAny("c", "b"))
+	require(t, !s.ContainsAny("c", "d"))
+}
+
+func TestSet_Filter(t *testing.T) {
+	s := NewSet([]int{1, 2, 3, 4, 5})
+
+	n := s.Filter(func(k int) bool { return k%2 == 0 })
+
+	require(t, 2 == n)
+	require(t, 3 == s.Size())
+	require(t, !s.Exists(2))
+}
+
+func TestSet_Clone(t *testing.T) {
+	s := NewSet([]int{1, 2})
+
+	c := s.Clone()
+	c.Set(3)
+
+	require(t, 2 == s.Size())
+	require(t, !s.Equal(c))
+	s.Set(3)
+	require(t, s.Equal(c))
+}
+
+func TestSet_Reserve(t *testing.T) {
+	var s Set[int]
+
+	s.Reserve(0)
+	s.Reserve(-1)
+	require(t, s.vals == nil)
+	s.Reserve(10)
+	require(t, s.vals != nil && 0 == s.Size())
+}
+
+func TestSet_Clone_options(t *testing.T) {
+	for _, opts := range [][]SetOption{nil, {WithShards(4)}} {
+		var st Stats
+		s := NewSetPtr([]int{1, 2}, append(opts, WithMetrics(&st), WithCapacity(8))...)
+
+		c := s.Clone()
+		c.Set(3)
+
+		require(t, 3 == st.Size.Load() && 1 == st.Sets.Load())
+		require(t, len(s.shards) == len(c.shards) && 8 == c.opts.capacity)
+	}
+}
+
+func TestSet_Random(t *testing.T) {
+	s := NewSet([]int{1, 2, 3})
+
+	require(t, s.Exists(s.Random()))
+	s.Delete(2)
+	s.Set(4)
+	for i := 0; i < 100; i++ {
+		k := s.Random()
+		require(t, k != 2 && s.Exists(k))
+	}
+}
+
+func TestSet_RandomN(t *testing.T) {
+	s := NewSet([]int{1, 2, 3, 4, 5})
+
+	kk := s.RandomN(3)
+
+	require(t, 3 == len(kk))
+	require(t, 3 == NewSetPtr(kk).Size())
+	require(t, 5 == len(s.RandomN(10)))
+}
+
+func TestSet_TryPop(t *testing.T) {
+	s := NewSet([]int{0})
+
+	k, ok := s.TryPop()
+	require(t, ok && 0 == k)
+	_, ok = s.TryPop()
+	require(t, !ok)
+}
+
+func TestSet_PopN(t *testing.T) {
+	s := NewSet([]int{1, 2, 3, 4, 5})
+
+	require(t, 2 == len(s.PopN(2)))
+	require(t, 3 == s.Size())
+	require(t, 3 == len(s.PopN(10)))
+	require(t, 0 == s.Size())
+}
+
+func TestSet_WithShards(t *testing.T) {
+	s := NewSetPtr([]int{1, 2, 3}, WithShards(4))
+	require(t, len(s.shards) == 4)
+	require(t, s.Size() == 3 && s.Exists(2) && !s.Exists(4))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Set(i*100 + j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	require(t, s.Size() == 800 && len(s.Values()) == 800)
+	require(t, s.ContainsAll(1, 2, 799) && !s.ContainsAny(800, 801))
+	require(t, s.DeleteMany(1, 2, 1000) == 2 && s.AddMany(1, 2, 3) == 2)
+	require(t, s.Filter(func(k int) bool { return k >= 100 }) == 700)
+	require(t, s.Size() == 100 && s.Exists(s.Random()))
+
+	b, err := json.Marshal(s)
+	require(t, err == nil)
+	s2 := NewSetPtr[int](nil, WithShards(3))
+	require(t, json.Unmarshal(b, s2) == nil)
+	require(t, s2.Equal(s) && s.Equal(s2) && s2.Clone().Equal(s))
+
+	ver := s.Version()
+	require(t, len(s.PopN(10)) == 10 && s.Version() > ver)
+	require(t, len(s.PopAll()) == 90 && s.Size() == 0)
+}
+
+func TestSet_WithShards_keys(t *testing.T) {
+	type point struct{ X, Y float64 }
+	s := NewSetPtr[point](nil, WithShards(16))
+	negZero := math.Copysign(0, -1)
+	for i := 0; i < 100; i++ {
+		s.Set(point{negZero, float64(i)})
+	}
+	for i := 0; i < 100; i++ {
+		require(t, s.Exists(point{0, float64(i)})) // -0 == +0 in the same shard
+	}
+	anys := NewSetPtr([]any{1, "a", point{negZero, 1}, [2]any{nil, 2.5}, &point{}}, WithShards(16))
+	require(t, anys.ContainsAll(1, "a", point{0, 1}, [2]any{nil, 2.5}) && !anys.Exists(&point{}))
+}
+
+func TestSet_AppendValues(t *testing.T) {
+	for _, s := range []*Set[int]{NewSetPtr([]int{1, 2, 3}), NewSetPtr([]int{1, 2, 3}, WithShards(4))} {
+		vals := s.AppendValues([]int{0})
+		slices.Sort(vals)
+		require(t, slices.Equal([]int{0, 1, 2, 3}, vals))
+	}
+}
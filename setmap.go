@@ -0,0 +1,200 @@
+package xsync
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// A SetMap is a map from key to a set of values, e.g. an adjacency list or a secondary index.
+// Empty sets are removed automatically, so a key exists exactly while it has values.
+//
+// A zero SetMap is ready to use. A SetMap is safe for use by multiple goroutines simultaneously.
+type SetMap[K, V comparable] struct {
+	mx   sync.RWMutex
+	ver  uint64
+	vals map[K]map[V]struct{}
+}
+
+// Add adds values to the set of the key and returns the number of values that were not present.
+func (m *SetMap[K, V]) Add(key K, values ...V) (n int) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	set := m.vals[key]
+	for _, v := range values {
+		if _, ok := set[v]; ok {
+			continue
+		}
+		if set == nil {
+			if m.vals == nil {
+				m.vals = map[K]map[V]struct{}{}
+			}
+			set = map[V]struct{}{}
+			m.vals[key] = set
+		}
+		set[v] = struct{}{}
+		n++
+	}
+	if n > 0 {
+		m.ver++
+	}
+	return
+}
+
+// Has reports whether the value is in the set of the key.
+func (m *SetMap[K, V]) Has(key K, value V) bool {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	_, ok := m.vals[key][value]
+	return ok
+}
+
+// ValuesOf returns the values of the key in unspecified order.
+func (m *SetMap[K, V]) ValuesOf(key K) []V {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return mapKeys(m.vals[key])
+}
+
+// DeleteValue removes the value from the set of the key, removing the key if its set becomes empty,
+// and reports whether the value was present.
+func (m *SetMap[K, V]) DeleteValue(key K, value V) bool {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	set := m.vals[key]
+	if _, ok := set[value]; !ok {
+		return false
+	}
+	delete(set, value)
+	if len(set) == 0 {
+		delete(m.vals, key)
+	}
+	m.ver++
+	return true
+}
+
+// DeleteValueAll removes the value from the sets of all keys and returns the number of keys it was removed from.
+func (m *SetMap[K, V]) DeleteValueAll(value V) (n int) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	for k, set := range m.vals {
+		if _, ok := set[value]; ok {
+			delete(set, value)
+			if len(set) == 0 {
+				delete(m.vals, k)
+			}
+			n++
+		}
+	}
+	if n > 0 {
+		m.ver++
+	}
+	return
+}
+
+// Delete removes the key with all its values.
+func (m *SetMap[K, V]) Delete(key K) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if _, ok := m.vals[key]; ok {
+		delete(m.vals, key)
+		m.ver++
+	}
+}
+
+func (m *SetMap[K, V]) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.vals = nil
+	m.ver++
+}
+
+func (m *SetMap[K, V]) Exists(key K) bool {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	_, ok := m.vals[key]
+	return ok
+}
+
+// CountValues returns the number of values of the key.
+func (m *SetMap[K, V]) CountValues(key K) int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.vals[key])
+}
+
+// Len returns the number of keys.
+func (m *SetMap[K, V]) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.vals)
+}
+
+func (m *SetMap[K, V]) Version() uint64 {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.ver
+}
+
+func (m *SetMap[K, V]) Keys() []K {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return mapKeys(m.vals)
+}
+
+// KeysOf returns the keys whose sets contain the value. It takes O(n) time.
+func (m *SetMap[K, V]) KeysOf(value V) (keys []K) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	for k, set := range m.vals {
+		if _, ok := set[value]; ok {
+			keys = append(keys, k)
+		}
+	}
+	return
+}
+
+func (m *SetMap[K, V]) KeyValues() map[K][]V {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	res := make(map[K][]V, len(m.vals))
+	for k, set := range m.vals {
+		res[k] = mapKeys(set)
+	}
+	return res
+}
+
+// Range calls fn sequentially for each key and its values over a snapshot of the SetMap.
+// If fn returns false, Range stops the iteration.
+func (m *SetMap[K, V]) Range(fn func(key K, values []V) bool) {
+	for k, vv := range m.KeyValues() {
+		if !fn(k, vv) {
+			return
+		}
+	}
+}
+
+func (m *SetMap[K, V]) String() string {
+	return encString(m.KeyValues())
+}
+
+// MarshalJSON encodes the SetMap as a JSON object of arrays.
+func (m *SetMap[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.KeyValues())
+}
+
+func (m *SetMap[K, V]) UnmarshalJSON(data []byte) error {
+	var vv map[K][]V
+	if err := json.Unmarshal(data, &vv); err != nil {
+		return err
+	}
+	vals := make(map[K]map[V]struct{}, len(vv))
+	for k, values := range vv {
+		if len(values) > 0 {
+			vals[k] = sliceToMap(values)
+		}
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.vals, m.ver = vals, m.ver+1
+	return nil
+}
@@ -0,0 +1,53 @@
+package xsync
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSetMap(t *testing.T) {
+	var m SetMap[string, int]
+	require(t, m.Add("a", 1, 2, 2) == 2 && m.Add("a", 2) == 0 && m.Add("b", 2) == 1)
+	require(t, m.Has("a", 1) && !m.Has("b", 1) && m.CountValues("a") == 2 && m.Len() == 2)
+
+	vv := m.ValuesOf("a")
+	slices.Sort(vv)
+	require(t, slices.Equal(vv, []int{1, 2}))
+	keys := m.KeysOf(2)
+	slices.Sort(keys)
+	require(t, slices.Equal(keys, []string{"a", "b"}))
+
+	ver := m.Version()
+	require(t, m.DeleteValue("b", 2) && !m.DeleteValue("b", 2))
+	require(t, !m.Exists("b") && m.Version() == ver+1) // the empty set is removed
+
+	require(t, m.DeleteValueAll(1) == 1 && m.CountValues("a") == 1)
+
+	data, err := json.Marshal(&m)
+	require(t, err == nil && string(data) == `{"a":[2]}`)
+	var m2 SetMap[string, int]
+	require(t, json.Unmarshal([]byte(`{"x":[1,1,2],"y":[]}`), &m2) == nil)
+	require(t, m2.CountValues("x") == 2 && !m2.Exists("y"))
+
+	m.Clear()
+	require(t, m.Len() == 0 && m.ValuesOf("a") != nil)
+}
+
+func TestSetMap_concurrent(t *testing.T) {
+	var m SetMap[int, int]
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.Add(i%10, g)
+				m.DeleteValue(i%10, g)
+			}
+		}()
+	}
+	wg.Wait()
+	require(t, m.Len() == 0)
+}
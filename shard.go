@@ -0,0 +1,101 @@
+package xsync
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+// WithShards splits a Set into n independently locked shards, so that operations on different keys
+// do not contend for a single mutex. Keys are assigned to shards by a seeded hash.
+// Operations on the whole set (Values, PopAll, Clear, marshaling) lock all shards and stay atomic.
+func WithShards(n int) SetOption {
+	return setOnlyOption(func(o *options) {
+		o.shards = n
+	})
+}
+
+// hashKey hashes a key for shard selection. Equal keys have equal hashes, e.g. +0.0 and -0.0.
+// Common key types are hashed directly, other keys by their fields and elements.
+func hashKey[K comparable](seed maphash.Seed, key K) uint64 {
+	var b [8]byte
+	switch k := any(key).(type) {
+	case string:
+		return maphash.String(seed, k)
+	case int:
+		binary.LittleEndian.PutUint64(b[:], uint64(k))
+	case int64:
+		binary.LittleEndian.PutUint64(b[:], uint64(k))
+	case int32:
+		binary.LittleEndian.PutUint64(b[:], uint64(k))
+	case uint:
+		binary.LittleEndian.PutUint64(b[:], uint64(k))
+	case uint64:
+		binary.LittleEndian.PutUint64(b[:], k)
+	case uint32:
+		binary.LittleEndian.PutUint64(b[:], uint64(k))
+	case float64:
+		binary.LittleEndian.PutUint64(b[:], floatBits(k))
+	default:
+		var h maphash.Hash
+		h.SetSeed(seed)
+		hashValue(&h, reflect.ValueOf(key))
+		return h.Sum64()
+	}
+	return maphash.Bytes(seed, b[:])
+}
+
+// hashValue writes v to h so that values equal by == write the same bytes.
+func hashValue(h *maphash.Hash, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Bool:
+		if v.Bool() {
+			hashUint(h, 1)
+		} else {
+			hashUint(h, 0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		hashUint(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		hashUint(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		hashUint(h, floatBits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		hashUint(h, floatBits(real(c)))
+		hashUint(h, floatBits(imag(c)))
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		hashUint(h, uint64(v.Pointer()))
+	case reflect.Interface:
+		if !v.IsNil() {
+			hashValue(h, v.Elem())
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Name != "_" { // blank fields are not compared
+				hashValue(h, v.Field(i))
+			}
+		}
+	}
+}
+
+func hashUint(h *maphash.Hash, u uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], u)
+	h.Write(b[:])
+}
+
+// floatBits returns the bits of f with -0 mapped to +0, which are equal.
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		f = 0
+	}
+	return math.Float64bits(f)
+}
@@ -0,0 +1,106 @@
+package xsync
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A SingleFlight is a group of calls deduplicated by key:
+// concurrent calls with the same key share a single execution of fn.
+//
+// A zero SingleFlight is ready to use.
+// A SingleFlight must not be copied after first use.
+type SingleFlight[K comparable, T any] struct {
+	mx    sync.Mutex
+	calls map[K]*flightCall[T]
+}
+
+// FlightResult holds the results of SingleFlight.DoChan.
+type FlightResult[T any] struct {
+	Val    T
+	Err    error
+	Shared bool
+}
+
+type flightCall[T any] struct {
+	wg    sync.WaitGroup
+	val   T
+	err   error
+	dups  int
+	chans []chan<- FlightResult[T]
+}
+
+// Do executes fn for the key, making sure that only one execution is in-flight at a time.
+// If a duplicate comes in, the duplicate caller waits for the original to complete and receives the same results.
+// shared reports whether the result was given to multiple callers.
+func (g *SingleFlight[K, T]) Do(key K, fn func() (T, error)) (v T, err error, shared bool) {
+	g.mx.Lock()
+	if g.calls == nil {
+		g.calls = map[K]*flightCall[T]{}
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mx.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := new(flightCall[T])
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mx.Unlock()
+
+	g.doCall(c, key, fn)
+	return c.val, c.err, c.dups > 0
+}
+
+// DoChan is like Do but returns a channel that will receive the results when they are ready.
+func (g *SingleFlight[K, T]) DoChan(key K, fn func() (T, error)) <-chan FlightResult[T] {
+	ch := make(chan FlightResult[T], 1)
+	g.mx.Lock()
+	if g.calls == nil {
+		g.calls = map[K]*flightCall[T]{}
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		c.chans = append(c.chans, ch)
+		g.mx.Unlock()
+		return ch
+	}
+	c := &flightCall[T]{chans: []chan<- FlightResult[T]{ch}}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mx.Unlock()
+
+	go g.doCall(c, key, fn)
+	return ch
+}
+
+// Forget tells the group to forget about a key.
+// Future calls to Do for this key will call fn rather than waiting for an earlier call to complete.
+func (g *SingleFlight[K, T]) Forget(key K) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	delete(g.calls, key)
+}
+
+func (g *SingleFlight[K, T]) doCall(c *flightCall[T], key K, fn func() (T, error)) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			c.err = fmt.Errorf("xsync: singleflight panic: %v", r)
+		}
+		g.mx.Lock()
+		c.wg.Done()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		for _, ch := range c.chans {
+			ch <- FlightResult[T]{c.val, c.err, c.dups > 0}
+		}
+		g.mx.Unlock()
+		if r != nil {
+			panic(r)
+		}
+	}()
+	c.val, c.err = fn()
+}
@@ -0,0 +1,74 @@
+package xsync
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleFlight_Do(t *testing.T) {
+	var g SingleFlight[string, int]
+
+	v, err, shared := g.Do("a", func() (int, error) { return 123, nil })
+
+	require(t, v == 123)
+	require(t, err == nil)
+	require(t, !shared)
+}
+
+func TestSingleFlight_DoDuplicates(t *testing.T) {
+	var g SingleFlight[string, int]
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _, _ := g.Do("a", func() (int, error) {
+				calls.Add(1)
+				<-release
+				return 1, nil
+			})
+			require(t, v == 1)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require(t, calls.Load() < 10)
+}
+
+func TestSingleFlight_DoChan(t *testing.T) {
+	var g SingleFlight[int, string]
+
+	res := <-g.DoChan(1, func() (string, error) { return "abc", nil })
+
+	require(t, res.Val == "abc")
+	require(t, res.Err == nil)
+}
+
+func TestMap_GetOrSet(t *testing.T) {
+	var m Map[string, int]
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.GetOrSet("a", func() int {
+				calls.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				return 123
+			})
+		}()
+	}
+	wg.Wait()
+
+	require(t, calls.Load() == 1)
+	require(t, m.Get("a") == 123)
+}
@@ -0,0 +1,52 @@
+package xsync
+
+import "log/slog"
+
+const defaultLogLimit = 10
+
+// WithLogLimit sets the maximum number of entries included by LogValue (10 by default).
+func WithLogLimit(n int) Option {
+	return func(o *options) {
+		o.logLimit = n
+	}
+}
+
+func (o *options) logEntries() int {
+	if o.logLimit == 0 {
+		return defaultLogLimit
+	}
+	return max(o.logLimit, 0)
+}
+
+// LogValue implements slog.LogValuer: the map is logged as its len, version and a few first entries.
+func (m *Map[K, T]) LogValue() slog.Value {
+	m.mx.RLock()
+	vals, total, ver := m.sample(m.opts.logEntries()), len(m.vals), m.ver
+	m.mx.RUnlock()
+
+	keys := mapKeys(vals)
+	sortKeys(keys)
+	entries := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		entries = append(entries, slog.Any(encString(k), vals[k]))
+	}
+	return slog.GroupValue(
+		slog.Int("len", total),
+		slog.Uint64("version", ver),
+		slog.Attr{Key: "entries", Value: slog.GroupValue(entries...)},
+	)
+}
+
+// LogValue implements slog.LogValuer: the set is logged as its len, version and a few first keys.
+func (m *Set[K]) LogValue() slog.Value {
+	limit := m.opts.logEntries()
+	keys, total := m.sample(limit)
+	ver := m.Version()
+
+	sortKeys(keys)
+	return slog.GroupValue(
+		slog.Int("len", total),
+		slog.Uint64("version", ver),
+		slog.Any("keys", keys),
+	)
+}
@@ -0,0 +1,41 @@
+package xsync
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestMap_LogValue(t *testing.T) {
+	m := NewMapPtr(map[string]int{"a": 1, "b": 2})
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	log.Info("test", "m", m)
+
+	require(t, "level=INFO msg=test m.len=2 m.version=0 m.entries.a=1 m.entries.b=2\n" == buf.String())
+}
+
+func TestMap_LogValue_limit(t *testing.T) {
+	m := NewMapPtr(map[int]int{1: 1, 2: 2, 3: 3}, WithLogLimit(2))
+
+	v := m.LogValue().Group()
+
+	require(t, 3 == v[0].Value.Int64())
+	require(t, 2 == len(v[2].Value.Group()))
+}
+
+func TestSet_LogValue(t *testing.T) {
+	s := NewSetPtr([]int{3, 1, 2})
+
+	v := s.LogValue()
+
+	require(t, "[len=3 version=0 keys=[1 2 3]]" == v.String())
+}
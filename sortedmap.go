@@ -0,0 +1,216 @@
+package xsync
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+// A SortedMap is a map that keeps its keys in ascending order.
+// It is backed by sorted slices: lookups are O(log n), inserts and deletes are O(n).
+//
+// A SortedMap is safe for use by multiple goroutines simultaneously.
+type SortedMap[K cmp.Ordered, T any] struct {
+	mx   sync.RWMutex
+	ver  uint64
+	keys []K
+	vals []T
+}
+
+func (m *SortedMap[K, T]) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.keys, m.vals = nil, nil
+	m.ver++
+}
+
+func (m *SortedMap[K, T]) Set(key K, value T) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.set(key, value)
+	m.ver++
+}
+
+func (m *SortedMap[K, T]) set(key K, value T) {
+	if i, ok := slices.BinarySearch(m.keys, key); ok {
+		m.vals[i] = value
+	} else {
+		m.keys = slices.Insert(m.keys, i, key)
+		m.vals = slices.Insert(m.vals, i, value)
+	}
+}
+
+func (m *SortedMap[K, T]) Get(key K) (_ T) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if i, ok := slices.BinarySearch(m.keys, key); ok {
+		return m.vals[i]
+	}
+	return
+}
+
+func (m *SortedMap[K, T]) Exists(key K) bool {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	_, ok := slices.BinarySearch(m.keys, key)
+	return ok
+}
+
+func (m *SortedMap[K, T]) Delete(key K) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if i, ok := slices.BinarySearch(m.keys, key); ok {
+		m.deleteAt(i)
+		m.ver++
+	}
+}
+
+func (m *SortedMap[K, T]) deleteAt(i int) {
+	m.keys = slices.Delete(m.keys, i, i+1)
+	m.vals = slices.Delete(m.vals, i, i+1)
+}
+
+func (m *SortedMap[K, T]) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.keys)
+}
+
+func (m *SortedMap[K, T]) Version() uint64 {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.ver
+}
+
+// Keys returns keys in ascending order.
+func (m *SortedMap[K, T]) Keys() []K {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return slices.Clone(m.keys)
+}
+
+// Values returns values in ascending order of their keys.
+func (m *SortedMap[K, T]) Values() []T {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return slices.Clone(m.vals)
+}
+
+func (m *SortedMap[K, T]) KeyValues() map[K]T {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	res := make(map[K]T, len(m.keys))
+	for i, k := range m.keys {
+		res[k] = m.vals[i]
+	}
+	return res
+}
+
+func (m *SortedMap[K, T]) Min() (key K, value T, ok bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if len(m.keys) > 0 {
+		return m.keys[0], m.vals[0], true
+	}
+	return
+}
+
+func (m *SortedMap[K, T]) Max() (key K, value T, ok bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if n := len(m.keys); n > 0 {
+		return m.keys[n-1], m.vals[n-1], true
+	}
+	return
+}
+
+func (m *SortedMap[K, T]) PopMin() (key K, value T, ok bool) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if len(m.keys) > 0 {
+		key, value, ok = m.keys[0], m.vals[0], true
+		m.deleteAt(0)
+		m.ver++
+	}
+	return
+}
+
+func (m *SortedMap[K, T]) PopMax() (key K, value T, ok bool) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if n := len(m.keys); n > 0 {
+		key, value, ok = m.keys[n-1], m.vals[n-1], true
+		m.deleteAt(n - 1)
+		m.ver++
+	}
+	return
+}
+
+// Range calls fn in ascending order for each entry with from <= key < to.
+// Iteration is done over a snapshot; if fn returns false, Range stops the iteration.
+func (m *SortedMap[K, T]) Range(from, to K, fn func(key K, value T) bool) {
+	m.mx.RLock()
+	i, _ := slices.BinarySearch(m.keys, from)
+	j, _ := slices.BinarySearch(m.keys, to)
+	j = max(i, j)
+	keys, vals := slices.Clone(m.keys[i:j]), slices.Clone(m.vals[i:j])
+	m.mx.RUnlock()
+
+	for i, k := range keys {
+		if !fn(k, vals[i]) {
+			return
+		}
+	}
+}
+
+// Ascend calls fn for each entry in ascending order of keys.
+// Iteration is done over a snapshot; if fn returns false, Ascend stops the iteration.
+func (m *SortedMap[K, T]) Ascend(fn func(key K, value T) bool) {
+	keys, vals := m.snapshot()
+	for i, k := range keys {
+		if !fn(k, vals[i]) {
+			return
+		}
+	}
+}
+
+// Descend calls fn for each entry in descending order of keys.
+// Iteration is done over a snapshot; if fn returns false, Descend stops the iteration.
+func (m *SortedMap[K, T]) Descend(fn func(key K, value T) bool) {
+	keys, vals := m.snapshot()
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !fn(keys[i], vals[i]) {
+			return
+		}
+	}
+}
+
+func (m *SortedMap[K, T]) snapshot() ([]K, []T) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return slices.Clone(m.keys), slices.Clone(m.vals)
+}
+
+func (m *SortedMap[K, T]) String() string {
+	return encString(m.KeyValues())
+}
+
+func (m *SortedMap[K, T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.KeyValues())
+}
+
+func (m *SortedMap[K, T]) UnmarshalJSON(data []byte) (err error) {
+	var vv map[K]T
+	if err = json.Unmarshal(data, &vv); err != nil {
+		return
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.keys, m.vals = nil, nil
+	for k, v := range vv {
+		m.set(k, v)
+	}
+	m.ver++
+	return
+}
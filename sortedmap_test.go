@@ -0,0 +1,63 @@
+package xsync
+
+import "testing"
+
+func TestSortedMap_Keys(t *testing.T) {
+	var m SortedMap[int, string]
+	m.Set(3, "c")
+	m.Set(1, "a")
+	m.Set(2, "b")
+	m.Set(1, "aa")
+
+	kk := m.Keys()
+
+	require(t, 3 == len(kk))
+	require(t, 1 == kk[0] && 2 == kk[1] && 3 == kk[2])
+	require(t, "aa" == m.Get(1))
+}
+
+func TestSortedMap_MinMax(t *testing.T) {
+	var m SortedMap[string, int]
+	_, _, ok := m.Min()
+	require(t, !ok)
+
+	m.Set("b", 2)
+	m.Set("a", 1)
+	m.Set("c", 3)
+
+	k, v, ok := m.Min()
+	require(t, ok && "a" == k && 1 == v)
+	k, v, ok = m.PopMax()
+	require(t, ok && "c" == k && 3 == v)
+	require(t, 2 == m.Len())
+}
+
+func TestSortedMap_Range(t *testing.T) {
+	var m SortedMap[int, int]
+	for i := 0; i < 10; i++ {
+		m.Set(i, i*10)
+	}
+	var kk []int
+
+	m.Range(3, 6, func(k, v int) bool {
+		kk = append(kk, k)
+		return true
+	})
+
+	require(t, 3 == len(kk))
+	require(t, 3 == kk[0] && 5 == kk[2])
+}
+
+func TestSortedMap_Descend(t *testing.T) {
+	var m SortedMap[int, int]
+	m.Set(1, 1)
+	m.Set(2, 2)
+	var kk []int
+
+	m.Descend(func(k, v int) bool {
+		kk = append(kk, k)
+		return true
+	})
+
+	require(t, 2 == kk[0] && 1 == kk[1])
+}
@@ -0,0 +1,73 @@
+package xsync
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Value implements driver.Valuer. The map is encoded as JSON unless configured with WithSQLCodec.
+func (m *Map[K, T]) Value() (driver.Value, error) {
+	if m.opts.sqlCodec == nil {
+		return m.MarshalJSON()
+	}
+	var buf bytes.Buffer
+	err := m.BinaryEncodeWith(&buf, m.opts.sqlCodec)
+	return buf.Bytes(), err
+}
+
+// Scan implements sql.Scanner. A NULL value clears the map.
+func (m *Map[K, T]) Scan(src any) error {
+	data, err := scanBytes(src)
+	if err != nil || data == nil {
+		m.Clear()
+		return err
+	}
+	var vals map[K]T
+	if m.opts.sqlCodec == nil {
+		err = json.Unmarshal(data, &vals)
+	} else {
+		err = m.opts.sqlCodec.Decode(bytes.NewReader(data), &vals)
+	}
+	if err != nil {
+		return err
+	}
+	m.replace(vals)
+	return nil
+}
+
+// Value implements driver.Valuer. The set is encoded as JSON unless configured with WithSQLCodec.
+func (m *Set[K]) Value() (driver.Value, error) {
+	if m.opts.sqlCodec == nil {
+		return m.MarshalJSON()
+	}
+	var buf bytes.Buffer
+	err := m.BinaryEncodeWith(&buf, m.opts.sqlCodec)
+	return buf.Bytes(), err
+}
+
+// Scan implements sql.Scanner. A NULL value clears the set.
+func (m *Set[K]) Scan(src any) error {
+	data, err := scanBytes(src)
+	if err != nil || data == nil {
+		m.Clear()
+		return err
+	}
+	if m.opts.sqlCodec == nil {
+		return m.UnmarshalJSON(data)
+	}
+	return m.BinaryDecodeWith(bytes.NewReader(data), m.opts.sqlCodec)
+}
+
+func scanBytes(src any) ([]byte, error) {
+	switch v := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("%w: cannot scan %T", ErrTypeMismatch, src)
+}
@@ -0,0 +1,35 @@
+package xsync
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestMap_Value(t *testing.T) {
+	var _ driver.Valuer = (*Map[string, int])(nil)
+	var _ sql.Scanner = (*Set[string])(nil)
+	m := NewMap(map[string]int{"a": 1})
+	m2 := NewMap(map[string]int{"b": 2})
+
+	v, err := m.Value()
+	require(t, err == nil)
+	require(t, `{"a":1}` == string(v.([]byte)))
+
+	require(t, nil == m2.Scan(v))
+	require(t, m.Equal(&m2))
+	require(t, nil == m2.Scan(nil))
+	require(t, 0 == m2.Len())
+}
+
+func TestSet_Value(t *testing.T) {
+	s := NewSetPtr([]int{1, 2}, WithSQLCodec(GobCodec))
+	s2 := NewSetPtr[int](nil, WithSQLCodec(GobCodec))
+
+	v, err := s.Value()
+	require(t, err == nil)
+
+	require(t, nil == s2.Scan(v))
+	require(t, s.Equal(s2))
+	require(t, nil != s2.Scan(123))
+}
@@ -0,0 +1,114 @@
+package xsync
+
+import (
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Stripes are padded to a cache line to avoid false sharing.
+type intStripe struct {
+	n atomic.Int64
+	_ [64 - 8]byte
+}
+
+type floatStripe struct {
+	bits atomic.Uint64
+	_    [64 - 8]byte
+}
+
+// stripeCount returns the number of stripes: GOMAXPROCS rounded up to a power of two.
+func stripeCount() int {
+	return 1 << bits.Len(uint(runtime.GOMAXPROCS(0)-1))
+}
+
+// A StripedCounter is an int64 counter for very high increment rates.
+// Increments are spread over cache-line-padded stripes, so concurrent writers do not contend,
+// and Load sums the stripes.
+//
+// A zero StripedCounter is ready to use. It must not be copied after first use.
+type StripedCounter struct {
+	once    sync.Once
+	stripes []intStripe
+}
+
+func (c *StripedCounter) stripe() *intStripe {
+	c.once.Do(func() { c.stripes = make([]intStripe, stripeCount()) })
+	return &c.stripes[rand.Uint32()&uint32(len(c.stripes)-1)]
+}
+
+// Add adds delta to the counter.
+func (c *StripedCounter) Add(delta int64) {
+	c.stripe().n.Add(delta)
+}
+
+func (c *StripedCounter) Inc() {
+	c.Add(1)
+}
+
+func (c *StripedCounter) Dec() {
+	c.Add(-1)
+}
+
+// Load returns the sum of the stripes. Concurrent increments may or may not be included.
+func (c *StripedCounter) Load() (sum int64) {
+	c.stripe()
+	for i := range c.stripes {
+		sum += c.stripes[i].n.Load()
+	}
+	return
+}
+
+// Reset sets the counter to zero and returns its previous value.
+func (c *StripedCounter) Reset() (sum int64) {
+	c.stripe()
+	for i := range c.stripes {
+		sum += c.stripes[i].n.Swap(0)
+	}
+	return
+}
+
+// A StripedFloat64 is a float64 accumulator for very high update rates, striped like StripedCounter.
+//
+// A zero StripedFloat64 is ready to use. It must not be copied after first use.
+type StripedFloat64 struct {
+	once    sync.Once
+	stripes []floatStripe
+}
+
+func (c *StripedFloat64) stripe() *floatStripe {
+	c.once.Do(func() { c.stripes = make([]floatStripe, stripeCount()) })
+	return &c.stripes[rand.Uint32()&uint32(len(c.stripes)-1)]
+}
+
+// Add adds delta to the accumulator.
+func (c *StripedFloat64) Add(delta float64) {
+	s := c.stripe()
+	for {
+		old := s.bits.Load()
+		if s.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// Load returns the sum of the stripes. Concurrent updates may or may not be included.
+func (c *StripedFloat64) Load() (sum float64) {
+	c.stripe()
+	for i := range c.stripes {
+		sum += math.Float64frombits(c.stripes[i].bits.Load())
+	}
+	return
+}
+
+// Reset sets the accumulator to zero and returns its previous value.
+func (c *StripedFloat64) Reset() (sum float64) {
+	c.stripe()
+	for i := range c.stripes {
+		sum += math.Float64frombits(c.stripes[i].bits.Swap(0))
+	}
+	return
+}
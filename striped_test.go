@@ -0,0 +1,29 @@
+package xsync
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestStripedCounter(t *testing.T) {
+	var c StripedCounter
+	var f StripedFloat64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Inc()
+				f.Add(0.5)
+			}
+		}()
+	}
+	wg.Wait()
+	c.Dec()
+	require(t, 7999 == c.Load())
+	require(t, 4000 == f.Load())
+
+	require(t, 7999 == c.Reset() && 0 == c.Load())
+	require(t, 4000 == f.Reset() && 0 == f.Load())
+}
@@ -0,0 +1,207 @@
+package xsync
+
+import (
+	"cmp"
+	"container/heap"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A TopK tracks the most frequent keys of a stream in bounded memory using the space-saving algorithm.
+// It monitors at most capacity keys; when a new key arrives and all slots are taken, the least
+// frequent key is replaced and the new key inherits its count. Counts are thus overestimated by at
+// most the count of the replaced key, and any key more frequent than 1/capacity of the stream
+// is guaranteed to be tracked.
+//
+// A TopK is safe for use by multiple goroutines simultaneously.
+type TopK[K comparable] struct {
+	mx       sync.Mutex
+	capacity int
+	index    map[K]*topKEntry[K]
+	heap     topKHeap[K]
+	opts     topKOptions
+	decayed  time.Time
+}
+
+// A KeyCount is a key with its (estimated) count.
+type KeyCount[K comparable] struct {
+	Key   K
+	Count uint64
+}
+
+type topKEntry[K comparable] struct {
+	key   K
+	count uint64
+	idx   int
+}
+
+// A TopKOption configures a TopK.
+type TopKOption func(*topKOptions)
+
+type topKOptions struct {
+	factor float64
+	every  time.Duration
+	clock  Clock
+}
+
+// WithDecay multiplies all counts by factor every interval, so that the TopK follows recent traffic.
+// Decay is applied lazily by the next call.
+func WithDecay(factor float64, interval time.Duration) TopKOption {
+	return func(o *topKOptions) {
+		o.factor, o.every = factor, interval
+	}
+}
+
+// WithTopKClock sets the clock measuring decay intervals instead of RealClock.
+func WithTopKClock(c Clock) TopKOption {
+	return func(o *topKOptions) {
+		o.clock = c
+	}
+}
+
+// NewTopK returns a TopK monitoring up to capacity keys.
+// A capacity several times larger than the number of keys queried with Top improves accuracy.
+func NewTopK[K comparable](capacity int, opts ...TopKOption) *TopK[K] {
+	tk := &TopK[K]{
+		capacity: max(capacity, 1),
+		index:    map[K]*topKEntry[K]{},
+	}
+	for _, fn := range opts {
+		fn(&tk.opts)
+	}
+	tk.opts.clock = clockOrReal(tk.opts.clock)
+	tk.decayed = tk.opts.clock.Now()
+	return tk
+}
+
+// Add counts one occurrence of the key.
+func (tk *TopK[K]) Add(key K) {
+	tk.AddN(key, 1)
+}
+
+// AddN counts n occurrences of the key.
+func (tk *TopK[K]) AddN(key K, n uint64) {
+	tk.mx.Lock()
+	defer tk.mx.Unlock()
+	tk.autoDecay()
+
+	if e := tk.index[key]; e != nil {
+		e.count += n
+		heap.Fix(&tk.heap, e.idx)
+		return
+	}
+	if len(tk.heap) < tk.capacity {
+		e := &topKEntry[K]{key: key, count: n}
+		tk.index[key] = e
+		heap.Push(&tk.heap, e)
+		return
+	}
+	e := tk.heap[0] // the least frequent key
+	delete(tk.index, e.key)
+	e.key = key
+	e.count += n
+	tk.index[key] = e
+	heap.Fix(&tk.heap, 0)
+}
+
+// Count returns the estimated count of the key, or 0 if it is not tracked.
+func (tk *TopK[K]) Count(key K) uint64 {
+	tk.mx.Lock()
+	defer tk.mx.Unlock()
+	tk.autoDecay()
+	if e := tk.index[key]; e != nil {
+		return e.count
+	}
+	return 0
+}
+
+// Top returns up to n most frequent keys in descending order of count; nil if n <= 0.
+func (tk *TopK[K]) Top(n int) []KeyCount[K] {
+	if n <= 0 {
+		return nil
+	}
+	tk.mx.Lock()
+	defer tk.mx.Unlock()
+	tk.autoDecay()
+
+	res := make([]KeyCount[K], 0, len(tk.heap))
+	for _, e := range tk.heap {
+		res = append(res, KeyCount[K]{e.key, e.count})
+	}
+	slices.SortFunc(res, func(a, b KeyCount[K]) int { return cmp.Compare(b.Count, a.Count) })
+	return res[:min(n, len(res))]
+}
+
+// Len returns the number of tracked keys.
+func (tk *TopK[K]) Len() int {
+	tk.mx.Lock()
+	defer tk.mx.Unlock()
+	return len(tk.heap)
+}
+
+// Decay multiplies all counts by factor; keys whose count drops to zero are forgotten.
+func (tk *TopK[K]) Decay(factor float64) {
+	tk.mx.Lock()
+	defer tk.mx.Unlock()
+	tk.decay(factor)
+}
+
+// Clear forgets all keys.
+func (tk *TopK[K]) Clear() {
+	tk.mx.Lock()
+	defer tk.mx.Unlock()
+	clear(tk.index)
+	tk.heap = nil
+}
+
+func (tk *TopK[K]) autoDecay() {
+	if tk.opts.every <= 0 {
+		return
+	}
+	if n := tk.opts.clock.Now().Sub(tk.decayed) / tk.opts.every; n > 0 {
+		tk.decayed = tk.decayed.Add(n * tk.opts.every)
+		tk.decay(math.Pow(tk.opts.factor, float64(n)))
+	}
+}
+
+func (tk *TopK[K]) decay(factor float64) {
+	h := tk.heap[:0]
+	for _, e := range tk.heap {
+		e.count = uint64(float64(e.count) * factor)
+		if e.count == 0 {
+			delete(tk.index, e.key)
+			continue
+		}
+		e.idx = len(h)
+		h = append(h, e)
+	}
+	clear(tk.heap[len(h):])
+	tk.heap = h
+	heap.Init(&tk.heap)
+}
+
+type topKHeap[K comparable] []*topKEntry[K]
+
+func (h topKHeap[K]) Len() int           { return len(h) }
+func (h topKHeap[K]) Less(i, j int) bool { return h[i].count < h[j].count }
+
+func (h topKHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].idx, h[j].idx = i, j
+}
+
+func (h *topKHeap[K]) Push(x any) {
+	e := x.(*topKEntry[K])
+	e.idx = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *topKHeap[K]) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,50 @@
+package xsync
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTopK(t *testing.T) {
+	tk := NewTopK[int](10)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				tk.Add(i % 100) // noise
+				tk.Add(1000 + i%3)
+			}
+		}()
+	}
+	wg.Wait()
+
+	top := tk.Top(3)
+	require(t, 3 == len(top))
+	seen := map[int]bool{}
+	for _, kc := range top {
+		seen[kc.Key] = true
+		require(t, kc.Count >= 1332)
+	}
+	require(t, seen[1000] && seen[1001] && seen[1002])
+	require(t, 10 == tk.Len() && 10 == len(tk.Top(100)) && nil == tk.Top(-1))
+	require(t, tk.Count(1000) >= 1332 && 0 == tk.Count(-1))
+
+	tk.Clear()
+	require(t, 0 == tk.Len() && 0 == len(tk.Top(3)))
+}
+
+func TestTopK_Decay(t *testing.T) {
+	tk := NewTopK[string](10)
+	tk.AddN("a", 10)
+	tk.AddN("b", 1)
+	tk.Decay(0.5)
+	require(t, 5 == tk.Count("a") && 0 == tk.Count("b") && 1 == tk.Len())
+
+	tk = NewTopK[string](10, WithDecay(0.5, 10*time.Millisecond))
+	tk.AddN("a", 100)
+	time.Sleep(25 * time.Millisecond)
+	require(t, tk.Count("a") <= 25) // decayed at least twice
+}
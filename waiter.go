@@ -0,0 +1,92 @@
+package xsync
+
+import (
+	"context"
+	"reflect"
+)
+
+// A Case is a condition a Waiter can wait for, created by EventCase, LatchCase, KeyCase or ChanCase.
+type Case struct {
+	// arm returns a channel that becomes ready to receive when the condition holds,
+	// and a function releasing resources registered for the wait.
+	arm func() (ch reflect.Value, stop func())
+}
+
+// EventCase holds when the event is set.
+func EventCase(e *Event) Case {
+	return Case{func() (reflect.Value, func()) {
+		return reflect.ValueOf(e.Done()), nil
+	}}
+}
+
+// LatchCase holds when the counter of the latch is zero.
+func LatchCase(l *Latch) Case {
+	return Case{func() (reflect.Value, func()) {
+		l.mx.Lock()
+		ch := l.ch
+		l.mx.Unlock()
+		if ch == nil {
+			ch = closedChan
+		}
+		return reflect.ValueOf(ch), nil
+	}}
+}
+
+// KeyCase holds when the key is present in the map.
+func KeyCase[K comparable, T any](m *Map[K, T], key K) Case {
+	return Case{func() (reflect.Value, func()) {
+		if _, ch := m.addWaiter(key); ch != nil {
+			return reflect.ValueOf(ch), func() { m.removeWaiter(key, ch) }
+		}
+		return reflect.ValueOf(closedChan), nil
+	}}
+}
+
+// ChanCase holds when ch is closed or has a value to receive, which is consumed by the wait.
+func ChanCase[T any](ch <-chan T) Case {
+	return Case{func() (reflect.Value, func()) {
+		return reflect.ValueOf(ch), nil
+	}}
+}
+
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// A Waiter waits for the first of several conditions on xsync primitives, like a select statement,
+// without starting a goroutine per condition. A Waiter may be reused, and its Wait method may be called
+// by multiple goroutines simultaneously.
+type Waiter struct {
+	cases []Case
+}
+
+// NewWaiter returns a Waiter for the cases.
+func NewWaiter(cases ...Case) *Waiter {
+	return &Waiter{cases: cases}
+}
+
+// Wait blocks until one of the cases holds and returns its index; if several hold, the lowest index is returned.
+// If ctx is done first, Wait returns -1 and ctx.Err().
+func (w *Waiter) Wait(ctx context.Context) (int, error) {
+	sel := make([]reflect.SelectCase, len(w.cases)+1)
+	for i, c := range w.cases {
+		ch, stop := c.arm()
+		if stop != nil {
+			defer stop()
+		}
+		sel[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: ch}
+	}
+	for i, c := range sel[:len(w.cases)] {
+		if x, ok := c.Chan.TryRecv(); ok || x.IsValid() { // received or closed
+			return i, nil
+		}
+	}
+	sel[len(w.cases)] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	i, _, _ := reflect.Select(sel)
+	if i == len(w.cases) {
+		return -1, ctx.Err()
+	}
+	return i, nil
+}
@@ -0,0 +1,44 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaiter(t *testing.T) {
+	var e Event
+	var m Map[string, int]
+	l := NewLatch(1)
+	ch := make(chan int, 1)
+	w := NewWaiter(EventCase(&e), KeyCase(&m, "k"), LatchCase(l), ChanCase(ch))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	i, err := w.Wait(ctx)
+	require(t, i == -1 && errors.Is(err, context.DeadlineExceeded))
+	require(t, len(m.waiters) == 0) // the key waiter is released
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		m.Set("k", 1)
+	}()
+	i, err = w.Wait(context.Background())
+	require(t, i == 1 && err == nil)
+
+	l.Done()
+	e.Set()
+	i, _ = w.Wait(context.Background())
+	require(t, i == 0) // the lowest ready index wins
+
+	e.Reset()
+	m.Delete("k")
+	i, _ = w.Wait(context.Background())
+	require(t, i == 2)
+
+	w = NewWaiter(ChanCase(ch))
+	ch <- 1
+	i, _ = w.Wait(context.Background())
+	require(t, i == 0 && len(ch) == 0)
+}
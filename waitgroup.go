@@ -0,0 +1,67 @@
+package xsync
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// A PanicError is a panic recovered in a goroutine, with the stack trace of the panic.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("xsync: panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// A WaitGroup waits for a collection of goroutines started by Go.
+// Unlike sync.WaitGroup, Wait respects a context, and panics of the goroutines are recovered
+// and returned by Wait instead of crashing the program.
+//
+// A zero WaitGroup is ready to use.
+type WaitGroup struct {
+	latch Latch
+	mx    sync.Mutex
+	err   *PanicError // first recovered panic
+}
+
+// Go calls fn in a new goroutine.
+func (wg *WaitGroup) Go(fn func()) {
+	wg.latch.Add(1)
+	go func() {
+		defer wg.latch.Done()
+		defer func() {
+			if v := recover(); v != nil {
+				wg.mx.Lock()
+				defer wg.mx.Unlock()
+				if wg.err == nil {
+					wg.err = &PanicError{Value: v, Stack: debug.Stack()}
+				}
+			}
+		}()
+		fn()
+	}()
+}
+
+// Wait blocks until all goroutines have returned or ctx is done, in which case it returns ctx.Err().
+// Otherwise it returns a *PanicError with the first panic of the goroutines, if any.
+func (wg *WaitGroup) Wait(ctx context.Context) error {
+	if err := wg.latch.Wait(ctx); err != nil {
+		return err
+	}
+	wg.mx.Lock()
+	defer wg.mx.Unlock()
+	if wg.err != nil {
+		return wg.err
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitGroup(t *testing.T) {
+	var wg WaitGroup
+	var n atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Go(func() { n.Add(1) })
+	}
+	require(t, nil == wg.Wait(context.Background()))
+	require(t, 10 == n.Load())
+
+	errBoom := errors.New("boom")
+	wg.Go(func() { panic(errBoom) })
+	err := wg.Wait(context.Background())
+	var pe *PanicError
+	require(t, errors.As(err, &pe) && errors.Is(err, errBoom) && len(pe.Stack) > 0)
+
+	stop := make(chan struct{})
+	wg.Go(func() { <-stop })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require(t, context.DeadlineExceeded == wg.Wait(ctx))
+	close(stop)
+}
@@ -0,0 +1,197 @@
+package xsync
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Write-ahead log records.
+const (
+	walSet byte = iota + 1
+	walDelete
+	walSnapshot
+)
+
+type walRecord[K comparable, T any] struct {
+	Op       byte
+	Key      K
+	Val      T
+	Snapshot map[K]T
+}
+
+type walWriter[K comparable, T any] struct {
+	enc     *gob.Encoder
+	err     error
+	records int // records written since the last snapshot
+
+	// set by StartLogFile
+	file         *os.File
+	path         string
+	compactAfter int
+}
+
+func (w *walWriter[K, T]) write(op byte, key K, val T, snapshot map[K]T) {
+	if w.err == nil {
+		w.err = w.enc.Encode(walRecord[K, T]{op, key, val, snapshot})
+		w.records++
+		if op == walSnapshot {
+			w.records = 0
+		}
+	}
+}
+
+// due reports whether the log file should be compacted.
+func (w *walWriter[K, T]) due() bool {
+	return w.err == nil && w.file != nil && w.compactAfter > 0 && w.records >= w.compactAfter
+}
+
+// rotate atomically replaces the log file with a new one starting with a snapshot of vals.
+func (w *walWriter[K, T]) rotate(vals map[K]T) (err error) {
+	f, err := createTemp(w.path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	var key K
+	var val T
+	enc := gob.NewEncoder(f)
+	if err = enc.Encode(walRecord[K, T]{walSnapshot, key, val, vals}); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	if err = renameDurable(f.Name(), w.path); err != nil {
+		return
+	}
+	w.close()
+	w.enc, w.file, w.records = enc, f, 0
+	return nil
+}
+
+// close closes the log file, if any.
+func (w *walWriter[K, T]) close() {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+}
+
+func (w *walWriter[K, T]) writeSnapshot(vals map[K]T) {
+	var key K
+	var val T
+	w.write(walSnapshot, key, val, vals)
+}
+
+// StartLog starts appending every mutation of the map to w (write-ahead log).
+// The current contents are written to w first, so a log always starts with a full snapshot.
+//
+// Calling StartLog while logging switches to the new writer, which compacts the log:
+// the new log contains the snapshot and subsequent mutations only, so the old one can be discarded.
+func (m *Map[K, T]) StartLog(w io.Writer) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	wal := &walWriter[K, T]{enc: gob.NewEncoder(w)}
+	wal.writeSnapshot(m.vals)
+	if wal.err != nil {
+		return wal.err
+	}
+	m.setLog(wal)
+	return nil
+}
+
+// StartLogFile starts logging every mutation of the map to the file like StartLog, and compacts the log
+// automatically: after compactAfter records the file is atomically replaced with a new log starting with
+// a snapshot, so it does not grow without bound. compactAfter <= 0 disables compaction.
+// The log can be replayed with ReplayLog.
+func (m *Map[K, T]) StartLogFile(path string, compactAfter int) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	wal := &walWriter[K, T]{path: path, compactAfter: compactAfter}
+	if err := wal.rotate(m.vals); err != nil {
+		return err
+	}
+	m.setLog(wal)
+	return nil
+}
+
+// setLog replaces the log writer, closing the file of the previous one. m.mx must be held.
+func (m *Map[K, T]) setLog(wal *walWriter[K, T]) {
+	if m.wal != nil {
+		m.wal.close()
+	}
+	m.wal = wal
+}
+
+// compactLog replaces the log file with a snapshot if it is due. m.mx must be held.
+func (m *Map[K, T]) compactLog() {
+	if m.wal != nil && m.wal.due() {
+		m.wal.err = m.wal.rotate(m.vals)
+	}
+}
+
+// StopLog stops logging mutations, closing the file of StartLogFile,
+// and returns the first error that occurred while writing the log.
+func (m *Map[K, T]) StopLog() error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	if m.wal == nil {
+		return nil
+	}
+	err := m.wal.err
+	m.setLog(nil)
+	return err
+}
+
+// LogErr returns the first error that occurred while writing the log, if any.
+// After a write error the log is no longer appended.
+func (m *Map[K, T]) LogErr() error {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	if m.wal == nil {
+		return nil
+	}
+	return m.wal.err
+}
+
+// ReplayLog reads a log written by StartLog and applies its records to the map.
+// If the log ends with a partially written record (e.g. after a crash), all complete records are applied
+// and io.ErrUnexpectedEOF is returned.
+func (m *Map[K, T]) ReplayLog(r io.Reader) error {
+	dec := gob.NewDecoder(r)
+	for {
+		var rec walRecord[K, T]
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		m.mx.Lock()
+		switch rec.Op {
+		case walSet:
+			m.set(rec.Key, rec.Val)
+		case walDelete:
+			m.del(rec.Key)
+		case walSnapshot:
+			m.reset(rec.Snapshot)
+		default:
+			m.mx.Unlock()
+			return fmt.Errorf("%w: invalid log record %d", ErrCorrupt, rec.Op)
+		}
+		m.commit()
+		m.mx.Unlock()
+	}
+}
@@ -0,0 +1,101 @@
+package xsync
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMap_ReplayLog(t *testing.T) {
+	m := NewMap(map[string]int{"a": 1})
+	var log bytes.Buffer
+	require(t, nil == m.StartLog(&log))
+
+	m.Set("b", 2)
+	m.Increment("b", 3)
+	m.Delete("a")
+	m.Set("c", 3)
+	require(t, nil == m.StopLog())
+	m.Set("d", 4)
+
+	var m2 Map[string, int]
+	require(t, nil == m2.ReplayLog(&log))
+	require(t, 2 == m2.Len())
+	require(t, 5 == m2.Get("b"))
+	require(t, 3 == m2.Get("c"))
+}
+
+func TestMap_StartLog_compact(t *testing.T) {
+	var m Map[int, int]
+	var log1, log2 bytes.Buffer
+	m.StartLog(&log1)
+	for i := 0; i < 100; i++ {
+		m.Set(i%10, i)
+	}
+
+	require(t, nil == m.StartLog(&log2))
+	m.Clear()
+	m.Set(1, 1)
+
+	require(t, log2.Len() < log1.Len())
+
+	var m1, m2 Map[int, int]
+	require(t, nil == m1.ReplayLog(&log1))
+	require(t, nil == m2.ReplayLog(&log2))
+	require(t, 10 == m1.Len())
+	require(t, m.Equal(&m2))
+}
+
+func TestMap_ReplayLog_truncated(t *testing.T) {
+	var m Map[string, string]
+	var log bytes.Buffer
+	m.StartLog(&log)
+	m.Set("a", "aaa")
+	m.Set("b", "bbb")
+
+	var m2 Map[string, string]
+	err := m2.ReplayLog(bytes.NewReader(log.Bytes()[:log.Len()-2]))
+
+	require(t, err == io.ErrUnexpectedEOF)
+	require(t, "aaa" == m2.Get("a"))
+}
+
+func TestMap_StartLogFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "map.log")
+	var m Map[int, int]
+	require(t, nil == m.StartLogFile(path, 10))
+
+	size := func() int64 {
+		fi, err := os.Stat(path)
+		require(t, err == nil)
+		return fi.Size()
+	}
+	maxSize := int64(0)
+	for i := 0; i < 1000; i++ {
+		m.Set(i%5, i)
+		maxSize = max(maxSize, size())
+	}
+	m.Delete(0)
+	require(t, nil == m.StopLog())
+
+	var m2 Map[int, int]
+	f, err := os.Open(path)
+	require(t, err == nil)
+	defer f.Close()
+	require(t, nil == m2.ReplayLog(f))
+	require(t, m.Equal(&m2) && 4 == m2.Len())
+
+	var full bytes.Buffer // the same mutations without compaction
+	var m3 Map[int, int]
+	m3.StartLog(&full)
+	for i := 0; i < 1000; i++ {
+		m3.Set(i%5, i)
+	}
+	require(t, maxSize < int64(full.Len())/10)
+
+	entries, _ := os.ReadDir(dir)
+	require(t, 1 == len(entries)) // no temporary files left
+}
@@ -0,0 +1,128 @@
+package xsync
+
+// Number is a constraint that permits any integer or floating-point type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// RandomWeighted returns a random entry chosen with probability proportional to weight(key, value).
+// Entries with non-positive weights are never chosen. It takes O(n) time.
+func (m *Map[K, T]) RandomWeighted(weight func(key K, value T) float64) (key K, value T) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	var total float64
+	pick := func(k K, v T) {
+		// weighted reservoir sampling: replace the choice with probability w/total
+		if w := weight(k, v); w > 0 {
+			total += w
+			if m.opts.rand.float64()*total < w {
+				key, value = k, v
+			}
+		}
+	}
+	if m.opts.rand != nil { // visit the entries in a reproducible order
+		keys := mapKeys(m.vals)
+		sortKeys(keys)
+		for _, k := range keys {
+			pick(k, m.vals[k])
+		}
+		return key, m.cloneVal(value)
+	}
+	for k, v := range m.vals {
+		pick(k, v)
+	}
+	return key, m.cloneVal(value)
+}
+
+// RandomByValue returns a random entry of m chosen with probability proportional to its value in O(1).
+// It uses an alias table which is rebuilt on the first call after the map is modified.
+func RandomByValue[K comparable, T Number](m *Map[K, T]) (key K, value T) {
+	m.mx.RLock()
+	if a := m.alias; a != nil && a.ver == m.ver {
+		defer m.mx.RUnlock()
+		key = a.random()
+		return key, m.vals[key]
+	}
+	m.mx.RUnlock()
+
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if m.alias == nil || m.alias.ver != m.ver {
+		keys := make([]K, 0, len(m.vals))
+		for k, v := range m.vals {
+			if v > 0 {
+				keys = append(keys, k)
+			}
+		}
+		if m.opts.rand != nil {
+			sortKeys(keys)
+		}
+		weights := make([]float64, len(keys))
+		for i, k := range keys {
+			weights[i] = float64(m.vals[k])
+		}
+		m.alias = newAliasTable(keys, weights, m.opts.rand)
+		m.alias.ver = m.ver
+	}
+	key = m.alias.random()
+	return key, m.vals[key]
+}
+
+// aliasTable implements Vose's alias method for O(1) weighted sampling.
+type aliasTable[K any] struct {
+	ver   uint64
+	rnd   *randSource
+	keys  []K
+	prob  []float64
+	alias []int
+}
+
+func newAliasTable[K any](keys []K, weights []float64, rnd *randSource) *aliasTable[K] {
+	n := len(keys)
+	a := &aliasTable[K]{rnd: rnd, keys: keys, prob: make([]float64, n), alias: make([]int, n)}
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		a.keys = nil
+		return a
+	}
+	scaled := make([]float64, n)
+	var small, large []int
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	for len(small) > 0 && len(large) > 0 {
+		s, l := small[len(small)-1], large[len(large)-1]
+		small = small[:len(small)-1]
+		a.prob[s], a.alias[s] = scaled[s], l
+		if scaled[l] += scaled[s] - 1; scaled[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+	for _, i := range append(small, large...) {
+		a.prob[i] = 1
+	}
+	return a
+}
+
+func (a *aliasTable[K]) random() (key K) {
+	if len(a.keys) == 0 {
+		return
+	}
+	i := a.rnd.intn(len(a.keys))
+	if a.rnd.float64() < a.prob[i] {
+		return a.keys[i]
+	}
+	return a.keys[a.alias[i]]
+}
@@ -0,0 +1,33 @@
+package xsync
+
+import "testing"
+
+func TestMap_RandomWeighted(t *testing.T) {
+	m := NewMap(map[string]int{"a": 0, "b": 1, "c": 3})
+	cnt := map[string]int{}
+
+	for i := 0; i < 4000; i++ {
+		k, _ := m.RandomWeighted(func(k string, v int) float64 { return float64(v) })
+		cnt[k]++
+	}
+
+	require(t, 0 == cnt["a"])
+	require(t, cnt["c"] > 2*cnt["b"])
+}
+
+func TestRandomByValue(t *testing.T) {
+	m := NewMap(map[string]float64{"a": 0, "b": 1, "c": 3})
+	cnt := map[string]int{}
+
+	for i := 0; i < 4000; i++ {
+		k, _ := RandomByValue(&m)
+		cnt[k]++
+	}
+
+	require(t, 0 == cnt["a"])
+	require(t, cnt["c"] > 2*cnt["b"])
+
+	m.Set("c", 0)
+	k, v := RandomByValue(&m)
+	require(t, "b" == k && 1 == v)
+}
@@ -0,0 +1,168 @@
+package xsync
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// windowSamples is the number of values a bucket of a window stripe keeps for percentiles.
+const windowSamples = 128
+
+// A Window aggregates values recorded over a sliding time window.
+// The window is divided into buckets expiring one at a time, and every bucket is striped
+// over cache-line-padded locks, so concurrent writers rarely contend.
+//
+// Sum and Count are exact up to the bucket granularity. Percentiles are estimated from
+// a bounded sample of each bucket, so memory does not grow with the recording rate.
+//
+// A Window is safe for use by multiple goroutines simultaneously.
+type Window[T Number] struct {
+	width   time.Duration // of a bucket
+	clock   Clock
+	stripes []windowStripe[T]
+}
+
+type windowStripe[T Number] struct {
+	mx      sync.Mutex
+	buckets []windowBucket[T]
+	_       [64]byte
+}
+
+type windowBucket[T Number] struct {
+	idx     int64 // index of the bucket interval since the epoch
+	count   uint64
+	sum     T
+	samples []T
+}
+
+// NewWindow returns a Window of the given size divided into the given number of buckets.
+// size must be positive; a non-positive number of buckets defaults to 10.
+func NewWindow[T Number](size time.Duration, buckets int, opts ...ClockOption) *Window[T] {
+	if buckets <= 0 {
+		buckets = 10
+	}
+	w := &Window[T]{
+		width:   max(size/time.Duration(buckets), 1),
+		clock:   newClock(opts),
+		stripes: make([]windowStripe[T], stripeCount()),
+	}
+	for i := range w.stripes {
+		w.stripes[i].buckets = make([]windowBucket[T], buckets)
+	}
+	return w
+}
+
+// Size returns the length of the window.
+func (w *Window[T]) Size() time.Duration {
+	return w.width * time.Duration(len(w.stripes[0].buckets))
+}
+
+// Add records v at the current time.
+func (w *Window[T]) Add(v T) {
+	w.AddAt(w.clock.Now(), v)
+}
+
+// AddAt records v at time t. Values older than the window, or newer than the current time,
+// are ignored.
+func (w *Window[T]) AddAt(t time.Time, v T) {
+	idx, now := w.index(t), w.index(w.clock.Now())
+	n := int64(len(w.stripes[0].buckets))
+	if idx <= now-n || idx > now {
+		return
+	}
+	s := &w.stripes[rand.Uint32()&uint32(len(w.stripes)-1)]
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	b := &s.buckets[(idx%n+n)%n] // idx is negative before the epoch
+	if b.idx != idx {
+		if b.idx > idx { // the slot already holds a newer bucket
+			return
+		}
+		b.idx, b.count, b.sum, b.samples = idx, 0, 0, b.samples[:0]
+	}
+	b.count++
+	b.sum += v
+	if len(b.samples) < windowSamples {
+		b.samples = append(b.samples, v)
+	} else if j := rand.Uint64N(b.count); j < windowSamples { // reservoir sampling
+		b.samples[j] = v
+	}
+}
+
+func (w *Window[T]) index(t time.Time) int64 {
+	return t.UnixNano() / int64(w.width)
+}
+
+// each calls fn for each bucket within the window, with the stripe locked.
+func (w *Window[T]) each(fn func(b *windowBucket[T])) {
+	now := w.index(w.clock.Now())
+	for i := range w.stripes {
+		s := &w.stripes[i]
+		s.mx.Lock()
+		for j := range s.buckets {
+			if b := &s.buckets[j]; b.count > 0 && b.idx > now-int64(len(s.buckets)) && b.idx <= now {
+				fn(b)
+			}
+		}
+		s.mx.Unlock()
+	}
+}
+
+// Count returns the number of values recorded within the window.
+func (w *Window[T]) Count() (n uint64) {
+	w.each(func(b *windowBucket[T]) { n += b.count })
+	return
+}
+
+// Sum returns the sum of the values recorded within the window.
+func (w *Window[T]) Sum() (sum T) {
+	w.each(func(b *windowBucket[T]) { sum += b.sum })
+	return
+}
+
+// Mean returns the mean of the values recorded within the window, or 0 if there are none.
+func (w *Window[T]) Mean() float64 {
+	var sum float64
+	var n uint64
+	w.each(func(b *windowBucket[T]) {
+		sum += float64(b.sum)
+		n += b.count
+	})
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// Rate returns the number of values recorded within the window per second.
+func (w *Window[T]) Rate() float64 {
+	return float64(w.Count()) / w.Size().Seconds()
+}
+
+// Percentile returns an estimate of the p-th percentile (0 <= p <= 100) of the values
+// recorded within the window, or 0 if there are none.
+func (w *Window[T]) Percentile(p float64) T {
+	var vals []T
+	w.each(func(b *windowBucket[T]) { vals = append(vals, b.samples...) })
+	if len(vals) == 0 {
+		return 0
+	}
+	slices.Sort(vals)
+	i := int(math.Ceil(p/100*float64(len(vals)))) - 1 // nearest rank
+	return vals[min(max(i, 0), len(vals)-1)]
+}
+
+// Reset removes all recorded values.
+func (w *Window[T]) Reset() {
+	for i := range w.stripes {
+		s := &w.stripes[i]
+		s.mx.Lock()
+		for j := range s.buckets {
+			s.buckets[j] = windowBucket[T]{}
+		}
+		s.mx.Unlock()
+	}
+}
@@ -0,0 +1,51 @@
+package xsync
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	c := NewFakeClock(time.Unix(1000, 0))
+	w := NewWindow[int](10*time.Second, 10, WithClock(c))
+	require(t, w.Size() == 10*time.Second && w.Count() == 0 && w.Percentile(50) == 0)
+
+	for i := 1; i <= 100; i++ {
+		w.Add(i)
+	}
+	require(t, w.Count() == 100 && w.Sum() == 5050 && w.Mean() == 50.5)
+	require(t, w.Rate() == 10)
+	require(t, w.Percentile(50) == 50 && w.Percentile(99) == 99 && w.Percentile(100) == 100 && w.Percentile(0) == 1)
+
+	c.Advance(5 * time.Second)
+	w.Add(1000)
+	require(t, w.Count() == 101)
+
+	c.Advance(5 * time.Second) // the first values expire
+	require(t, w.Count() == 1 && w.Sum() == 1000)
+
+	w.AddAt(c.Now().Add(-time.Minute), 1) // too old
+	w.AddAt(c.Now().Add(time.Minute), 1)  // in the future
+	require(t, w.Count() == 1)
+
+	w.Reset()
+	require(t, w.Count() == 0)
+}
+
+func TestWindow_concurrent(t *testing.T) {
+	w := NewWindow[float64](time.Hour, 4)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				w.Add(1)
+			}
+			w.Percentile(90)
+		}()
+	}
+	wg.Wait()
+	require(t, w.Count() == 8000 && w.Sum() == 8000 && w.Percentile(90) == 1)
+}
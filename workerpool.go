@@ -0,0 +1,109 @@
+package xsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrStopped = errors.New("xsync: stopped")
+
+// A WorkerPool runs submitted tasks on a fixed number of worker goroutines.
+//
+// A WorkerPool is safe for use by multiple goroutines simultaneously.
+type WorkerPool struct {
+	mx      sync.Mutex
+	stopped bool
+	tasks   chan func()
+	done    chan struct{}  // closed by Stop to abort blocked submissions
+	senders sync.WaitGroup // submissions in progress; tasks is closed when they are over
+	wg      sync.WaitGroup
+}
+
+// NewWorkerPool starts a pool of workers goroutines with a task queue of queueSize.
+func NewWorkerPool(workers, queueSize int) *WorkerPool {
+	p := &WorkerPool{tasks: make(chan func(), queueSize), done: make(chan struct{})}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *WorkerPool) worker() {
+	defer p.wg.Done()
+	for fn := range p.tasks {
+		fn()
+	}
+}
+
+// Submit enqueues fn, blocking while the queue is full.
+// Returns ErrStopped if the pool is stopped, also while waiting for the queue.
+func (p *WorkerPool) Submit(fn func()) error {
+	return p.submit(context.Background(), fn)
+}
+
+// submit enqueues fn, blocking while the queue is full until the pool is stopped or ctx is done.
+func (p *WorkerPool) submit(ctx context.Context, fn func()) error {
+	p.mx.Lock()
+	if p.stopped {
+		p.mx.Unlock()
+		return ErrStopped
+	}
+	p.senders.Add(1)
+	p.mx.Unlock()
+	defer p.senders.Done()
+
+	select {
+	case p.tasks <- fn:
+		return nil
+	case <-p.done:
+		return ErrStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SubmitWait enqueues fn and blocks until it has been executed or ctx is done.
+func (p *WorkerPool) SubmitWait(ctx context.Context, fn func()) error {
+	done := make(chan struct{})
+	if err := p.submit(ctx, func() {
+		defer close(done)
+		fn()
+	}); err != nil {
+		return err
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop stops accepting new tasks and waits until all queued tasks are executed or ctx is done.
+// Submissions blocked on a full queue return ErrStopped.
+func (p *WorkerPool) Stop(ctx context.Context) error {
+	p.mx.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.done)
+		go func() {
+			p.senders.Wait()
+			close(p.tasks)
+		}()
+	}
+	p.mx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,53 @@
+package xsync
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool_Stop(t *testing.T) {
+	var cnt atomic.Int32
+	p := NewWorkerPool(4, 10)
+
+	for i := 0; i < 100; i++ {
+		require(t, nil == p.Submit(func() { cnt.Add(1) }))
+	}
+	err := p.Stop(context.Background())
+
+	require(t, err == nil)
+	require(t, 100 == cnt.Load())
+	require(t, ErrStopped == p.Submit(func() {}))
+}
+
+func TestWorkerPool_SubmitWait(t *testing.T) {
+	p := NewWorkerPool(1, 0)
+	defer p.Stop(context.Background())
+	var done bool
+
+	err := p.SubmitWait(context.Background(), func() { done = true })
+
+	require(t, err == nil)
+	require(t, done)
+}
+
+func TestWorkerPool_fullQueue(t *testing.T) {
+	p := NewWorkerPool(1, 0)
+	release := make(chan struct{})
+	require(t, nil == p.Submit(func() { <-release }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require(t, context.DeadlineExceeded == p.SubmitWait(ctx, func() {}))
+
+	blocked := make(chan error)
+	go func() { blocked <- p.Submit(func() {}) }()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require(t, context.DeadlineExceeded == p.Stop(ctx)) // the running task blocks the worker
+	require(t, ErrStopped == <-blocked)
+
+	close(release)
+	require(t, nil == p.Stop(context.Background()))
+}
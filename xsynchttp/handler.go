@@ -0,0 +1,163 @@
+// Package xsynchttp exposes an xsync.Map as a REST resource.
+package xsynchttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goldic/xsync"
+)
+
+// An Option configures a handler.
+type Option func(*options)
+
+type options struct {
+	readOnly     bool
+	maxBodyBytes int64
+}
+
+// DefaultMaxBodyBytes is the default limit of PUT request bodies.
+const DefaultMaxBodyBytes = 1 << 20
+
+// ReadOnly disables PUT and DELETE requests.
+func ReadOnly() Option {
+	return func(o *options) {
+		o.readOnly = true
+	}
+}
+
+// MaxBodyBytes limits the size of PUT request bodies; larger bodies are rejected with 413.
+// The default is DefaultMaxBodyBytes.
+func MaxBodyBytes(n int64) Option {
+	return func(o *options) {
+		o.maxBodyBytes = n
+	}
+}
+
+type handler[T any] struct {
+	m    *xsync.Map[string, T]
+	opts options
+}
+
+// NewHandler returns a handler serving m with JSON bodies:
+//
+//	GET    /      - all entries as a JSON object
+//	GET    /{key} - value of the key
+//	PUT    /{key} - set value of the key from the request body
+//	DELETE /{key} - delete the key
+//
+// Responses carry an ETag derived from the map version. GET supports If-None-Match,
+// PUT and DELETE support If-Match (optimistic concurrency). PUT bodies are limited by MaxBodyBytes.
+// Mount the handler with http.StripPrefix when serving it under a path prefix.
+func NewHandler[T any](m *xsync.Map[string, T], opts ...Option) http.Handler {
+	h := &handler[T]{m: m, opts: options{maxBodyBytes: DefaultMaxBodyBytes}}
+	for _, fn := range opts {
+		fn(&h.opts)
+	}
+	return h
+}
+
+func etag(ver uint64) string {
+	return `"` + strconv.FormatUint(ver, 10) + `"`
+}
+
+func (h *handler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		ver := h.m.Version()
+		if match := r.Header.Get("If-None-Match"); match != "" && match == etag(ver) {
+			w.Header().Set("ETag", etag(ver))
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		var v any
+		if key == "" {
+			v = h.m.KeyValues()
+		} else if val, ok := h.m.Lookup(key); ok {
+			v = val
+		} else {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, ver, v)
+
+	case http.MethodPut, http.MethodDelete:
+		if h.opts.readOnly {
+			http.Error(w, "read-only", http.StatusMethodNotAllowed)
+			return
+		}
+		if key == "" {
+			http.Error(w, "key required", http.StatusMethodNotAllowed)
+			return
+		}
+		var val T
+		if r.Method == http.MethodPut {
+			body := http.MaxBytesReader(w, r.Body, h.opts.maxBodyBytes)
+			if err := json.NewDecoder(body).Decode(&val); err != nil {
+				status := http.StatusBadRequest
+				if errors.As(err, new(*http.MaxBytesError)) {
+					status = http.StatusRequestEntityTooLarge
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+		}
+		match := r.Header.Get("If-Match")
+		ver, err := h.write(r.Method, key, val, match)
+		switch {
+		case errors.Is(err, xsync.ErrVersionMismatch):
+			http.Error(w, "version mismatch", http.StatusPreconditionFailed)
+			return
+		case errors.Is(err, xsync.ErrKeyNotFound):
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag(ver))
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// write applies a PUT or DELETE and returns the map version after it.
+// With an If-Match precondition the version check and the write happen atomically.
+func (h *handler[T]) write(method, key string, val T, match string) (uint64, error) {
+	if match != "" && match != "*" {
+		ver, err := strconv.ParseUint(strings.Trim(match, `"`), 10, 64)
+		if err != nil || match != etag(ver) {
+			return h.m.Version(), xsync.ErrVersionMismatch
+		}
+		if method == http.MethodPut {
+			return h.m.SetIfVersion(key, val, ver)
+		}
+		return h.m.DeleteIfVersion(key, ver)
+	}
+	if method == http.MethodPut {
+		h.m.Set(key, val)
+	} else {
+		if !h.m.Exists(key) {
+			return h.m.Version(), xsync.ErrKeyNotFound
+		}
+		h.m.Delete(key)
+	}
+	return h.m.Version(), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, ver uint64, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(ver))
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,100 @@
+package xsynchttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goldic/xsync"
+)
+
+func do(h http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	var m xsync.Map[string, int]
+	h := NewHandler(&m)
+
+	require(t, 404 == do(h, "GET", "/a", "").Code)
+	require(t, 204 == do(h, "PUT", "/a", "123").Code)
+	require(t, 400 == do(h, "PUT", "/a", "abc").Code)
+	require(t, 123 == m.Get("a"))
+
+	w := do(h, "GET", "/a", "")
+	require(t, 200 == w.Code)
+	require(t, "123" == w.Body.String())
+	require(t, `"1"` == w.Header().Get("ETag"))
+
+	require(t, `{"a":123}` == do(h, "GET", "/", "").Body.String())
+	require(t, 204 == do(h, "DELETE", "/a", "").Code)
+	require(t, 404 == do(h, "DELETE", "/a", "").Code)
+	require(t, 0 == m.Len())
+}
+
+func TestHandler_ETag(t *testing.T) {
+	m := xsync.NewMapPtr(map[string]string{"a": "x"})
+	h := NewHandler(m)
+
+	require(t, 304 == do(h, "GET", "/a", "", "If-None-Match", `"0"`).Code)
+	require(t, 412 == do(h, "PUT", "/a", `"y"`, "If-Match", `"5"`).Code)
+	require(t, 204 == do(h, "PUT", "/a", `"y"`, "If-Match", `"0"`).Code)
+	require(t, "y" == m.Get("a"))
+
+	w := do(h, "DELETE", "/a", "", "If-Match", `"1"`)
+	require(t, 204 == w.Code && `"2"` == w.Header().Get("ETag"))
+	require(t, 404 == do(h, "DELETE", "/a", "", "If-Match", `"2"`).Code)
+}
+
+func TestHandler_IfMatchConcurrent(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		m := xsync.NewMapPtr(map[string]int{"a": 1})
+		h := NewHandler(m)
+		codes := make(chan int, 2)
+		var wg sync.WaitGroup
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- do(h, "PUT", "/a", strconv.Itoa(j), "If-Match", `"0"`).Code
+			}()
+		}
+		wg.Wait()
+		a, b := <-codes, <-codes
+		require(t, a == 204 && b == 412 || a == 412 && b == 204)
+		require(t, m.Version() == 1)
+	}
+}
+
+func TestHandler_MaxBodyBytes(t *testing.T) {
+	var m xsync.Map[string, string]
+	h := NewHandler(&m, MaxBodyBytes(8))
+
+	require(t, 204 == do(h, "PUT", "/a", `"abc"`).Code)
+	require(t, 413 == do(h, "PUT", "/a", `"abcdefghij"`).Code)
+	require(t, "abc" == m.Get("a"))
+}
+
+func TestHandler_ReadOnly(t *testing.T) {
+	var m xsync.Map[string, int]
+	h := NewHandler(&m, ReadOnly())
+
+	require(t, 405 == do(h, "PUT", "/a", "1").Code)
+	require(t, 405 == do(h, "DELETE", "/a", "").Code)
+	require(t, 200 == do(h, "GET", "/", "").Code)
+}
+
+func require(t *testing.T, ok bool) {
+	if !ok {
+		t.Fatal()
+	}
+}
@@ -0,0 +1,98 @@
+// Package xsyncprom exports xsync.Stats in the Prometheus text exposition format.
+//
+// It does not depend on the Prometheus client library: an Exporter is an http.Handler
+// that can be scraped directly or mounted next to other metric handlers.
+package xsyncprom
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goldic/xsync"
+)
+
+// An Exporter exports registered container stats. A zero Exporter is ready to use.
+type Exporter struct {
+	mx    sync.RWMutex
+	stats map[string]*xsync.Stats
+}
+
+// Register registers stats under the container name, which becomes the `container` label value.
+func (e *Exporter) Register(name string, stats *xsync.Stats) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if e.stats == nil {
+		e.stats = map[string]*xsync.Stats{}
+	}
+	e.stats[name] = stats
+}
+
+func (e *Exporter) Unregister(name string) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	delete(e.stats, name)
+}
+
+// WriteTo writes all registered stats to w in the Prometheus text format.
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	e.mx.RLock()
+	names := make([]string, 0, len(e.stats))
+	for name := range e.stats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	stats := make([]*xsync.Stats, len(names))
+	for i, name := range names {
+		stats[i] = e.stats[name]
+	}
+	e.mx.RUnlock()
+
+	var buf bytes.Buffer
+	metric := func(name, typ, help string, fn func(label string, s *xsync.Stats)) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		for i, s := range stats {
+			fn(`container="`+escape(names[i])+`"`, s)
+		}
+	}
+	sample := func(name, labels string, v any) {
+		fmt.Fprintf(&buf, "%s{%s} %v\n", name, labels, v)
+	}
+	metric("xsync_operations_total", "counter", "Number of container operations.", func(l string, s *xsync.Stats) {
+		sample("xsync_operations_total", l+`,op="get"`, s.Gets.Load())
+		sample("xsync_operations_total", l+`,op="set"`, s.Sets.Load())
+		sample("xsync_operations_total", l+`,op="delete"`, s.Deletes.Load())
+	})
+	metric("xsync_lookups_total", "counter", "Number of get operations by key presence.", func(l string, s *xsync.Stats) {
+		sample("xsync_lookups_total", l+`,result="hit"`, s.Hits.Load())
+		sample("xsync_lookups_total", l+`,result="miss"`, s.Misses.Load())
+	})
+	metric("xsync_lock_acquisitions_total", "counter", "Number of container lock acquisitions.", func(l string, s *xsync.Stats) {
+		sample("xsync_lock_acquisitions_total", l, s.LockWaits.Load())
+	})
+	metric("xsync_lock_wait_seconds_total", "counter", "Total time spent waiting for the container lock.", func(l string, s *xsync.Stats) {
+		secs := time.Duration(s.LockWaitTime.Load()).Seconds()
+		sample("xsync_lock_wait_seconds_total", l, strconv.FormatFloat(secs, 'g', -1, 64))
+	})
+	metric("xsync_size", "gauge", "Number of entries in the container.", func(l string, s *xsync.Stats) {
+		sample("xsync_size", l, s.Size.Load())
+	})
+	return buf.WriteTo(w)
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.WriteTo(w)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string {
+	return labelEscaper.Replace(s)
+}
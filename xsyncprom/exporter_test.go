@@ -0,0 +1,34 @@
+package xsyncprom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/goldic/xsync"
+)
+
+func TestExporter_WriteTo(t *testing.T) {
+	var st xsync.Stats
+	var e Exporter
+	e.Register(`users"`, &st)
+	m := xsync.NewMapPtr[string, int](nil, xsync.WithMetrics(&st))
+	m.Set("a", 1)
+	m.Get("b")
+
+	var buf bytes.Buffer
+	_, err := e.WriteTo(&buf)
+	out := buf.String()
+
+	require(t, err == nil)
+	require(t, strings.Contains(out, `xsync_operations_total{container="users\"",op="set"} 1`))
+	require(t, strings.Contains(out, `xsync_lookups_total{container="users\"",result="miss"} 1`))
+	require(t, strings.Contains(out, `xsync_size{container="users\""} 1`))
+	require(t, strings.Contains(out, "# TYPE xsync_size gauge\n"))
+}
+
+func require(t *testing.T, ok bool) {
+	if !ok {
+		t.Fatal()
+	}
+}
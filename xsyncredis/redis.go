@@ -0,0 +1,224 @@
+// Package xsyncredis implements xsync.KV backed by a Redis hash.
+//
+// The package does not depend on a particular Redis client library:
+// any client can be adapted to the Client interface.
+package xsyncredis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/goldic/xsync"
+)
+
+// Client executes a Redis command, e.g. Do(ctx, "HGET", "key", "field").
+// Replies are expected as returned by common clients: nil, int64, string or []byte, and []any for arrays.
+//
+// For go-redis: ClientFunc(func(ctx context.Context, args ...any) (any, error) { return rdb.Do(ctx, args...).Result() }).
+type Client interface {
+	Do(ctx context.Context, args ...any) (any, error)
+}
+
+// ClientFunc adapts a function to the Client interface.
+type ClientFunc func(ctx context.Context, args ...any) (any, error)
+
+func (f ClientFunc) Do(ctx context.Context, args ...any) (any, error) {
+	return f(ctx, args...)
+}
+
+// Map is an xsync.KV stored in the Redis hash at Key. Keys and values are JSON-encoded.
+// The version is kept in a separate counter at Key+":ver".
+//
+// Since xsync.KV methods do not return errors, the first failure is stored and reported by Err.
+type Map[K comparable, T any] struct {
+	client Client
+	key    string
+	ctx    context.Context
+
+	mx  sync.Mutex
+	err error
+}
+
+var _ xsync.KV[string, int] = (*Map[string, int])(nil)
+
+// NewMap returns a Map stored in the Redis hash at key.
+func NewMap[K comparable, T any](client Client, key string) *Map[K, T] {
+	return &Map[K, T]{client: client, key: key, ctx: context.Background()}
+}
+
+// WithContext returns a shallow copy of the map whose commands use ctx.
+func (m *Map[K, T]) WithContext(ctx context.Context) *Map[K, T] {
+	return &Map[K, T]{client: m.client, key: m.key, ctx: ctx}
+}
+
+// Err returns the first error that occurred, if any.
+func (m *Map[K, T]) Err() error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.err
+}
+
+func (m *Map[K, T]) fail(err error) {
+	if err != nil {
+		m.mx.Lock()
+		if m.err == nil {
+			m.err = err
+		}
+		m.mx.Unlock()
+	}
+}
+
+func (m *Map[K, T]) do(args ...any) any {
+	res, err := m.client.Do(m.ctx, args...)
+	m.fail(err)
+	return res
+}
+
+func (m *Map[K, T]) verKey() string {
+	return m.key + ":ver"
+}
+
+func (m *Map[K, T]) Set(key K, value T) {
+	f, err := encodeKey(key)
+	if err != nil {
+		m.fail(err)
+		return
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		m.fail(err)
+		return
+	}
+	m.do("HSET", m.key, f, string(v))
+	m.do("INCR", m.verKey())
+}
+
+func (m *Map[K, T]) Get(key K) (value T) {
+	f, err := encodeKey(key)
+	if err != nil {
+		m.fail(err)
+		return
+	}
+	if s, ok := toString(m.do("HGET", m.key, f)); ok {
+		m.fail(json.Unmarshal([]byte(s), &value))
+	}
+	return
+}
+
+func (m *Map[K, T]) Exists(key K) bool {
+	f, err := encodeKey(key)
+	if err != nil {
+		m.fail(err)
+		return false
+	}
+	n, _ := toInt(m.do("HEXISTS", m.key, f))
+	return n == 1
+}
+
+func (m *Map[K, T]) Delete(key K) {
+	f, err := encodeKey(key)
+	if err != nil {
+		m.fail(err)
+		return
+	}
+	if n, _ := toInt(m.do("HDEL", m.key, f)); n > 0 {
+		m.do("INCR", m.verKey())
+	}
+}
+
+func (m *Map[K, T]) Clear() {
+	m.do("DEL", m.key)
+	m.do("INCR", m.verKey())
+}
+
+func (m *Map[K, T]) Len() int {
+	n, _ := toInt(m.do("HLEN", m.key))
+	return int(n)
+}
+
+func (m *Map[K, T]) Version() uint64 {
+	n, _ := toInt(m.do("GET", m.verKey()))
+	return uint64(n)
+}
+
+func (m *Map[K, T]) Keys() []K {
+	arr, _ := m.do("HKEYS", m.key).([]any)
+	keys := make([]K, 0, len(arr))
+	for _, f := range arr {
+		s, _ := toString(f)
+		k, err := decodeKey[K](s)
+		if err != nil {
+			m.fail(err)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (m *Map[K, T]) KeyValues() map[K]T {
+	arr, _ := m.do("HGETALL", m.key).([]any)
+	res := make(map[K]T, len(arr)/2)
+	for i := 0; i+1 < len(arr); i += 2 {
+		f, _ := toString(arr[i])
+		s, _ := toString(arr[i+1])
+		k, err := decodeKey[K](f)
+		if err != nil {
+			m.fail(err)
+			continue
+		}
+		var v T
+		if err = json.Unmarshal([]byte(s), &v); err != nil {
+			m.fail(err)
+			continue
+		}
+		res[k] = v
+	}
+	return res
+}
+
+// encodeKey encodes string keys as is, other keys as JSON.
+func encodeKey(key any) (string, error) {
+	if s, ok := key.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(key)
+	return string(b), err
+}
+
+func decodeKey[K any](s string) (key K, err error) {
+	if p, ok := any(&key).(*string); ok {
+		*p = s
+		return
+	}
+	err = json.Unmarshal([]byte(s), &key)
+	return
+}
+
+func toString(v any) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case []byte:
+		return string(s), true
+	}
+	return "", false
+}
+
+func toInt(v any) (int64, error) {
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return n, nil
+	case int:
+		return int64(n), nil
+	}
+	if s, ok := toString(v); ok {
+		return strconv.ParseInt(s, 10, 64)
+	}
+	return 0, fmt.Errorf("xsyncredis: unexpected reply %T", v)
+}
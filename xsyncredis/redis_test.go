@@ -0,0 +1,114 @@
+package xsyncredis
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/goldic/xsync"
+	"github.com/goldic/xsync/xsynctest"
+)
+
+// fakeRedis implements the hash and counter commands used by Map.
+type fakeRedis struct {
+	mx     sync.Mutex
+	hashes map[string]map[string]string
+	vals   map[string]string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{hashes: map[string]map[string]string{}, vals: map[string]string{}}
+}
+
+func (r *fakeRedis) Do(_ context.Context, args ...any) (any, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	s := func(i int) string { return args[i].(string) }
+	h := r.hashes[s(1)]
+	switch s(0) {
+	case "HSET":
+		if h == nil {
+			h = map[string]string{}
+			r.hashes[s(1)] = h
+		}
+		h[s(2)] = s(3)
+		return int64(1), nil
+	case "HGET":
+		if v, ok := h[s(2)]; ok {
+			return []byte(v), nil
+		}
+		return nil, nil
+	case "HEXISTS":
+		_, ok := h[s(2)]
+		if ok {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case "HDEL":
+		_, ok := h[s(2)]
+		delete(h, s(2))
+		if ok {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case "HLEN":
+		return int64(len(h)), nil
+	case "HKEYS":
+		var res []any
+		for k := range h {
+			res = append(res, k)
+		}
+		return res, nil
+	case "HGETALL":
+		var res []any
+		for k, v := range h {
+			res = append(res, k, v)
+		}
+		return res, nil
+	case "DEL":
+		delete(r.hashes, s(1))
+		return int64(1), nil
+	case "INCR":
+		n, _ := strconv.ParseInt(r.vals[s(1)], 10, 64)
+		r.vals[s(1)] = strconv.FormatInt(n+1, 10)
+		return n + 1, nil
+	case "GET":
+		if v, ok := r.vals[s(1)]; ok {
+			return v, nil
+		}
+		return nil, nil
+	}
+	panic(s(0))
+}
+
+func TestMap(t *testing.T) {
+	m := NewMap[int, []string](newFakeRedis(), "test")
+
+	m.Set(1, []string{"a"})
+	m.Set(2, []string{"b", "c"})
+	m.Set(3, nil)
+	m.Delete(3)
+
+	require(t, 2 == m.Len())
+	require(t, m.Exists(1))
+	require(t, !m.Exists(3))
+	require(t, "c" == m.Get(2)[1])
+	require(t, 2 == len(m.Keys()))
+	require(t, 2 == len(m.KeyValues()))
+	require(t, 4 == m.Version())
+
+	m.Clear()
+	require(t, 0 == m.Len())
+	require(t, nil == m.Err())
+}
+
+func require(t *testing.T, ok bool) {
+	if !ok {
+		t.Fatal()
+	}
+}
+
+func TestMap_conformance(t *testing.T) {
+	xsynctest.TestKV(t, func() xsync.KV[string, int] { return NewMap[string, int](newFakeRedis(), "test") })
+}
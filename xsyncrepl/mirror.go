@@ -0,0 +1,155 @@
+package xsyncrepl
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/goldic/xsync"
+)
+
+var errVersionGap = errors.New("xsyncrepl: version gap")
+
+// A Mirror is a read-only replica of a map published by a Server.
+type Mirror[K comparable, T any] struct {
+	dial   func(ctx context.Context) (io.ReadCloser, error)
+	retry  time.Duration
+	vals   xsync.ReadMostlyMap[K, T]
+	ver    atomic.Uint64
+	synced xsync.Event
+}
+
+// NewMirror returns a mirror reading the streams opened by dial. Call Run to start replication.
+func NewMirror[K comparable, T any](dial func(ctx context.Context) (io.ReadCloser, error)) *Mirror[K, T] {
+	return &Mirror[K, T]{dial: dial, retry: time.Second}
+}
+
+// SetRetryDelay sets the delay before reconnecting after a failed connection. The default is 1s.
+// It must be called before Run.
+func (c *Mirror[K, T]) SetRetryDelay(d time.Duration) {
+	c.retry = d
+}
+
+// Run replicates the map until ctx is done, reconnecting when a stream fails or a version gap is detected.
+// The mirror keeps serving the last received contents while disconnected.
+func (c *Mirror[K, T]) Run(ctx context.Context) error {
+	for {
+		err := c.sync(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == errVersionGap {
+			continue // resync immediately
+		}
+		select {
+		case <-time.After(c.retry):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sync reads one stream, applying the snapshot and the updates that follow it.
+func (c *Mirror[K, T]) sync(ctx context.Context) error {
+	rc, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	stop := context.AfterFunc(ctx, func() { rc.Close() })
+	defer stop()
+
+	dec := gob.NewDecoder(bufio.NewReader(rc))
+	for first := true; ; first = false {
+		var msg message[K, T]
+		if err = dec.Decode(&msg); err != nil {
+			return err
+		}
+		switch {
+		case msg.Snapshot:
+			c.vals.Replace(msg.Set)
+		case first:
+			return errors.New("xsyncrepl: stream does not start with a snapshot")
+		case msg.From != c.ver.Load():
+			return errVersionGap
+		default:
+			c.vals.Update(func(vals map[K]T) {
+				for k, v := range msg.Set {
+					vals[k] = v
+				}
+				for _, k := range msg.Deleted {
+					delete(vals, k)
+				}
+			})
+		}
+		c.ver.Store(msg.To)
+		c.synced.Set()
+	}
+}
+
+// WaitSynced waits until the first snapshot is received or ctx is done.
+func (c *Mirror[K, T]) WaitSynced(ctx context.Context) error {
+	return c.synced.Wait(ctx)
+}
+
+// Version returns the version of the source map the mirror is synchronized to.
+func (c *Mirror[K, T]) Version() uint64 {
+	return c.ver.Load()
+}
+
+func (c *Mirror[K, T]) Get(key K) T {
+	return c.vals.Get(key)
+}
+
+func (c *Mirror[K, T]) Lookup(key K) (T, bool) {
+	return c.vals.Lookup(key)
+}
+
+func (c *Mirror[K, T]) Exists(key K) bool {
+	return c.vals.Exists(key)
+}
+
+func (c *Mirror[K, T]) Len() int {
+	return c.vals.Len()
+}
+
+func (c *Mirror[K, T]) Keys() []K {
+	return c.vals.Keys()
+}
+
+func (c *Mirror[K, T]) KeyValues() map[K]T {
+	return c.vals.KeyValues()
+}
+
+// Range calls fn for each entry of the current contents until fn returns false.
+func (c *Mirror[K, T]) Range(fn func(key K, value T) bool) {
+	c.vals.Range(fn)
+}
+
+// HTTPDialer returns a dial function for NewMirror reading the stream served by Server.ServeHTTP at url.
+func HTTPDialer(client *http.Client, url string) func(ctx context.Context) (io.ReadCloser, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) (io.ReadCloser, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("xsyncrepl: unexpected status %s", resp.Status)
+		}
+		return resp.Body, nil
+	}
+}
@@ -0,0 +1,80 @@
+package xsyncrepl
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goldic/xsync"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	for i := 0; i < 200 && !cond(); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	require(t, cond())
+}
+
+func TestReplication(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := xsync.NewMapPtr(map[string]int{"a": 1, "b": 2})
+	srv := NewServer(m, WithInterval(time.Millisecond))
+	go srv.Run(ctx)
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+	defer cancel() // end the streams before closing the server
+
+	mirror := NewMirror[string, int](HTTPDialer(nil, hs.URL))
+	go mirror.Run(ctx)
+	require(t, nil == mirror.WaitSynced(ctx))
+	require(t, 1 == mirror.Get("a") && 2 == mirror.Len())
+
+	m.Set("c", 3)
+	m.Delete("a")
+	m.Set("b", 20)
+	waitFor(t, func() bool { return mirror.Version() == m.Version() })
+	require(t, !mirror.Exists("a") && 20 == mirror.Get("b") && 3 == mirror.Get("c"))
+}
+
+func TestMirror_versionGap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	streams := make(chan []*message[string, int], 2)
+	streams <- []*message[string, int]{
+		{To: 1, Snapshot: true, Set: map[string]int{"a": 1}},
+		{From: 3, To: 4, Set: map[string]int{"b": 2}}, // updates 1..3 were lost
+	}
+	streams <- []*message[string, int]{
+		{To: 4, Snapshot: true, Set: map[string]int{"b": 2}},
+	}
+	mirror := NewMirror[string, int](func(ctx context.Context) (io.ReadCloser, error) {
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		select {
+		case msgs := <-streams:
+			for _, msg := range msgs {
+				enc.Encode(msg)
+			}
+		default:
+		}
+		return io.NopCloser(&buf), nil
+	})
+	mirror.SetRetryDelay(time.Millisecond)
+	go mirror.Run(ctx)
+
+	waitFor(t, func() bool { return 4 == mirror.Version() })
+	require(t, !mirror.Exists("a") && 2 == mirror.Get("b") && 0 == len(streams))
+}
+
+func require(t *testing.T, ok bool) {
+	if !ok {
+		t.Fatal()
+	}
+}
@@ -0,0 +1,168 @@
+// Package xsyncrepl replicates an xsync.Map to read-only mirrors over any byte stream,
+// e.g. TCP connections or HTTP responses.
+//
+// A Server sends a new subscriber a snapshot of the map followed by incremental updates.
+// Every message carries the source map versions it moves the mirror from and to;
+// a Mirror that sees a version gap (e.g. after updates were dropped for a slow connection)
+// reconnects and resynchronizes from a fresh snapshot.
+//
+// Messages are gob-encoded, so keys and values must be gob-encodable.
+package xsyncrepl
+
+import (
+	"context"
+	"encoding/gob"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/goldic/xsync"
+)
+
+// A message moves a mirror from version From to version To.
+type message[K comparable, T any] struct {
+	From, To uint64
+	Snapshot bool // Set holds the full contents
+	Set      map[K]T
+	Deleted  []K
+}
+
+// An Option configures a Server.
+type Option func(*options)
+
+type options struct {
+	interval time.Duration
+	buffer   int
+	equal    any // func(a, b T) bool
+}
+
+// WithInterval sets how often the server checks the map for changes. The default is 100ms.
+func WithInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.interval = d
+	}
+}
+
+// WithBuffer sets the number of updates queued per connection.
+// A connection falling further behind loses updates and its mirror resynchronizes. The default is 64.
+func WithBuffer(n int) Option {
+	return func(o *options) {
+		o.buffer = n
+	}
+}
+
+// WithEqual sets the function deciding whether a value has changed. reflect.DeepEqual is used by default.
+func WithEqual[T any](eq func(a, b T) bool) Option {
+	return func(o *options) {
+		o.equal = eq
+	}
+}
+
+// A Server publishes the contents of a map to mirrors.
+type Server[K comparable, T any] struct {
+	m     *xsync.Map[K, T]
+	opts  options
+	equal func(a, b T) bool
+
+	mx      sync.Mutex
+	seen    uint64  // last checked map version
+	ver     uint64  // version of the last published state
+	vals    map[K]T // last published state
+	updates xsync.Broadcast[*message[K, T]]
+}
+
+// NewServer returns a server publishing m. Call Run to start publishing updates.
+func NewServer[K comparable, T any](m *xsync.Map[K, T], opts ...Option) *Server[K, T] {
+	s := &Server[K, T]{m: m, opts: options{interval: 100 * time.Millisecond, buffer: 64}}
+	for _, fn := range opts {
+		fn(&s.opts)
+	}
+	s.equal = func(a, b T) bool { return reflect.DeepEqual(a, b) }
+	if s.opts.equal != nil {
+		s.equal = s.opts.equal.(func(a, b T) bool)
+	}
+	s.seen = m.Version()
+	s.ver, s.vals = s.seen, m.KeyValues()
+	return s
+}
+
+// Run publishes changes of the map until ctx is done and then closes all connections.
+func (s *Server[K, T]) Run(ctx context.Context) error {
+	defer s.updates.Close()
+	ticker := time.NewTicker(s.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			s.poll()
+		}
+	}
+}
+
+// poll publishes the changes made since the last poll.
+func (s *Server[K, T]) poll() {
+	ver := s.m.Version()
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if ver == s.seen {
+		return
+	}
+	s.seen = ver
+	vals := s.m.KeyValues() // may be newer than ver, which is then diffed again by the next poll
+
+	msg := &message[K, T]{From: s.ver, To: ver, Set: map[K]T{}}
+	for k, v := range vals {
+		if old, ok := s.vals[k]; !ok || !s.equal(old, v) {
+			msg.Set[k] = v
+		}
+	}
+	for k := range s.vals {
+		if _, ok := vals[k]; !ok {
+			msg.Deleted = append(msg.Deleted, k)
+		}
+	}
+	if len(msg.Set) == 0 && len(msg.Deleted) == 0 {
+		return
+	}
+	s.ver, s.vals = ver, vals
+	s.updates.Publish(msg)
+}
+
+// Serve writes a snapshot of the map and then the updates to w until ctx is done,
+// the server stops or writing fails. If w is an http.Flusher, every message is flushed.
+func (s *Server[K, T]) Serve(ctx context.Context, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.mx.Lock()
+	snap := &message[K, T]{To: s.ver, Snapshot: true, Set: maps.Clone(s.vals)}
+	updates := s.updates.Subscribe(ctx, xsync.WithBuffer(s.opts.buffer), xsync.WithDropping())
+	s.mx.Unlock()
+
+	enc := gob.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	for msg := snap; ; {
+		if err := enc.Encode(msg); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		var ok bool
+		if msg, ok = <-updates; !ok {
+			return ctx.Err()
+		}
+	}
+}
+
+// ServeHTTP streams the map to the response body. Use HTTPDialer to connect a Mirror to it.
+func (s *Server[K, T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	s.Serve(r.Context(), w)
+}
@@ -0,0 +1,60 @@
+package xsynctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("xsynctest.update", false, "update golden files compared by RequireGoldenJSON")
+
+// RequireGoldenJSON fails the test if the JSON encoding of v differs from the golden file at path.
+// Both documents are normalized before the comparison, so formatting and the order of object keys
+// do not matter. Run the test with -xsynctest.update to write the file instead.
+func RequireGoldenJSON(t testing.TB, path string, v any) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal %T: %v", v, err)
+		return
+	}
+	got, err := normalizeJSON(data)
+	if err != nil {
+		t.Fatalf("normalize %T output: %v", v, err)
+		return
+	}
+	if *update {
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			err = os.WriteFile(path, got, 0o644)
+		}
+		if err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+		return
+	}
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+		return
+	}
+	if want, err := normalizeJSON(golden); err != nil {
+		t.Fatalf("golden file %s: %v", path, err)
+	} else if !bytes.Equal(got, want) {
+		t.Fatalf("json differs from golden file %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// normalizeJSON re-encodes a JSON document indented, with object keys sorted.
+func normalizeJSON(data []byte) ([]byte, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	return append(b, '\n'), err
+}
@@ -0,0 +1,187 @@
+package xsynctest
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/goldic/xsync"
+)
+
+// TestKV runs a conformance suite checking that a KV implementation behaves like xsync.Map.
+// newKV must return a new, empty KV on every call.
+//
+// The suite checks that:
+//   - random operation sequences agree with a plain map;
+//   - Version never decreases, and increases with every Set, every Delete of an existing key and every Clear;
+//   - concurrent updates are atomic: no write is lost or torn;
+//   - JSON and binary marshaling, if implemented, round-trip the contents.
+func TestKV(t *testing.T, newKV func() xsync.KV[string, int]) {
+	t.Run("Model", func(t *testing.T) { testKVModel(t, newKV) })
+	t.Run("Version", func(t *testing.T) { testKVVersion(t, newKV) })
+	t.Run("Concurrent", func(t *testing.T) { testKVConcurrent(t, newKV) })
+	t.Run("Marshal", func(t *testing.T) { testKVMarshal(t, newKV) })
+}
+
+func testKVModel(t *testing.T, newKV func() xsync.KV[string, int]) {
+	kv, model := newKV(), map[string]int{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		key := strconv.Itoa(r.Intn(20))
+		var op string
+		switch n := r.Intn(10); {
+		case n < 5:
+			op = fmt.Sprintf("Set(%q, %d)", key, i)
+			kv.Set(key, i)
+			model[key] = i
+		case n < 9:
+			op = fmt.Sprintf("Delete(%q)", key)
+			kv.Delete(key)
+			delete(model, key)
+		default:
+			op = "Clear()"
+			kv.Clear()
+			clear(model)
+		}
+		if got, want := kv.Get(key), model[key]; got != want {
+			t.Fatalf("after op %d %s: Get(%q) = %d, want %d", i, op, key, got, want)
+		}
+		if _, want := model[key]; kv.Exists(key) != want {
+			t.Fatalf("after op %d %s: Exists(%q) = %v, want %v", i, op, key, !want, want)
+		}
+		if got := kv.Len(); got != len(model) {
+			t.Fatalf("after op %d %s: Len() = %d, want %d", i, op, got, len(model))
+		}
+		keys, want := kv.Keys(), make([]string, 0, len(model))
+		for k := range model {
+			want = append(want, k)
+		}
+		slices.Sort(keys)
+		slices.Sort(want)
+		if !slices.Equal(keys, want) {
+			t.Fatalf("after op %d %s: Keys() = %v, want %v", i, op, keys, want)
+		}
+	}
+	RequireMapEqual(t, kv, model)
+}
+
+func testKVVersion(t *testing.T, newKV func() xsync.KV[string, int]) {
+	kv := newKV()
+	ver := kv.Version()
+	step := func(op string, mustIncrease bool, fn func()) {
+		t.Helper()
+		fn()
+		v := kv.Version()
+		if v < ver || mustIncrease && v == ver {
+			t.Fatalf("%s: version went from %d to %d", op, ver, v)
+		}
+		ver = v
+	}
+	step("Set", true, func() { kv.Set("a", 1) })
+	step("Set same value", true, func() { kv.Set("a", 1) })
+	step("Get", false, func() { kv.Get("a") })
+	step("Delete missing key", false, func() { kv.Delete("b") })
+	step("Delete", true, func() { kv.Delete("a") })
+	step("Clear", true, func() { kv.Clear() })
+}
+
+func testKVConcurrent(t *testing.T, newKV func() xsync.KV[string, int]) {
+	const goroutines, n = 8, 50
+
+	kv := newKV()
+	ver := kv.Version()
+	Hammer(t, goroutines, func(g int, _ *rand.Rand) {
+		for i := 0; i < n; i++ {
+			kv.Set(fmt.Sprintf("%d-%d", g, i), g*n+i) // distinct keys
+			kv.Set("shared", g*n+i)
+		}
+	})
+	if got := kv.Len(); got != goroutines*n+1 {
+		t.Fatalf("lost writes: Len() = %d, want %d", got, goroutines*n+1)
+	}
+	for k, v := range kv.KeyValues() {
+		var g, i int
+		if _, err := fmt.Sscanf(k, "%d-%d", &g, &i); err == nil && v != g*n+i {
+			t.Fatalf("torn write: %q = %d, want %d", k, v, g*n+i)
+		}
+	}
+	if v := kv.Get("shared"); v < 0 || v >= goroutines*n {
+		t.Fatalf("shared key holds a value never written: %d", v)
+	}
+	if v := kv.Version(); v < ver+2*goroutines*n {
+		t.Fatalf("version advanced by %d after %d concurrent writes", v-ver, 2*goroutines*n)
+	}
+
+	// readers must never observe a value that was not written
+	kv = newKV()
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			kv.Set("k", i*2)
+		}
+	}()
+	Hammer(t, 4, func(int, *rand.Rand) {
+		for i := 0; i < n; i++ {
+			if v := kv.Get("k"); v%2 != 0 || v < 0 {
+				t.Errorf("read a value never written: %d", v)
+				return
+			}
+		}
+	})
+	close(stop)
+	wg.Wait()
+}
+
+func testKVMarshal(t *testing.T, newKV func() xsync.KV[string, int]) {
+	src := newKV()
+	for i := 0; i < 10; i++ {
+		src.Set(strconv.Itoa(i), i*i)
+	}
+	want := src.KeyValues()
+	tested := false
+
+	if m, ok := src.(json.Marshaler); ok {
+		dst := newKV()
+		if u, ok := dst.(json.Unmarshaler); ok {
+			tested = true
+			data, err := m.MarshalJSON()
+			if err != nil {
+				t.Fatalf("MarshalJSON: %v", err)
+			}
+			if err = u.UnmarshalJSON(data); err != nil {
+				t.Fatalf("UnmarshalJSON: %v", err)
+			}
+			RequireMapEqual(t, dst, want)
+		}
+	}
+	if m, ok := src.(encoding.BinaryMarshaler); ok {
+		dst := newKV()
+		if u, ok := dst.(encoding.BinaryUnmarshaler); ok {
+			tested = true
+			data, err := m.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary: %v", err)
+			}
+			if err = u.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary: %v", err)
+			}
+			RequireMapEqual(t, dst, want)
+		}
+	}
+	if !tested {
+		t.Skip("the KV implements neither JSON nor binary marshaling")
+	}
+}
@@ -0,0 +1,101 @@
+// Package xsynctest provides helpers for testing code built on xsync containers:
+// content assertions, polling, concurrent stress runs and golden JSON files.
+package xsynctest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goldic/xsync"
+)
+
+// RequireMapEqual fails the test if the contents of m differ from want, listing the differing keys.
+func RequireMapEqual[K comparable, T any](t testing.TB, m xsync.KV[K, T], want map[K]T) {
+	t.Helper()
+	got := m.KeyValues()
+	var diff []string
+	for k, w := range want {
+		if g, ok := got[k]; !ok {
+			diff = append(diff, fmt.Sprintf("missing %v: want %v", k, w))
+		} else if !reflect.DeepEqual(g, w) {
+			diff = append(diff, fmt.Sprintf("%v: got %v, want %v", k, g, w))
+		}
+	}
+	for k, g := range got {
+		if _, ok := want[k]; !ok {
+			diff = append(diff, fmt.Sprintf("unexpected %v: %v", k, g))
+		}
+	}
+	if len(diff) > 0 {
+		slices.Sort(diff)
+		t.Fatalf("map contents differ:\n\t%s", strings.Join(diff, "\n\t"))
+	}
+}
+
+// RequireSetEqual fails the test if the keys of s differ from want, ignoring order and duplicates.
+func RequireSetEqual[K comparable](t testing.TB, s *xsync.Set[K], want ...K) {
+	t.Helper()
+	wantSet := make(map[K]bool, len(want))
+	for _, k := range want {
+		wantSet[k] = true
+	}
+	var diff []string
+	for _, k := range s.Values() {
+		if !wantSet[k] {
+			diff = append(diff, fmt.Sprintf("unexpected %v", k))
+		}
+		delete(wantSet, k)
+	}
+	for k := range wantSet {
+		diff = append(diff, fmt.Sprintf("missing %v", k))
+	}
+	if len(diff) > 0 {
+		slices.Sort(diff)
+		t.Fatalf("set contents differ:\n\t%s", strings.Join(diff, "\n\t"))
+	}
+}
+
+// Eventually polls cond until it returns true, failing the test if it does not within timeout.
+func Eventually(t testing.TB, cond func() bool, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for wait := time.Millisecond; !cond(); wait = min(2*wait, 50*time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %v", timeout)
+			return
+		}
+		time.Sleep(wait)
+	}
+}
+
+// Hammer runs fn in n goroutines released at the same time and waits for them.
+// Each goroutine gets its index and a random source seeded with it, so that an op mix
+// chosen from r is reproducible. A panic in fn fails the test instead of crashing the binary.
+//
+// Invariants that must hold after the concurrent run are checked by the caller when Hammer returns.
+func Hammer(t testing.TB, n int, fn func(g int, r *rand.Rand)) {
+	t.Helper()
+	var start, done sync.WaitGroup
+	start.Add(1)
+	done.Add(n)
+	for g := 0; g < n; g++ {
+		go func() {
+			defer done.Done()
+			defer func() {
+				if p := recover(); p != nil {
+					t.Errorf("goroutine %d panicked: %v", g, p)
+				}
+			}()
+			start.Wait()
+			fn(g, rand.New(rand.NewSource(int64(g))))
+		}()
+	}
+	start.Done()
+	done.Wait()
+}
@@ -0,0 +1,116 @@
+package xsynctest
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goldic/xsync"
+)
+
+func require(t *testing.T, ok bool) {
+	t.Helper()
+	if !ok {
+		t.Fatal()
+	}
+}
+
+// recorder is a testing.TB capturing failures instead of stopping the test.
+type recorder struct {
+	testing.TB
+	failed atomic.Bool
+	msg    atomic.Value
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed.Store(true)
+	r.msg.Store(fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+func (r *recorder) message() string {
+	s, _ := r.msg.Load().(string)
+	return s
+}
+
+func TestRequireMapEqual(t *testing.T) {
+	m := xsync.NewMapPtr(map[string]int{"a": 1, "b": 2})
+	RequireMapEqual[string, int](t, m, map[string]int{"a": 1, "b": 2})
+
+	r := &recorder{TB: t}
+	RequireMapEqual[string, int](r, m, map[string]int{"a": 3, "c": 1})
+	require(t, r.failed.Load())
+	require(t, strings.Contains(r.message(), "a: got 1, want 3\n\tmissing c: want 1\n\tunexpected b: 2"))
+}
+
+func TestRequireSetEqual(t *testing.T) {
+	s := xsync.NewSetPtr([]int{1, 2})
+	RequireSetEqual(t, s, 2, 1, 1)
+
+	r := &recorder{TB: t}
+	RequireSetEqual(r, s, 1, 3)
+	require(t, r.failed.Load() && strings.Contains(r.message(), "missing 3\n\tunexpected 2"))
+}
+
+func TestEventually(t *testing.T) {
+	var n atomic.Int32
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		n.Store(1)
+	}()
+	Eventually(t, func() bool { return n.Load() == 1 }, time.Second)
+
+	r := &recorder{TB: t}
+	Eventually(r, func() bool { return false }, 5*time.Millisecond)
+	require(t, r.failed.Load())
+}
+
+func TestHammer(t *testing.T) {
+	var m xsync.Map[int, int]
+	Hammer(t, 8, func(g int, r *rand.Rand) {
+		for i := 0; i < 1000; i++ {
+			switch k := r.Intn(10); r.Intn(3) {
+			case 0:
+				m.Set(k, g)
+			case 1:
+				m.Delete(k)
+			default:
+				m.Get(k)
+			}
+			runtime.Gosched()
+		}
+	})
+	require(t, m.Len() <= 10)
+
+	r := &recorder{TB: t}
+	Hammer(r, 2, func(g int, _ *rand.Rand) {
+		if g == 1 {
+			panic("boom")
+		}
+	})
+	require(t, r.failed.Load() && r.message() == "goroutine 1 panicked: boom")
+}
+
+func TestRequireGoldenJSON(t *testing.T) {
+	m := xsync.NewMapPtr(map[string]any{"a": map[string]bool{"x": true}, "b": []int{1, 2}})
+	RequireGoldenJSON(t, filepath.Join("testdata", "map.json"), m)
+
+	r := &recorder{TB: t}
+	m.Set("c", 1)
+	RequireGoldenJSON(r, filepath.Join("testdata", "map.json"), m)
+	require(t, r.failed.Load() && strings.Contains(r.message(), `"c": 1`))
+}
+
+func TestTestKV(t *testing.T) {
+	TestKV(t, func() xsync.KV[string, int] { return xsync.NewMapPtr[string, int](nil) })
+}